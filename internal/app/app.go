@@ -1,11 +1,14 @@
 package app
 
 import (
+	"context"
 	"go-app-marketplace/internal/app/config"
 	"go-app-marketplace/internal/app/connections"
 	"go-app-marketplace/internal/app/start"
 	"go-app-marketplace/internal/deliveries/http"
+	"go-app-marketplace/internal/payments"
 	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/internal/scheduler"
 	"go-app-marketplace/internal/services"
 	"go-app-marketplace/internal/usecases"
 	"log"
@@ -26,48 +29,126 @@ func Run(configFiles ...string) {
 	defer conns.Close()
 
 	// Dependency injection
+	uow := repositories.NewUnitOfWork(conns.DB)
+
 	userRepo := repositories.NewUserPostgresRepo(conns.DB)
 	userUC := usecases.NewUserUseCase(userRepo)
 	userService := services.NewUserService(userUC, cfg.JWTSecret)
 
+	addressRepo := repositories.NewAddressRepository(conns.DB)
+	addressUC := usecases.NewAddressUsecase(addressRepo)
+	addressService := services.NewAddressService(addressUC)
+
 	productRepo := repositories.NewProductRepository(conns.DB)
 	productUC := usecases.NewProductUseCase(productRepo)
 	productService := services.NewProductService(productUC)
 
+	categoryRepo := repositories.NewCategoryRepository(conns.DB)
+	categoryUC := usecases.NewCategoryUsecase(categoryRepo)
+	categoryService := services.NewCategoryService(categoryUC)
+
+	variantRepo := repositories.NewVariantRepository(conns.DB)
+	variantUC := usecases.NewVariantUsecase(variantRepo)
+	variantService := services.NewVariantService(variantUC)
+
 	offerRepo := repositories.NewOfferRepository(conns.DB)
 	offerUC := usecases.NewOfferUseCase(offerRepo)
 	offerService := services.NewOfferService(offerUC)
 
 	cartRepo := repositories.NewCartRepository(conns.DB)
-	cartUC := usecases.NewCartUseCase(cartRepo, offerRepo)
+	exchangeRateRepo := repositories.NewExchangeRateRepository(conns.DB)
+	cartUC := usecases.NewCartUseCase(cartRepo, offerRepo, exchangeRateRepo)
 	cartService := services.NewCartService(cartUC)
 
+	reservationRepo := repositories.NewReservationRepository(conns.DB)
+
+	shippingRepo := repositories.NewShippingRepository(conns.DB)
+	shippingUC := usecases.NewShippingUsecase(shippingRepo)
+	shippingService := services.NewShippingService(shippingUC)
+
+	shipmentRepo := repositories.NewShipmentRepository(conns.DB)
+	shipmentUC := usecases.NewShipmentUsecase(uow, shipmentRepo)
+	shipmentService := services.NewShipmentService(shipmentUC)
+
 	orderRepo := repositories.NewOrderRepository(conns.DB)
-	orderUC := usecases.NewOrderUsecase(orderRepo, cartRepo, offerRepo)
-	orderService := services.NewOrderService(orderUC)
+	orderEventRepo := repositories.NewOrderEventRepository(conns.DB)
+	orderUC := usecases.NewOrderUsecase(uow, orderRepo, cartRepo, offerRepo, reservationRepo, shipmentRepo, orderEventRepo, cfg.Reservation.TTL)
+	orderService := services.NewOrderService(orderUC, cfg.JWTSecret, cfg.Quote.TTL)
 
-	// Stripe Payment Service
-	paymentService := services.NewPaymentService(cfg.StripeSecretKey, cfg.StripeWebhookSecret)
+	// Payment Service
+	var gateway payments.Gateway
+	switch cfg.Payment.Provider {
+	case payments.ProviderFake:
+		gateway = payments.NewFakeGateway(cfg.Payment.FakeBaseURL, cfg.Payment.FakeWebhookSecret)
+	case payments.ProviderStripe:
+		if err := payments.ValidateStripeSessionTTL(cfg.Reservation.TTL); err != nil {
+			log.Fatalf("Invalid RESERVATION_TTL: %v", err)
+		}
+		gateway = payments.NewStripeGateway(cfg.StripeSecretKey, cfg.StripeWebhookSecret)
+	default:
+		log.Fatalf("Unknown payment provider: %q", cfg.Payment.Provider)
+	}
+	paymentRepo := repositories.NewPaymentRepository(conns.DB)
+	paymentUC := usecases.NewPaymentUsecase(paymentRepo, orderRepo)
+	paymentService := services.NewPaymentService(gateway, paymentUC)
 
 	// Set the payment service on the order service to avoid circular dependency
 	orderService.SetPaymentService(paymentService)
 
 	// refund
 	refundRepo := repositories.NewRefundRepository(conns.DB)
-	refundUC := usecases.NewRefundUsecase(refundRepo, orderRepo)
-	refundService := services.NewRefundService(refundUC)
+	refundUC := usecases.NewRefundUsecase(uow, refundRepo, orderRepo)
+	refundService := services.NewRefundService(refundUC, paymentService)
+	orderService.SetRefundService(refundService)
+
+	// webhooks
+	webhookEventRepo := repositories.NewWebhookEventRepository(conns.DB)
+	webhookEventUC := usecases.NewWebhookEventUsecase(webhookEventRepo)
+	webhookService := services.NewWebhookService(webhookEventUC, orderService, paymentService, refundService)
+
+	// exchange rates
+	exchangeRateUC := usecases.NewExchangeRateUsecase(exchangeRateRepo)
+	exchangeRateService := services.NewExchangeRateService(exchangeRateUC)
+
+	// taxes
+	taxRepo := repositories.NewTaxRepository(conns.DB)
+	taxUC := usecases.NewTaxUsecase(taxRepo)
+	taxService := services.NewTaxService(taxUC)
+
 	// Wrap services
 	svc := &http.Services{
-		User:    userService,
-		Cart:    cartService,
-		Product: productService,
-		Offer:   offerService,
-		Order:   orderService,
-		Payment: paymentService,
-		Refund:  refundService,
-		JWTKey:  []byte(cfg.JWTSecret),
+		User:         userService,
+		Address:      addressService,
+		Cart:         cartService,
+		Product:      productService,
+		Category:     categoryService,
+		Variant:      variantService,
+		Offer:        offerService,
+		Order:        orderService,
+		Payment:      paymentService,
+		Refund:       refundService,
+		Shipping:     shippingService,
+		Shipment:     shipmentService,
+		Webhook:      webhookService,
+		ExchangeRate: exchangeRateService,
+		Tax:          taxService,
+		JWTKey:       []byte(cfg.JWTSecret),
 	}
 
+	// Background jobs
+	jobs := scheduler.New()
+	// Return stock held by unpaid orders once their reservation expires
+	jobs.Add("release_expired_reservations", cfg.Reservation.SweepInterval, orderService.ReleaseExpiredReservations)
+	// Cancel orders whose buyer abandoned the checkout page
+	jobs.Add("expire_pending_orders", cfg.OrderExpiry.Interval, func(ctx context.Context) (int64, error) {
+		return orderService.ExpireStalePendingOrders(ctx, cfg.OrderExpiry.TTL, cfg.OrderExpiry.BatchSize)
+	})
+	// Send again refunds whose provider call or its result got lost
+	jobs.Add("resubmit_stale_refunds", cfg.RefundResubmit.Interval, func(ctx context.Context) (int64, error) {
+		return refundService.ResubmitStale(ctx, cfg.RefundResubmit.After, cfg.RefundResubmit.BatchSize)
+	})
+	jobs.Start(context.Background())
+
 	// Router
 	router := http.NewRouter(svc)
 
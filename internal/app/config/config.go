@@ -3,23 +3,64 @@ package config
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	HTTPServer          HTTPServerConfig `envPrefix:"HTTP_"`
-	DB                  *DBConfig        `envPrefix:"DB_"`
-	JWTSecret           string           `env:"JWT_SECRET"`
-	StripeSecretKey     string           `env:"STRIPE_SECRET_KEY"`
-	StripeWebhookSecret string           `env:"STRIPE_WEBHOOK_SECRET"`
+	HTTPServer          HTTPServerConfig     `envPrefix:"HTTP_"`
+	DB                  *DBConfig            `envPrefix:"DB_"`
+	JWTSecret           string               `env:"JWT_SECRET"`
+	StripeSecretKey     string               `env:"STRIPE_SECRET_KEY"`
+	StripeWebhookSecret string               `env:"STRIPE_WEBHOOK_SECRET"`
+	Reservation         ReservationConfig    `envPrefix:"RESERVATION_"`
+	Payment             PaymentConfig        `envPrefix:"PAYMENT_"`
+	OrderExpiry         OrderExpiryConfig    `envPrefix:"ORDER_EXPIRY_"`
+	Quote               QuoteConfig          `envPrefix:"QUOTE_"`
+	RefundResubmit      RefundResubmitConfig `envPrefix:"REFUND_RESUBMIT_"`
 }
 
 type HTTPServerConfig struct {
 	Port string `env:"PORT" envDefault:"8080"`
 }
 
+// ReservationConfig controls how long checkout holds offer stock for an unpaid order.
+// Stripe sessions expire together with the reservation, so with the stripe provider
+// the app refuses to start unless TTL is between 31m and 24h.
+type ReservationConfig struct {
+	TTL           time.Duration `env:"TTL" envDefault:"1h"`
+	SweepInterval time.Duration `env:"SWEEP_INTERVAL" envDefault:"1m"`
+}
+
+// OrderExpiryConfig controls the job that cancels orders left unpaid for longer than TTL
+type OrderExpiryConfig struct {
+	TTL       time.Duration `env:"TTL" envDefault:"1h"`
+	Interval  time.Duration `env:"INTERVAL" envDefault:"5m"`
+	BatchSize int           `env:"BATCH_SIZE" envDefault:"100"`
+}
+
+// RefundResubmitConfig controls the job that sends again approved refunds
+// with no provider refund recorded after After
+type RefundResubmitConfig struct {
+	After     time.Duration `env:"AFTER" envDefault:"10m"`
+	Interval  time.Duration `env:"INTERVAL" envDefault:"5m"`
+	BatchSize int           `env:"BATCH_SIZE" envDefault:"100"`
+}
+
+// QuoteConfig controls how long a checkout quote keeps its prices
+type QuoteConfig struct {
+	TTL time.Duration `env:"TTL" envDefault:"15m"`
+}
+
+// PaymentConfig selects the payment provider: "stripe" or the local "fake" one
+type PaymentConfig struct {
+	Provider          string `env:"PROVIDER" envDefault:"stripe"`
+	FakeBaseURL       string `env:"FAKE_BASE_URL" envDefault:"http://localhost:8080"`
+	FakeWebhookSecret string `env:"FAKE_WEBHOOK_SECRET" envDefault:"whsec_fake"`
+}
+
 type DBConfig struct {
 	DSN string `env:"DB_DSN"`
 }
@@ -0,0 +1,236 @@
+package address
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/internal/services"
+	"go-app-marketplace/pkg/httpx"
+	"go-app-marketplace/pkg/reqresp"
+	"net/http"
+	"strconv"
+)
+
+var validate = validator.New()
+
+type AddressHandler struct {
+	addressService *services.AddressService
+}
+
+func NewAddressHandler(addressService *services.AddressService) *AddressHandler {
+	return &AddressHandler{addressService: addressService}
+}
+
+// @Summary List my addresses
+// @Description The address book of the current user, default address first
+// @Tags addresses
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} reqresp.AddressResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/me/addresses [get]
+func (h *AddressHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "Unauthorized", "Invalid user context")
+		return
+	}
+
+	addresses, err := h.addressService.List(r.Context(), userID)
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to list addresses", err.Error())
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Addresses retrieved successfully", addresses)
+}
+
+// @Summary Get an address
+// @Tags addresses
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Address ID"
+// @Success 200 {object} reqresp.AddressResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Router /api/me/addresses/{id} [get]
+func (h *AddressHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "Unauthorized", "Invalid user context")
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid address ID", err.Error())
+		return
+	}
+
+	address, err := h.addressService.Get(r.Context(), userID, id)
+	if err != nil {
+		writeAddressError(w, "Failed to get address", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Address retrieved successfully", address)
+}
+
+// @Summary Add an address
+// @Description The first address, or one sent with is_default, becomes the default
+// @Tags addresses
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body reqresp.AddressRequest true "Address"
+// @Success 201 {object} reqresp.AddressResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/me/addresses [post]
+func (h *AddressHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "Unauthorized", "Invalid user context")
+		return
+	}
+
+	var req reqresp.AddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if err := validate.Struct(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Validation failed", err.Error())
+		return
+	}
+
+	address, err := h.addressService.Create(r.Context(), userID, &req)
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to create address", err.Error())
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusCreated, "Address created successfully", address)
+}
+
+// @Summary Update an address
+// @Description Orders already placed keep the address they were checked out with
+// @Tags addresses
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Address ID"
+// @Param input body reqresp.AddressRequest true "Address"
+// @Success 200 {object} reqresp.AddressResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/me/addresses/{id} [put]
+func (h *AddressHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "Unauthorized", "Invalid user context")
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid address ID", err.Error())
+		return
+	}
+
+	var req reqresp.AddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if err := validate.Struct(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Validation failed", err.Error())
+		return
+	}
+
+	address, err := h.addressService.Update(r.Context(), userID, id, &req)
+	if err != nil {
+		writeAddressError(w, "Failed to update address", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Address updated successfully", address)
+}
+
+// @Summary Make an address the default
+// @Tags addresses
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Address ID"
+// @Success 200 {object} reqresp.StandardResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Router /api/me/addresses/{id}/default [post]
+func (h *AddressHandler) SetDefault(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "Unauthorized", "Invalid user context")
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid address ID", err.Error())
+		return
+	}
+
+	if err := h.addressService.SetDefault(r.Context(), userID, id); err != nil {
+		writeAddressError(w, "Failed to set default address", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Default address updated successfully", nil)
+}
+
+// @Summary Delete an address
+// @Description Deleting the default address makes the newest remaining one the default
+// @Tags addresses
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Address ID"
+// @Success 200 {object} reqresp.StandardResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Router /api/me/addresses/{id} [delete]
+func (h *AddressHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "Unauthorized", "Invalid user context")
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid address ID", err.Error())
+		return
+	}
+
+	if err := h.addressService.Delete(r.Context(), userID, id); err != nil {
+		writeAddressError(w, "Failed to delete address", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Address deleted successfully", nil)
+}
+
+func writeAddressError(w http.ResponseWriter, message string, err error) {
+	if errors.Is(err, repositories.ErrAddressNotFound) {
+		httpx.WriteError(w, http.StatusNotFound, "Address not found", err.Error())
+		return
+	}
+	httpx.WriteError(w, http.StatusInternalServerError, message, err.Error())
+}
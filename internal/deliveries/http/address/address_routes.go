@@ -0,0 +1,21 @@
+package address
+
+import (
+	"github.com/gorilla/mux"
+	"go-app-marketplace/internal/middleware"
+	"net/http"
+)
+
+func RegisterAddressRoutes(r *mux.Router, h *AddressHandler, jwtKey []byte) {
+
+	addresses := r.PathPrefix("/me/addresses").Subrouter()
+	addresses.Use(middleware.AuthMiddleware(jwtKey))
+
+	addresses.HandleFunc("", h.List).Methods(http.MethodGet)
+	addresses.HandleFunc("", h.Create).Methods(http.MethodPost)
+
+	addresses.HandleFunc("/{id:[0-9]+}", h.Get).Methods(http.MethodGet)
+	addresses.HandleFunc("/{id:[0-9]+}", h.Update).Methods(http.MethodPut)
+	addresses.HandleFunc("/{id:[0-9]+}", h.Delete).Methods(http.MethodDelete)
+	addresses.HandleFunc("/{id:[0-9]+}/default", h.SetDefault).Methods(http.MethodPost)
+}
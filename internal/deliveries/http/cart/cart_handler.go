@@ -2,7 +2,9 @@ package cart
 
 import (
 	"encoding/json"
+	"errors"
 	"go-app-marketplace/internal/services"
+	"go-app-marketplace/pkg/domain"
 	"go-app-marketplace/pkg/httpx"
 	"go-app-marketplace/pkg/reqresp"
 	"net/http"
@@ -61,14 +63,21 @@ func (h *CartHandler) AddItemToCart(w http.ResponseWriter, r *http.Request) {
 // @Summary Get cart items
 // @Tags Cart
 // @Security BearerAuth
+// @Description Prices are those the items were added at, also shown in the currency as display_price
 // @Produce json
-// @Success 200 {object} reqresp.StandardResponse
+// @Param currency query string false "Currency the prices are shown in" default(USD)
+// @Success 200 {object} reqresp.StandardResponse{data=[]reqresp.CartItemResponse}
+// @Failure 400 {object} reqresp.StandardResponse
 // @Failure 500 {object} reqresp.StandardResponse
 // @Router /api/cart [get]
 func (h *CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id").(int64)
 
-	cartItems, err := h.cartService.GetCart(r.Context(), userID)
+	cartItems, err := h.cartService.GetCart(r.Context(), userID, r.URL.Query().Get("currency"))
+	if errors.Is(err, domain.ErrUnsupportedCurrency) {
+		httpx.WriteError(w, http.StatusBadRequest, "Unsupported currency", err.Error())
+		return
+	}
 	if err != nil {
 		httpx.WriteError(w, http.StatusInternalServerError, "Failed to retrieve cart", err.Error())
 		return
@@ -77,8 +86,13 @@ func (h *CartHandler) GetCart(w http.ResponseWriter, r *http.Request) {
 	var resp []reqresp.CartItemResponse
 	for _, item := range cartItems {
 		resp = append(resp, reqresp.CartItemResponse{
-			OfferID:  item.OfferID,
-			Quantity: item.Quantity,
+			OfferID:      item.OfferID,
+			Price:        item.UnitPrice,
+			DisplayPrice: item.DisplayPrice,
+			Quantity:     item.Quantity,
+			VariantID:    item.VariantID,
+			SKU:          item.SKU,
+			Attributes:   item.Attributes,
 		})
 	}
 
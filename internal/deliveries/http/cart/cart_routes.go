@@ -10,6 +10,7 @@ func RegisterCartRoutes(r *mux.Router, h *CartHandler, jwtSecret []byte) {
 	cartRouter := r.PathPrefix("/cart").Subrouter()
 
 	cartRouter.Use(middleware.AuthMiddleware(jwtSecret))
+	cartRouter.Use(middleware.Idempotency())
 
 	cartRouter.HandleFunc("/add", h.AddItemToCart).Methods(http.MethodPost)
 	cartRouter.HandleFunc("", h.GetCart).Methods(http.MethodGet)
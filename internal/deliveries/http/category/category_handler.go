@@ -0,0 +1,387 @@
+package category
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/internal/services"
+	"go-app-marketplace/pkg/domain"
+	"go-app-marketplace/pkg/httpx"
+	"go-app-marketplace/pkg/reqresp"
+	"net/http"
+	"strconv"
+)
+
+var validate = validator.New()
+
+type CategoryHandler struct {
+	categoryService *services.CategoryService
+}
+
+func NewCategoryHandler(categoryService *services.CategoryService) *CategoryHandler {
+	return &CategoryHandler{categoryService: categoryService}
+}
+
+// @Summary Category tree
+// @Description Root categories with their subcategories. Product counts include the products of subcategories.
+// @Tags categories
+// @Produce json
+// @Success 200 {object} reqresp.StandardResponse{data=[]reqresp.CategoryTreeResponse}
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/categories [get]
+func (h *CategoryHandler) Tree(w http.ResponseWriter, r *http.Request) {
+	roots, err := h.categoryService.Tree(r.Context())
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to fetch categories", err.Error())
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Categories fetched successfully", toTreeResponses(roots))
+}
+
+// @Summary List categories
+// @Description All categories as a flat list, ordered by position and name
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} reqresp.StandardResponse{data=[]reqresp.CategoryResponse}
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/categories [get]
+func (h *CategoryHandler) List(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.categoryService.List(r.Context())
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to list categories", err.Error())
+		return
+	}
+
+	resp := make([]reqresp.CategoryResponse, 0, len(categories))
+	for _, c := range categories {
+		resp = append(resp, toCategoryResponse(c))
+	}
+	httpx.WriteSuccess(w, http.StatusOK, "Categories retrieved successfully", resp)
+}
+
+// @Summary Create a category
+// @Description Without a slug one is made from the name; without a parent the category is a root
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body reqresp.CategoryRequest true "Category"
+// @Success 201 {object} reqresp.StandardResponse{data=reqresp.CategoryResponse}
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/categories [post]
+func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req reqresp.CategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if err := validate.Struct(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Validation failed", err.Error())
+		return
+	}
+
+	category, err := h.categoryService.Create(r.Context(), req)
+	if err != nil {
+		writeCategoryError(w, "Failed to create category", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusCreated, "Category created successfully", toCategoryResponse(category))
+}
+
+// @Summary Update a category
+// @Description Replaces the category. Moving it under itself or one of its subcategories is refused.
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param input body reqresp.CategoryRequest true "Category"
+// @Success 200 {object} reqresp.StandardResponse{data=reqresp.CategoryResponse}
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/categories/{id} [put]
+func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid category ID", err.Error())
+		return
+	}
+
+	var req reqresp.CategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if err := validate.Struct(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Validation failed", err.Error())
+		return
+	}
+
+	category, err := h.categoryService.Update(r.Context(), id, req)
+	if err != nil {
+		writeCategoryError(w, "Failed to update category", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Category updated successfully", toCategoryResponse(category))
+}
+
+// @Summary Delete a category
+// @Description Only categories without subcategories can be deleted; their products simply leave them
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Category ID"
+// @Success 200 {object} reqresp.StandardResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/categories/{id} [delete]
+func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid category ID", err.Error())
+		return
+	}
+
+	if err := h.categoryService.Delete(r.Context(), id); err != nil {
+		writeCategoryError(w, "Failed to delete category", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Category deleted successfully", nil)
+}
+
+// @Summary List category attributes
+// @Description The attributes the category defines for its products' variants; subcategories inherit them
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Category ID"
+// @Success 200 {object} reqresp.StandardResponse{data=[]reqresp.AttributeResponse}
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/categories/{id}/attributes [get]
+func (h *CategoryHandler) ListAttributes(w http.ResponseWriter, r *http.Request) {
+	categoryID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid category ID", err.Error())
+		return
+	}
+
+	attributes, err := h.categoryService.ListAttributes(r.Context(), categoryID)
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to list attributes", err.Error())
+		return
+	}
+
+	resp := make([]reqresp.AttributeResponse, 0, len(attributes))
+	for _, a := range attributes {
+		resp = append(resp, toAttributeResponse(a))
+	}
+	httpx.WriteSuccess(w, http.StatusOK, "Attributes retrieved successfully", resp)
+}
+
+// @Summary Create a category attribute
+// @Description Type is text, number, boolean or enum; an enum lists its options
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param input body reqresp.AttributeRequest true "Attribute"
+// @Success 201 {object} reqresp.StandardResponse{data=reqresp.AttributeResponse}
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/categories/{id}/attributes [post]
+func (h *CategoryHandler) CreateAttribute(w http.ResponseWriter, r *http.Request) {
+	categoryID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid category ID", err.Error())
+		return
+	}
+
+	var req reqresp.AttributeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if err := validate.Struct(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Validation failed", err.Error())
+		return
+	}
+
+	attr, err := h.categoryService.CreateAttribute(r.Context(), categoryID, req)
+	if err != nil {
+		writeCategoryError(w, "Failed to create attribute", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusCreated, "Attribute created successfully", toAttributeResponse(attr))
+}
+
+// @Summary Update a category attribute
+// @Description Replaces the attribute. Existing variant values are checked again only when the variant is saved.
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param attributeID path int true "Attribute ID"
+// @Param input body reqresp.AttributeRequest true "Attribute"
+// @Success 200 {object} reqresp.StandardResponse{data=reqresp.AttributeResponse}
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/categories/{id}/attributes/{attributeID} [put]
+func (h *CategoryHandler) UpdateAttribute(w http.ResponseWriter, r *http.Request) {
+	categoryID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid category ID", err.Error())
+		return
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["attributeID"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid attribute ID", err.Error())
+		return
+	}
+
+	var req reqresp.AttributeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if err := validate.Struct(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Validation failed", err.Error())
+		return
+	}
+
+	attr, err := h.categoryService.UpdateAttribute(r.Context(), categoryID, id, req)
+	if err != nil {
+		writeCategoryError(w, "Failed to update attribute", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Attribute updated successfully", toAttributeResponse(attr))
+}
+
+// @Summary Delete a category attribute
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param attributeID path int true "Attribute ID"
+// @Success 200 {object} reqresp.StandardResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/categories/{id}/attributes/{attributeID} [delete]
+func (h *CategoryHandler) DeleteAttribute(w http.ResponseWriter, r *http.Request) {
+	categoryID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid category ID", err.Error())
+		return
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["attributeID"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid attribute ID", err.Error())
+		return
+	}
+
+	if err := h.categoryService.DeleteAttribute(r.Context(), categoryID, id); err != nil {
+		writeCategoryError(w, "Failed to delete attribute", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Attribute deleted successfully", nil)
+}
+
+func writeCategoryError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidCategory):
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid category", err.Error())
+	case errors.Is(err, domain.ErrInvalidAttribute):
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid attribute", err.Error())
+	case errors.Is(err, repositories.ErrCategoryNotFound):
+		httpx.WriteError(w, http.StatusNotFound, "Category not found", err.Error())
+	case errors.Is(err, repositories.ErrAttributeNotFound):
+		httpx.WriteError(w, http.StatusNotFound, "Attribute not found", err.Error())
+	case errors.Is(err, repositories.ErrAttributeExists),
+		errors.Is(err, repositories.ErrCategoryExists),
+		errors.Is(err, repositories.ErrCategoryHasChildren),
+		errors.Is(err, repositories.ErrCategoryCycle):
+		httpx.WriteError(w, http.StatusConflict, message, err.Error())
+	default:
+		httpx.WriteError(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
+func toCategoryResponse(c domain.Category) reqresp.CategoryResponse {
+	return reqresp.CategoryResponse{
+		ID:       c.ID,
+		ParentID: c.ParentID,
+		Name:     c.Name,
+		Slug:     c.Slug,
+		Position: c.Position,
+	}
+}
+
+func toAttributeResponse(a domain.CategoryAttribute) reqresp.AttributeResponse {
+	options := []string(a.Options)
+	if options == nil {
+		options = []string{}
+	}
+	return reqresp.AttributeResponse{
+		ID:         a.ID,
+		CategoryID: a.CategoryID,
+		Code:       a.Code,
+		Name:       a.Name,
+		Type:       string(a.Type),
+		Options:    options,
+		Position:   a.Position,
+	}
+}
+
+func toTreeResponses(nodes []*domain.CategoryNode) []reqresp.CategoryTreeResponse {
+	resp := make([]reqresp.CategoryTreeResponse, 0, len(nodes))
+	for _, n := range nodes {
+		resp = append(resp, reqresp.CategoryTreeResponse{
+			ID:           n.ID,
+			Name:         n.Name,
+			Slug:         n.Slug,
+			Position:     n.Position,
+			ProductCount: n.ProductCount,
+			Children:     toTreeResponses(n.Children),
+		})
+	}
+	return resp
+}
@@ -0,0 +1,27 @@
+package category
+
+import (
+	"github.com/gorilla/mux"
+	"go-app-marketplace/internal/middleware"
+	"go-app-marketplace/pkg/domain"
+	"net/http"
+)
+
+func RegisterCategoryRoutes(r *mux.Router, h *CategoryHandler, jwtKey []byte) {
+	r.HandleFunc("/categories", h.Tree).Methods(http.MethodGet)
+
+	admin := r.PathPrefix("/admin/categories").Subrouter()
+	admin.Use(middleware.AuthMiddleware(jwtKey))
+	admin.Use(middleware.RequireRoles(domain.UserRoleAdmin))
+
+	admin.HandleFunc("", h.List).Methods(http.MethodGet)
+	admin.HandleFunc("", h.Create).Methods(http.MethodPost)
+
+	admin.HandleFunc("/{id:[0-9]+}", h.Update).Methods(http.MethodPut)
+	admin.HandleFunc("/{id:[0-9]+}", h.Delete).Methods(http.MethodDelete)
+
+	admin.HandleFunc("/{id:[0-9]+}/attributes", h.ListAttributes).Methods(http.MethodGet)
+	admin.HandleFunc("/{id:[0-9]+}/attributes", h.CreateAttribute).Methods(http.MethodPost)
+	admin.HandleFunc("/{id:[0-9]+}/attributes/{attributeID:[0-9]+}", h.UpdateAttribute).Methods(http.MethodPut)
+	admin.HandleFunc("/{id:[0-9]+}/attributes/{attributeID:[0-9]+}", h.DeleteAttribute).Methods(http.MethodDelete)
+}
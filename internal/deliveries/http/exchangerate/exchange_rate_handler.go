@@ -0,0 +1,105 @@
+package exchangerate
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/internal/services"
+	"go-app-marketplace/pkg/domain"
+	"go-app-marketplace/pkg/httpx"
+	"go-app-marketplace/pkg/reqresp"
+	"net/http"
+)
+
+type ExchangeRateHandler struct {
+	exchangeRateService *services.ExchangeRateService
+}
+
+func NewExchangeRateHandler(exchangeRateService *services.ExchangeRateService) *ExchangeRateHandler {
+	return &ExchangeRateHandler{exchangeRateService: exchangeRateService}
+}
+
+// @Summary List exchange rates
+// @Description Rates used to convert offers into the buyer's currency at checkout
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} domain.ExchangeRate
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/exchange-rates [get]
+func (h *ExchangeRateHandler) List(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.exchangeRateService.List(r.Context())
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to list exchange rates", err.Error())
+		return
+	}
+	if rates == nil {
+		rates = []domain.ExchangeRate{}
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Exchange rates retrieved successfully", rates)
+}
+
+// @Summary Set an exchange rate
+// @Description Creates or replaces the rate of a currency pair: 1 base = rate quote
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body reqresp.ExchangeRateRequest true "Exchange rate"
+// @Success 200 {object} domain.ExchangeRate
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/exchange-rates [put]
+func (h *ExchangeRateHandler) Set(w http.ResponseWriter, r *http.Request) {
+	var req reqresp.ExchangeRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	rate, err := h.exchangeRateService.Set(r.Context(), req.Base, req.Quote, req.Rate)
+	if errors.Is(err, domain.ErrUnsupportedCurrency) || errors.Is(err, domain.ErrInvalidExchangeRate) {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid exchange rate", err.Error())
+		return
+	}
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to set exchange rate", err.Error())
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Exchange rate saved successfully", rate)
+}
+
+// @Summary Delete an exchange rate
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param base path string true "Base currency"
+// @Param quote path string true "Quote currency"
+// @Success 200 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/exchange-rates/{base}/{quote} [delete]
+func (h *ExchangeRateHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	err := h.exchangeRateService.Delete(r.Context(), vars["base"], vars["quote"])
+	if errors.Is(err, repositories.ErrExchangeRateNotFound) {
+		httpx.WriteError(w, http.StatusNotFound, "Exchange rate not found", err.Error())
+		return
+	}
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to delete exchange rate", err.Error())
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Exchange rate deleted successfully", nil)
+}
@@ -0,0 +1,20 @@
+package exchangerate
+
+import (
+	"github.com/gorilla/mux"
+	"go-app-marketplace/internal/middleware"
+	"go-app-marketplace/pkg/domain"
+	"net/http"
+)
+
+func RegisterExchangeRateRoutes(r *mux.Router, h *ExchangeRateHandler, jwtKey []byte) {
+
+	admin := r.PathPrefix("/admin/exchange-rates").Subrouter()
+	admin.Use(middleware.AuthMiddleware(jwtKey))
+	admin.Use(middleware.RequireRoles(domain.UserRoleAdmin))
+
+	admin.HandleFunc("", h.List).Methods(http.MethodGet)
+	admin.HandleFunc("", h.Set).Methods(http.MethodPut)
+
+	admin.HandleFunc("/{base:[A-Za-z]{3}}/{quote:[A-Za-z]{3}}", h.Delete).Methods(http.MethodDelete)
+}
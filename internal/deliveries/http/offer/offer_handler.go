@@ -2,8 +2,11 @@ package offer
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/gorilla/mux"
+	"go-app-marketplace/internal/repositories"
 	"go-app-marketplace/internal/services"
+	"go-app-marketplace/pkg/domain"
 	"go-app-marketplace/pkg/httpx"
 	"go-app-marketplace/pkg/reqresp"
 	"net/http"
@@ -20,7 +23,7 @@ func NewOfferHandler(offerService *services.OfferService) *OfferHandler {
 }
 
 // @Summary Create offer
-// @Description Create a new offer for a product
+// @Description Create a new offer for a product variant. A product with a single variant may be named by product_id alone.
 // @Tags offers
 // @Security BearerAuth
 // @Accept json
@@ -29,6 +32,8 @@ func NewOfferHandler(offerService *services.OfferService) *OfferHandler {
 // @Success 201 {object} reqresp.StandardResponse{data=reqresp.OfferCreateResponse}
 // @Failure 400 {object} reqresp.StandardResponse "Invalid request"
 // @Failure 401 {object} reqresp.StandardResponse "Unauthorized"
+// @Failure 404 {object} reqresp.StandardResponse "Product or variant not found, or archived"
+// @Failure 409 {object} reqresp.StandardResponse "Variant already offered by the seller"
 // @Failure 500 {object} reqresp.StandardResponse "Server error"
 // @Router /api/offers [post]
 func (h *OfferHandler) CreateOffer(w http.ResponseWriter, r *http.Request) {
@@ -37,6 +42,18 @@ func (h *OfferHandler) CreateOffer(w http.ResponseWriter, r *http.Request) {
 		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
 		return
 	}
+	if req.ProductID == 0 && req.VariantID == 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", "variant_id or product_id is required")
+		return
+	}
+	if !req.Price.IsPositive() {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid price", "price must be greater than zero")
+		return
+	}
+	if !domain.IsSupportedCurrency(req.Price.Currency) {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid price", domain.ErrUnsupportedCurrency.Error())
+		return
+	}
 
 	sellerID, ok := r.Context().Value("user_id").(int64)
 	if !ok {
@@ -44,7 +61,23 @@ func (h *OfferHandler) CreateOffer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, err := h.offerService.CreateOffer(r.Context(), req.ProductID, sellerID, req.Price, req.Stock, req.IsAvailable)
+	id, err := h.offerService.CreateOffer(r.Context(), req.ProductID, req.VariantID, sellerID, req.Price, req.Stock, req.IsAvailable)
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		httpx.WriteError(w, http.StatusNotFound, "Product not found", err.Error())
+		return
+	}
+	if errors.Is(err, repositories.ErrVariantNotFound) {
+		httpx.WriteError(w, http.StatusNotFound, "Variant not found", err.Error())
+		return
+	}
+	if errors.Is(err, repositories.ErrVariantRequired) {
+		httpx.WriteError(w, http.StatusBadRequest, "Variant required", err.Error())
+		return
+	}
+	if errors.Is(err, repositories.ErrOfferExists) {
+		httpx.WriteError(w, http.StatusConflict, "Offer already exists", err.Error())
+		return
+	}
 	if err != nil {
 		httpx.WriteError(w, http.StatusInternalServerError, "Failed to create offer", err.Error())
 		return
@@ -82,6 +115,7 @@ func (h *OfferHandler) GetOffer(w http.ResponseWriter, r *http.Request) {
 	response := reqresp.OfferResponse{
 		ID:          offer.ID,
 		ProductID:   offer.ProductID,
+		VariantID:   offer.VariantID,
 		SellerID:    offer.SellerID,
 		Price:       offer.Price,
 		Stock:       offer.Stock,
@@ -106,6 +140,7 @@ func (h *OfferHandler) GetOffer(w http.ResponseWriter, r *http.Request) {
 // @Failure 401 {object} reqresp.StandardResponse "Unauthorized"
 // @Failure 403 {object} reqresp.StandardResponse "Forbidden - not the offer owner"
 // @Failure 404 {object} reqresp.StandardResponse "Offer not found"
+// @Failure 409 {object} reqresp.StandardResponse "The product is archived"
 // @Failure 500 {object} reqresp.StandardResponse "Server error"
 // @Router /api/offers/{id} [put]
 func (h *OfferHandler) UpdateOffer(w http.ResponseWriter, r *http.Request) {
@@ -121,6 +156,14 @@ func (h *OfferHandler) UpdateOffer(w http.ResponseWriter, r *http.Request) {
 		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
 		return
 	}
+	if !req.Price.IsPositive() {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid price", "price must be greater than zero")
+		return
+	}
+	if !domain.IsSupportedCurrency(req.Price.Currency) {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid price", domain.ErrUnsupportedCurrency.Error())
+		return
+	}
 
 	sellerID, ok := r.Context().Value("user_id").(int64)
 	if !ok {
@@ -141,6 +184,14 @@ func (h *OfferHandler) UpdateOffer(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = h.offerService.UpdateOffer(r.Context(), id, sellerID, req.Price, req.Stock, req.IsAvailable)
+	if errors.Is(err, repositories.ErrOfferNotFound) {
+		httpx.WriteError(w, http.StatusNotFound, "Offer not found", err.Error())
+		return
+	}
+	if errors.Is(err, repositories.ErrOfferProductArchived) {
+		httpx.WriteError(w, http.StatusConflict, "Product is archived", err.Error())
+		return
+	}
 	if err != nil {
 		httpx.WriteError(w, http.StatusInternalServerError, "Failed to update offer", err.Error())
 		return
@@ -224,6 +275,7 @@ func (h *OfferHandler) ListMyOffers(w http.ResponseWriter, r *http.Request) {
 		response = append(response, reqresp.OfferResponse{
 			ID:          offer.ID,
 			ProductID:   offer.ProductID,
+			VariantID:   offer.VariantID,
 			SellerID:    offer.SellerID,
 			Price:       offer.Price,
 			Stock:       offer.Stock,
@@ -10,6 +10,7 @@ func RegisterOfferRoutes(r *mux.Router, handler *OfferHandler, jwtSecret []byte)
 	offerRouter := r.PathPrefix("/offers").Subrouter()
 	offerRouter.Use(middleware.AuthMiddleware(jwtSecret))
 	offerRouter.Use(middleware.RequireRoles(domain.UserRoleSeller))
+	offerRouter.Use(middleware.Idempotency())
 
 	offerRouter.HandleFunc("", handler.CreateOffer).Methods("POST")
 	offerRouter.HandleFunc("/{id:[0-9]+}", handler.GetOffer).Methods("GET")
@@ -2,16 +2,24 @@ package order
 
 import (
 	"encoding/json"
+	"errors"
+	"go-app-marketplace/internal/repositories"
 	"go-app-marketplace/internal/services"
+	"go-app-marketplace/internal/usecases"
+	"go-app-marketplace/pkg/auth"
 	"go-app-marketplace/pkg/domain"
 	"go-app-marketplace/pkg/httpx"
 	"go-app-marketplace/pkg/reqresp"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 )
 
+var validate = validator.New()
+
 type OrderHandler struct {
 	orderService *services.OrderService
 }
@@ -23,38 +31,150 @@ func NewOrderHandler(orderService *services.OrderService) *OrderHandler {
 }
 
 // @Summary Checkout cart
-// @Description Create a new order from cart items
+// @Description Create a new order from cart items shipped to an address book entry, converted into the requested currency.
+// @Description With a quote_token from /api/orders/quote the order is charged the quoted prices.
 // @Tags orders
 // @Security BearerAuth
+// @Param Idempotency-Key header string false "Replays the first response for retried requests"
+// @Param input body reqresp.CheckoutRequest true "Shipping address, settlement currency and shipping profile per seller"
+// @Accept json
 // @Produce json
 // @Success 200 {object} reqresp.CheckoutResponse
 // @Failure 400 {object} reqresp.StandardResponse
 // @Failure 401 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse
+// @Failure 422 {object} reqresp.StandardResponse
 // @Failure 500 {object} reqresp.StandardResponse
 // @Router /api/orders/checkout [post]
 func (h *OrderHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id").(int64)
 
-	resp, err := h.orderService.Checkout(r.Context(), userID)
+	var req reqresp.CheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if req.AddressID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "Shipping address required", "address_id must reference one of your addresses")
+		return
+	}
+
+	resp, err := h.orderService.Checkout(r.Context(), userID, req.AddressID, strings.ToUpper(req.Currency), req.Shipping, req.QuoteToken)
 	if err != nil {
-		httpx.WriteError(w, http.StatusInternalServerError, "Failed to checkout", err.Error())
+		writeCheckoutError(w, "Failed to checkout", err)
 		return
 	}
 
 	httpx.WriteSuccess(w, http.StatusOK, "Checkout successful", resp)
 }
 
+// @Summary Preview checkout
+// @Description Price the cart as checkout would, grouped by seller with the chosen shipping and every shipping option of the seller, without placing the order
+// @Tags orders
+// @Security BearerAuth
+// @Param input body reqresp.CheckoutRequest true "Shipping address, settlement currency and shipping profile per seller"
+// @Accept json
+// @Produce json
+// @Success 200 {object} reqresp.CheckoutPreviewResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse
+// @Failure 422 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/orders/checkout/preview [post]
+func (h *OrderHandler) PreviewCheckout(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int64)
+
+	var req reqresp.CheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if req.AddressID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "Shipping address required", "address_id must reference one of your addresses")
+		return
+	}
+
+	resp, err := h.orderService.PreviewCheckout(r.Context(), userID, req.AddressID, strings.ToUpper(req.Currency), req.Shipping)
+	if err != nil {
+		writeCheckoutError(w, "Failed to preview checkout", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Checkout preview", resp)
+}
+
+// @Summary Quote checkout
+// @Description Revalidate the cart against current offers and price it per seller with shipping, tax and discounts.
+// @Description Unless an item is unavailable or short of stock, the quote carries a token that checkout accepts to keep these prices until expires_at.
+// @Tags orders
+// @Security BearerAuth
+// @Param input body reqresp.CheckoutRequest true "Shipping address, settlement currency and shipping profile per seller"
+// @Accept json
+// @Produce json
+// @Success 200 {object} reqresp.CheckoutQuoteResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 422 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/orders/quote [post]
+func (h *OrderHandler) Quote(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int64)
+
+	var req reqresp.CheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if req.AddressID <= 0 {
+		httpx.WriteError(w, http.StatusBadRequest, "Shipping address required", "address_id must reference one of your addresses")
+		return
+	}
+
+	resp, err := h.orderService.Quote(r.Context(), userID, req.AddressID, strings.ToUpper(req.Currency), req.Shipping)
+	if err != nil {
+		writeCheckoutError(w, "Failed to quote checkout", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Checkout quote", resp)
+}
+
+func writeCheckoutError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidQuoteToken):
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid quote token", err.Error())
+	case errors.Is(err, usecases.ErrQuoteMismatch), errors.Is(err, repositories.ErrQuoteRedeemed):
+		httpx.WriteError(w, http.StatusConflict, message, err.Error())
+	case errors.Is(err, usecases.ErrOfferNotFound):
+		httpx.WriteError(w, http.StatusConflict, message, err.Error())
+	case errors.Is(err, repositories.ErrAddressNotFound):
+		httpx.WriteError(w, http.StatusBadRequest, "Shipping address not found", err.Error())
+	case errors.Is(err, domain.ErrUnsupportedCurrency), errors.Is(err, usecases.ErrInvalidShippingChoice):
+		httpx.WriteError(w, http.StatusBadRequest, message, err.Error())
+	case errors.Is(err, repositories.ErrExchangeRateNotFound):
+		httpx.WriteError(w, http.StatusUnprocessableEntity, message, err.Error())
+	case errors.Is(err, repositories.ErrInsufficientStock), errors.Is(err, services.ErrCheckoutTotalMismatch):
+		httpx.WriteError(w, http.StatusConflict, message, err.Error())
+	default:
+		httpx.WriteError(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
 // @Summary Cancel order item
-// @Description Cancel a specific order item
+// @Description Cancel a pending item of your order. Its stock is returned, the order total is recomputed
+// @Description and a paid order is refunded the difference.
 // @Tags orders
 // @Security BearerAuth
 // @Param id path int true "Order item ID"
 // @Produce json
-// @Success 200 {object} reqresp.StandardResponse
+// @Success 200 {object} reqresp.CancelOrderItemResponse
 // @Failure 400 {object} reqresp.StandardResponse
 // @Failure 401 {object} reqresp.StandardResponse
 // @Failure 404 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse
 // @Failure 500 {object} reqresp.StandardResponse
+// @Failure 502 {object} reqresp.StandardResponse "Cancelled, but the payment provider rejected the refund"
 // @Router /api/orders/{id}/cancel [post]
 func (h *OrderHandler) CancelOrderItem(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id").(int64)
@@ -66,13 +186,111 @@ func (h *OrderHandler) CancelOrderItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.orderService.CancelOrderItem(r.Context(), userID, itemID)
+	resp, err := h.orderService.CancelOrderItem(r.Context(), userID, itemID)
 	if err != nil {
-		httpx.WriteError(w, http.StatusInternalServerError, "Failed to cancel order item", err.Error())
+		writeCancelError(w, err)
 		return
 	}
 
-	httpx.WriteSuccess(w, http.StatusOK, "Order item canceled successfully", nil)
+	httpx.WriteSuccess(w, http.StatusOK, "Order item canceled successfully", resp)
+}
+
+// @Summary Cancel an order item as the seller
+// @Description Cancel one of your items whose shipment has not left yet, telling the buyer why.
+// @Description Its stock is returned, the order total is recomputed and a paid order is refunded the difference.
+// @Tags orders
+// @Security BearerAuth
+// @Param id path int true "Order item ID"
+// @Param input body reqresp.SellerCancelItemRequest true "Reason shown to the buyer"
+// @Accept json
+// @Produce json
+// @Success 200 {object} reqresp.CancelOrderItemResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Failure 502 {object} reqresp.StandardResponse "Cancelled, but the payment provider rejected the refund"
+// @Router /api/seller/orders/items/{id}/cancel [post]
+func (h *OrderHandler) SellerCancelOrderItem(w http.ResponseWriter, r *http.Request) {
+	sellerID := r.Context().Value("user_id").(int64)
+
+	itemID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid ID", err.Error())
+		return
+	}
+
+	var req reqresp.SellerCancelItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if err := validate.Struct(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Validation failed", err.Error())
+		return
+	}
+
+	resp, err := h.orderService.SellerCancelOrderItem(r.Context(), sellerID, itemID, req.Reason)
+	if err != nil {
+		writeCancelError(w, err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Order item canceled successfully", resp)
+}
+
+// @Summary Cancel order
+// @Description Cancel every item of your order that is still pending, in one go. An unpaid order's checkout
+// @Description sessions are voided; a paid order is refunded what it no longer costs. Items that can no longer
+// @Description be cancelled are listed under skipped, and the order itself is cancelled only when none are.
+// @Description A refund the payment provider rejects is flagged with refund_failed and can be retried by the seller.
+// @Tags orders
+// @Security BearerAuth
+// @Param id path int true "Order ID"
+// @Param Idempotency-Key header string false "Replays the first response for retried requests"
+// @Produce json
+// @Success 200 {object} reqresp.CancelOrderResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/orders/{id}/cancel-order [post]
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int64)
+
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid order ID", err.Error())
+		return
+	}
+
+	resp, err := h.orderService.CancelOrder(r.Context(), userID, orderID)
+	if err != nil {
+		writeCancelError(w, err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Order canceled successfully", resp)
+}
+
+func writeCancelError(w http.ResponseWriter, err error) {
+	const message = "Failed to cancel order item"
+	switch {
+	case errors.Is(err, repositories.ErrOrderItemNotFound):
+		httpx.WriteError(w, http.StatusNotFound, "Order item not found", err.Error())
+	case errors.Is(err, repositories.ErrOrderNotFound):
+		httpx.WriteError(w, http.StatusNotFound, "Order not found", err.Error())
+	case errors.Is(err, domain.ErrInvalidTransition), errors.Is(err, services.ErrOrderPaymentInFlight):
+		httpx.WriteError(w, http.StatusConflict, message, err.Error())
+	case errors.Is(err, services.ErrRefundPaymentFailed):
+		httpx.WriteError(w, http.StatusBadGateway, "Order item canceled but the refund failed", err.Error())
+	default:
+		httpx.WriteError(w, http.StatusInternalServerError, message, err.Error())
+	}
 }
 
 // @Summary List user orders
@@ -105,6 +323,10 @@ func (h *OrderHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
 				Quantity:  item.Quantity,
 				UnitPrice: item.UnitPrice,
 				Status:    item.Status,
+
+				VariantID:  item.VariantID,
+				SKU:        item.SKU,
+				Attributes: item.Attributes,
 			})
 		}
 
@@ -152,16 +374,55 @@ func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
 	httpx.WriteSuccess(w, http.StatusOK, "Order details retrieved successfully", order)
 }
 
+// @Summary Get order timeline
+// @Description Status history of the order, its payment, items, shipments and refunds, oldest first, with who made each change.
+// @Description Buyers and admins see the whole order, sellers only their own items, shipment and refunds.
+// @Tags orders
+// @Security BearerAuth
+// @Param id path int true "Order ID"
+// @Produce json
+// @Success 200 {array} reqresp.OrderEventResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/orders/{id}/timeline [get]
+func (h *OrderHandler) GetOrderTimeline(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("user_id").(int64)
+	role, _ := r.Context().Value("role").(string)
+
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid order ID", err.Error())
+		return
+	}
+
+	events, err := h.orderService.Timeline(r.Context(), userID, domain.UserRole(role), orderID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrOrderNotFound) {
+			httpx.WriteError(w, http.StatusNotFound, "Order not found or access denied", err.Error())
+			return
+		}
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to fetch order timeline", err.Error())
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Order timeline retrieved successfully", events)
+}
+
 // @Summary Checkout existing order
 // @Description Create new payment session for an existing order
 // @Tags orders
 // @Security BearerAuth
 // @Param id path int true "Order ID"
+// @Param Idempotency-Key header string false "Replays the first response for retried requests"
 // @Produce json
 // @Success 200 {object} reqresp.CheckoutResponse
 // @Failure 400 {object} reqresp.StandardResponse
 // @Failure 401 {object} reqresp.StandardResponse
 // @Failure 404 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse
+// @Failure 422 {object} reqresp.StandardResponse
 // @Failure 500 {object} reqresp.StandardResponse
 // @Router /api/orders/checkout/{id} [post]
 func (h *OrderHandler) CheckoutExistingOrder(w http.ResponseWriter, r *http.Request) {
@@ -176,6 +437,10 @@ func (h *OrderHandler) CheckoutExistingOrder(w http.ResponseWriter, r *http.Requ
 
 	resp, err := h.orderService.CheckoutExistingOrder(r.Context(), userID, orderID)
 	if err != nil {
+		if errors.Is(err, repositories.ErrReservationExpired) || errors.Is(err, services.ErrCheckoutTotalMismatch) {
+			httpx.WriteError(w, http.StatusConflict, "Failed to create checkout session", err.Error())
+			return
+		}
 		httpx.WriteError(w, http.StatusInternalServerError, "Failed to create checkout session", err.Error())
 		return
 	}
@@ -183,45 +448,20 @@ func (h *OrderHandler) CheckoutExistingOrder(w http.ResponseWriter, r *http.Requ
 	httpx.WriteSuccess(w, http.StatusOK, "Checkout session created successfully", resp)
 }
 
-// PATCH /api/orders/items/{id}/status
-// @Summary Seller updates order-item status
-// @Description Seller sets status to 'processing' or 'delivered'
+// @Summary Seller updates order-item status (removed)
+// @Description Items are no longer moved one by one: they follow their shipment, which the seller
+// @Description updates with PATCH /api/seller/shipments/{id}. The item's shipment_id is in GET /api/seller/orders.
 // @Tags orders
 // @Security BearerAuth
 // @Param id path int true "Order item ID"
-// @Accept json
 // @Produce json
-// @Param input body reqresp.UpdateOrderItemStatusRequest true "New status"
-// @Success 200 {object} reqresp.StandardResponse
-// @Failure 400,401,403,500 {object} reqresp.StandardResponse
+// @Failure 410 {object} reqresp.StandardResponse
 // @Router /api/seller/orders/items/{id}/status [patch]
+// @Deprecated
 func (h *OrderHandler) UpdateOrderItemStatus(w http.ResponseWriter, r *http.Request) {
-	sellerID := r.Context().Value("user_id").(int64)
-	idStr := mux.Vars(r)["id"]
-	itemID, _ := strconv.ParseInt(idStr, 10, 64)
-
-	var req reqresp.UpdateOrderItemStatusRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		httpx.WriteError(w, http.StatusBadRequest, "Invalid body", err.Error())
-		return
-	}
-	if req.Status != "processing" && req.Status != "delivered" {
-		httpx.WriteError(w, http.StatusBadRequest, "Invalid status", "allowed: processing, delivered")
-		return
-	}
-
-	err := h.orderService.SellerUpdateOrderItemStatus(
-		r.Context(),
-		sellerID,
-		itemID,
-		domain.OrderItemStatus(req.Status),
-	)
-	if err != nil {
-		httpx.WriteError(w, http.StatusBadRequest, "Update failed", err.Error())
-		return
-	}
-
-	httpx.WriteSuccess(w, http.StatusOK, "Status updated", nil)
+	httpx.WriteError(w, http.StatusGone, "Endpoint removed",
+		"order items follow their shipment; update it with PATCH /api/seller/shipments/{id}, "+
+			"the item's shipment_id is listed in GET /api/seller/orders")
 }
 
 // @Summary   Seller: list own order-items
@@ -11,14 +11,19 @@ func RegisterOrderRoutes(r *mux.Router, h *OrderHandler, jwtKey []byte) {
 
 	buyer := r.PathPrefix("/orders").Subrouter()
 	buyer.Use(middleware.AuthMiddleware(jwtKey))
+	buyer.Use(middleware.Idempotency())
 
 	buyer.HandleFunc("/checkout", h.Checkout).Methods(http.MethodPost)
+	buyer.HandleFunc("/checkout/preview", h.PreviewCheckout).Methods(http.MethodPost)
+	buyer.HandleFunc("/quote", h.Quote).Methods(http.MethodPost)
 
 	buyer.HandleFunc("/checkout/{id:[0-9]+}", h.CheckoutExistingOrder).Methods(http.MethodPost)
 
 	buyer.HandleFunc("/{id:[0-9]+}/cancel", h.CancelOrderItem).Methods(http.MethodPost)
+	buyer.HandleFunc("/{id:[0-9]+}/cancel-order", h.CancelOrder).Methods(http.MethodPost)
 
 	buyer.HandleFunc("/{id:[0-9]+}", h.GetOrder).Methods(http.MethodGet)
+	buyer.HandleFunc("/{id:[0-9]+}/timeline", h.GetOrderTimeline).Methods(http.MethodGet)
 
 	buyer.HandleFunc("", h.ListOrders).Methods(http.MethodGet)
 
@@ -27,7 +32,8 @@ func RegisterOrderRoutes(r *mux.Router, h *OrderHandler, jwtKey []byte) {
 	seller.Use(middleware.RequireRoles(domain.UserRoleSeller))
 
 	seller.HandleFunc("/orders", h.ListSellerOrderItems).Methods(http.MethodGet)
+	seller.HandleFunc("/orders/items/{id:[0-9]+}/cancel", h.SellerCancelOrderItem).Methods(http.MethodPost)
 
-	seller.HandleFunc("/orders/items/{id:[0-9]+}/status",
-		h.UpdateOrderItemStatus).Methods(http.MethodPatch)
+	// Deprecated: items move with their shipment now
+	seller.HandleFunc("/orders/items/{id:[0-9]+}/status", h.UpdateOrderItemStatus).Methods(http.MethodPatch)
 }
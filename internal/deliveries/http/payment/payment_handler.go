@@ -0,0 +1,189 @@
+package payment
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/internal/services"
+	"go-app-marketplace/pkg/domain"
+	"go-app-marketplace/pkg/httpx"
+	"go-app-marketplace/pkg/reqresp"
+	"net/http"
+	"strconv"
+)
+
+type PaymentHandler struct {
+	paymentService *services.PaymentService
+}
+
+func NewPaymentHandler(paymentService *services.PaymentService) *PaymentHandler {
+	return &PaymentHandler{paymentService: paymentService}
+}
+
+// @Summary List payment attempts of an order
+// @Description Every checkout session opened for the order, newest first
+// @Tags payments
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Order ID"
+// @Success 200 {array} reqresp.PaymentResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Router /api/orders/{id}/payments [get]
+func (h *PaymentHandler) ListOrderPayments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "Unauthorized", "Invalid user context")
+		return
+	}
+
+	orderID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid order ID", err.Error())
+		return
+	}
+
+	list, err := h.paymentService.ListOrderPayments(r.Context(), userID, orderID)
+	if err != nil {
+		httpx.WriteError(w, http.StatusNotFound, "Order not found or access denied", err.Error())
+		return
+	}
+
+	resp := make([]reqresp.PaymentResponse, 0, len(list))
+	for _, p := range list {
+		resp = append(resp, toPaymentResponse(p))
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Payments retrieved successfully", resp)
+}
+
+// @Summary List the payments ledger
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "Filter by status: open | succeeded | failed | refunded | expired | orphaned"
+// @Param order_id query int false "Filter by order ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Number of items per page" default(20)
+// @Success 200 {object} reqresp.PaginatedResponse[reqresp.PaymentResponse]
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Router /api/admin/payments [get]
+func (h *PaymentHandler) ListPayments(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	page := 1
+	pageSize := 20
+
+	if pageStr := query.Get("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	if pageSizeStr := query.Get("page_size"); pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 100 {
+			pageSize = ps
+		}
+	}
+
+	var orderID int64
+	if orderIDStr := query.Get("order_id"); orderIDStr != "" {
+		id, err := strconv.ParseInt(orderIDStr, 10, 64)
+		if err != nil {
+			httpx.WriteError(w, http.StatusBadRequest, "Invalid order ID", err.Error())
+			return
+		}
+		orderID = id
+	}
+
+	list, total, err := h.paymentService.ListPayments(r.Context(), query.Get("status"), orderID, page, pageSize)
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to fetch payments", err.Error())
+		return
+	}
+
+	items := make([]reqresp.PaymentResponse, 0, len(list))
+	for _, p := range list {
+		items = append(items, toPaymentResponse(p))
+	}
+
+	totalPages := int(total) / pageSize
+	if int(total)%pageSize > 0 {
+		totalPages++
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Payments fetched successfully", reqresp.PaginatedResponse[reqresp.PaymentResponse]{
+		Items:      items,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	})
+}
+
+// @Summary Get a payment with its transitions
+// @Description Includes the raw provider payloads for debugging
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Payment ID"
+// @Success 200 {object} reqresp.PaymentDetailResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Router /api/admin/payments/{id} [get]
+func (h *PaymentHandler) GetPayment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid payment ID", err.Error())
+		return
+	}
+
+	p, transitions, err := h.paymentService.GetPayment(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrPaymentNotFound) {
+			httpx.WriteError(w, http.StatusNotFound, "Payment not found", err.Error())
+			return
+		}
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to fetch payment", err.Error())
+		return
+	}
+
+	resp := reqresp.PaymentDetailResponse{
+		PaymentResponse: toPaymentResponse(*p),
+		RawPayload:      json.RawMessage(p.RawPayload),
+		Transitions:     make([]reqresp.PaymentTransitionResponse, 0, len(transitions)),
+	}
+	for _, t := range transitions {
+		tr := reqresp.PaymentTransitionResponse{
+			ToStatus:   string(t.ToStatus),
+			EventType:  t.EventType,
+			RawPayload: json.RawMessage(t.RawPayload),
+			CreatedAt:  t.CreatedAt,
+		}
+		if t.FromStatus != nil {
+			from := string(*t.FromStatus)
+			tr.FromStatus = &from
+		}
+		resp.Transitions = append(resp.Transitions, tr)
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Payment retrieved successfully", resp)
+}
+
+func toPaymentResponse(p domain.Payment) reqresp.PaymentResponse {
+	return reqresp.PaymentResponse{
+		ID:                p.ID,
+		OrderID:           p.OrderID,
+		Provider:          p.Provider,
+		CheckoutSessionID: p.CheckoutSessionID,
+		PaymentIntentID:   p.PaymentIntentID,
+		Amount:            p.Amount,
+		Status:            string(p.Status),
+		CreatedAt:         p.CreatedAt,
+		UpdatedAt:         p.UpdatedAt,
+	}
+}
@@ -0,0 +1,24 @@
+package payment
+
+import (
+	"github.com/gorilla/mux"
+	"go-app-marketplace/internal/middleware"
+	"go-app-marketplace/pkg/domain"
+	"net/http"
+)
+
+func RegisterPaymentRoutes(r *mux.Router, h *PaymentHandler, jwtKey []byte) {
+
+	buyer := r.PathPrefix("/orders").Subrouter()
+	buyer.Use(middleware.AuthMiddleware(jwtKey))
+
+	buyer.HandleFunc("/{id:[0-9]+}/payments", h.ListOrderPayments).Methods(http.MethodGet)
+
+	admin := r.PathPrefix("/admin/payments").Subrouter()
+	admin.Use(middleware.AuthMiddleware(jwtKey))
+	admin.Use(middleware.RequireRoles(domain.UserRoleAdmin))
+
+	admin.HandleFunc("", h.ListPayments).Methods(http.MethodGet)
+
+	admin.HandleFunc("/{id:[0-9]+}", h.GetPayment).Methods(http.MethodGet)
+}
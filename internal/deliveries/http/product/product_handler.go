@@ -2,24 +2,35 @@ package product
 
 import (
 	"encoding/json"
+	"errors"
+	"go-app-marketplace/internal/repositories"
 	"go-app-marketplace/internal/services"
+	"go-app-marketplace/internal/usecases"
+	"go-app-marketplace/pkg/domain"
 	"go-app-marketplace/pkg/httpx"
 	"go-app-marketplace/pkg/reqresp"
 	"net/http"
 	"strconv"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 )
 
+var validate = validator.New()
+
 type ProductHandler struct {
-	productService *services.ProductService
-	offerService   *services.OfferService
+	productService  *services.ProductService
+	offerService    *services.OfferService
+	categoryService *services.CategoryService
+	variantService  *services.VariantService
 }
 
-func NewProductHandler(productService *services.ProductService, offerService *services.OfferService) *ProductHandler {
+func NewProductHandler(productService *services.ProductService, offerService *services.OfferService, categoryService *services.CategoryService, variantService *services.VariantService) *ProductHandler {
 	return &ProductHandler{
-		productService: productService,
-		offerService:   offerService,
+		productService:  productService,
+		offerService:    offerService,
+		categoryService: categoryService,
+		variantService:  variantService,
 	}
 }
 
@@ -38,7 +49,12 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	id, err := h.productService.CreateProduct(r.Context(), req.Name, req.Description)
+	if req.TaxClass != "" && !domain.IsValidTaxClass(req.TaxClass) {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid tax class", "tax_class must be lowercase letters, digits or underscores")
+		return
+	}
+
+	id, err := h.productService.CreateProduct(r.Context(), req.Name, req.Description, req.TaxClass)
 	if err != nil {
 		httpx.WriteError(w, http.StatusInternalServerError, "Failed to create product", err.Error())
 		return
@@ -48,10 +64,17 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 }
 
 // @Summary Get product with offers
+// @Description The product with its offers, its categories and the breadcrumb down to its deepest category.
+// @Description Offers come cheapest first by their price in the currency, shown as display_price next to the seller's price.
+// @Description Variants come with their best offer priced in the currency; axes are the attributes they differ by.
 // @Tags products
 // @Produce json
 // @Param id path int true "Product ID"
-// @Success 200 {object} reqresp.StandardResponse
+// @Param currency query string false "Currency the offers and best offer prices are shown in" default(USD)
+// @Success 200 {object} reqresp.StandardResponse{data=reqresp.ProductWithOffersResponse}
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
 // @Router /api/products/{id} [get]
 func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	idStr := mux.Vars(r)["id"]
@@ -62,12 +85,21 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	product, err := h.productService.GetProductByID(r.Context(), id)
+	if errors.Is(err, repositories.ErrProductNotFound) {
+		httpx.WriteError(w, http.StatusNotFound, "Product not found", err.Error())
+		return
+	}
 	if err != nil {
 		httpx.WriteError(w, http.StatusInternalServerError, "Failed to fetch product", err.Error())
 		return
 	}
 
-	offers, err := h.offerService.ListOffersByProduct(r.Context(), id)
+	currency := r.URL.Query().Get("currency")
+	offers, err := h.offerService.ListOffersByProduct(r.Context(), id, currency)
+	if errors.Is(err, domain.ErrUnsupportedCurrency) {
+		httpx.WriteError(w, http.StatusBadRequest, "Unsupported currency", err.Error())
+		return
+	}
 	if err != nil {
 		httpx.WriteError(w, http.StatusInternalServerError, "Failed to fetch offers", err.Error())
 		return
@@ -76,35 +108,519 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	var offerResponses []reqresp.OfferShortResponse
 	for _, o := range offers {
 		offerResponses = append(offerResponses, reqresp.OfferShortResponse{
-			ID:          o.ID,
-			SellerID:    o.SellerID,
-			Price:       o.Price,
-			Stock:       o.Stock,
-			IsAvailable: o.IsAvailable,
+			ID:           o.ID,
+			VariantID:    o.VariantID,
+			SellerID:     o.SellerID,
+			Price:        o.Price,
+			DisplayPrice: o.DisplayPrice,
+			Stock:        o.Stock,
+			IsAvailable:  o.IsAvailable,
 		})
 	}
 
+	categories, err := h.categoryService.ListByProduct(r.Context(), id)
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to fetch categories", err.Error())
+		return
+	}
+	breadcrumb, err := h.categoryService.Breadcrumb(r.Context(), id)
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to fetch categories", err.Error())
+		return
+	}
+
+	variants, axes, err := h.variantService.Matrix(r.Context(), id, currency)
+	if errors.Is(err, domain.ErrUnsupportedCurrency) {
+		httpx.WriteError(w, http.StatusBadRequest, "Unsupported currency", err.Error())
+		return
+	}
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to fetch variants", err.Error())
+		return
+	}
+
 	response := reqresp.ProductWithOffersResponse{
 		ID:          product.ID,
 		Name:        product.Name,
 		Description: product.Description,
+		TaxClass:    product.TaxClass,
 		Offers:      offerResponses,
+		Categories:  toCategoryRefs(categories),
+		Breadcrumb:  toCategoryRefs(breadcrumb),
+		Axes:        toAxisResponses(axes),
+		Variants:    toMatrixEntries(variants),
+		ArchivedAt:  product.ArchivedAt,
 	}
 
 	httpx.WriteSuccess(w, http.StatusOK, "Product fetched successfully", response)
 }
 
+// @Summary Search products
+// @Description Full-text search over product names and descriptions. Every word matches as a prefix,
+// @Description name matches rank above description matches, and matched words are wrapped in <mark> tags.
+// @Description name_highlight and snippet are HTML with the product text escaped, safe to render as is.
+// @Tags products
+// @Produce json
+// @Param q query string true "Search text"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Number of items per page" default(10)
+// @Success 200 {object} reqresp.StandardResponse{data=reqresp.PaginatedResponse[reqresp.ProductSearchResponse]}
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/products/search [get]
+func (h *ProductHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	page, pageSize := parsePagination(r)
+
+	results, total, err := h.productService.SearchProducts(r.Context(), r.URL.Query().Get("q"), page, pageSize)
+	if errors.Is(err, usecases.ErrEmptySearchQuery) {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid search query", err.Error())
+		return
+	}
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to search products", err.Error())
+		return
+	}
+
+	items := make([]reqresp.ProductSearchResponse, 0, len(results))
+	for _, res := range results {
+		items = append(items, reqresp.ProductSearchResponse{
+			ProductResponse: toProductResponse(&res.Product),
+			Rank:            res.Rank,
+			NameHighlight:   res.NameHighlight,
+			Snippet:         res.Snippet,
+		})
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Products found", reqresp.PaginatedResponse[reqresp.ProductSearchResponse]{
+		Items:      items,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages(total, pageSize),
+	})
+}
+
 // @Summary List all products
+// @Description Lists live products with the price of their best available offer. Filters narrow the
+// @Description listing and the facets count what each filter option would leave given the others.
 // @Tags products
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param page_size query int false "Number of items per page" default(10)
-// @Success 200 {object} reqresp.StandardResponse
+// @Param min_price query string false "Minimum best offer price"
+// @Param max_price query string false "Maximum best offer price"
+// @Param currency query string false "Currency of the prices" default(USD)
+// @Param seller_id query int false "Only products offered by this seller"
+// @Param in_stock query bool false "Only products with stock left"
+// @Param category query string false "Only products in this category slug or below it"
+// @Param sort query string false "Sort order" Enums(newest, price_asc, price_desc, best_selling) default(newest)
+// @Success 200 {object} reqresp.StandardResponse{data=reqresp.ProductListResponse}
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
 // @Router /api/products [get]
 func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
-	// Parse pagination parameters
-	page := 1
-	pageSize := 10
+	req, err := parseProductFilter(r)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid filter", err.Error())
+		return
+	}
+	h.writeProductList(w, r, req)
+}
+
+// @Summary List the products of a category
+// @Description Products in the category or any of its subcategories, with the filters, sorting and facets of the product listing
+// @Tags categories
+// @Produce json
+// @Param slug path string true "Category slug"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Number of items per page" default(10)
+// @Param min_price query string false "Minimum best offer price"
+// @Param max_price query string false "Maximum best offer price"
+// @Param currency query string false "Currency of the prices" default(USD)
+// @Param seller_id query int false "Only products offered by this seller"
+// @Param in_stock query bool false "Only products with stock left"
+// @Param sort query string false "Sort order" Enums(newest, price_asc, price_desc, best_selling) default(newest)
+// @Success 200 {object} reqresp.StandardResponse{data=reqresp.ProductListResponse}
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/categories/{slug}/products [get]
+func (h *ProductHandler) ListCategoryProducts(w http.ResponseWriter, r *http.Request) {
+	category, err := h.categoryService.GetBySlug(r.Context(), mux.Vars(r)["slug"])
+	if errors.Is(err, repositories.ErrCategoryNotFound) {
+		httpx.WriteError(w, http.StatusNotFound, "Category not found", err.Error())
+		return
+	}
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to fetch category", err.Error())
+		return
+	}
+
+	req, err := parseProductFilter(r)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid filter", err.Error())
+		return
+	}
+	req.Category = category.Slug
+	h.writeProductList(w, r, req)
+}
+
+// writeProductList writes a page of the products matching the filter along
+// with the facets
+func (h *ProductHandler) writeProductList(w http.ResponseWriter, r *http.Request, req reqresp.ProductFilterRequest) {
+	page, pageSize := parsePagination(r)
+
+	if err := validate.Struct(req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid filter", err.Error())
+		return
+	}
+
+	products, total, facets, err := h.productService.ListProducts(r.Context(), req, page, pageSize)
+	if errors.Is(err, domain.ErrInvalidMoney) || errors.Is(err, domain.ErrInvalidProductFilter) || errors.Is(err, domain.ErrUnsupportedCurrency) {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid filter", err.Error())
+		return
+	}
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to fetch products", err.Error())
+		return
+	}
+
+	items := make([]reqresp.ProductListingResponse, 0, len(products))
+	for _, p := range products {
+		items = append(items, reqresp.ProductListingResponse{
+			ProductResponse: toProductResponse(&p.Product),
+			BestPrice:       p.BestPrice,
+			InStock:         p.InStock,
+			SoldCount:       p.SoldCount,
+		})
+	}
+
+	sellers := make([]reqresp.SellerFacet, 0, len(facets.Sellers))
+	for _, f := range facets.Sellers {
+		sellers = append(sellers, reqresp.SellerFacet{SellerID: f.SellerID, Username: f.Username, Count: f.Count})
+	}
+
+	response := reqresp.ProductListResponse{
+		PaginatedResponse: reqresp.PaginatedResponse[reqresp.ProductListingResponse]{
+			Items:      items,
+			Total:      total,
+			Page:       page,
+			PageSize:   pageSize,
+			TotalPages: totalPages(total, pageSize),
+		},
+		Facets: reqresp.ProductFacetsResponse{
+			Price:   reqresp.PriceRangeFacet{Min: facets.MinPrice, Max: facets.MaxPrice},
+			InStock: facets.InStockCount,
+			Sellers: sellers,
+		},
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Products fetched successfully", response)
+}
+
+// @Summary Set product categories
+// @Description Replaces the categories of a product; an empty list takes it out of all of them
+// @Tags products
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param input body reqresp.ProductCategoriesRequest true "Category IDs"
+// @Success 200 {object} reqresp.StandardResponse{data=[]reqresp.CategoryRef}
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/products/{id}/categories [put]
+func (h *ProductHandler) SetProductCategories(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid product ID", err.Error())
+		return
+	}
+
+	var req reqresp.ProductCategoriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if err := validate.Struct(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Validation failed", err.Error())
+		return
+	}
+
+	err = h.categoryService.SetProductCategories(r.Context(), id, req.CategoryIDs)
+	switch {
+	case errors.Is(err, repositories.ErrProductNotFound):
+		httpx.WriteError(w, http.StatusNotFound, "Product not found", err.Error())
+		return
+	case errors.Is(err, repositories.ErrCategoryNotFound):
+		httpx.WriteError(w, http.StatusNotFound, "Category not found", err.Error())
+		return
+	case err != nil:
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to set product categories", err.Error())
+		return
+	}
+
+	categories, err := h.categoryService.ListByProduct(r.Context(), id)
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to fetch categories", err.Error())
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Product categories updated successfully", toCategoryRefs(categories))
+}
+
+// @Summary Create product variant
+// @Description Attribute values must fit the attributes of the product's categories and their ancestors
+// @Tags products
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param input body reqresp.VariantRequest true "Variant"
+// @Success 201 {object} reqresp.StandardResponse{data=reqresp.VariantResponse}
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/products/{id}/variants [post]
+func (h *ProductHandler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid product ID", err.Error())
+		return
+	}
+
+	var req reqresp.VariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if err := validate.Struct(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Validation failed", err.Error())
+		return
+	}
+
+	variant, err := h.variantService.Create(r.Context(), id, req)
+	if err != nil {
+		writeVariantError(w, "Failed to create variant", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusCreated, "Variant created successfully", toVariantResponse(variant))
+}
+
+// @Summary Update product variant
+// @Description Replace the SKU and attribute values of a variant. Attribute values are fixed once the variant has offers or orders.
+// @Tags products
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param variantID path int true "Variant ID"
+// @Param input body reqresp.VariantRequest true "Variant"
+// @Success 200 {object} reqresp.StandardResponse{data=reqresp.VariantResponse}
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/products/{id}/variants/{variantID} [put]
+func (h *ProductHandler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid product ID", err.Error())
+		return
+	}
+	variantID, err := strconv.ParseInt(mux.Vars(r)["variantID"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid variant ID", err.Error())
+		return
+	}
+
+	var req reqresp.VariantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if err := validate.Struct(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Validation failed", err.Error())
+		return
+	}
+
+	variant, err := h.variantService.Update(r.Context(), id, variantID, req)
+	if err != nil {
+		writeVariantError(w, "Failed to update variant", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Variant updated successfully", toVariantResponse(variant))
+}
+
+// @Summary Delete product variant
+// @Description Only a variant without offers can go, and a product keeps at least one variant
+// @Tags products
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param variantID path int true "Variant ID"
+// @Success 200 {object} reqresp.StandardResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/products/{id}/variants/{variantID} [delete]
+func (h *ProductHandler) DeleteVariant(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid product ID", err.Error())
+		return
+	}
+	variantID, err := strconv.ParseInt(mux.Vars(r)["variantID"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid variant ID", err.Error())
+		return
+	}
+
+	if err := h.variantService.Delete(r.Context(), id, variantID); err != nil {
+		writeVariantError(w, "Failed to delete variant", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Variant deleted successfully", nil)
+}
+
+// @Summary Update product
+// @Description Replace the name, description and tax class of a product
+// @Tags products
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param input body reqresp.ProductUpdateRequest true "Product data"
+// @Success 200 {object} reqresp.StandardResponse{data=reqresp.ProductResponse}
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/products/{id} [put]
+func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid product ID", err.Error())
+		return
+	}
+
+	var req reqresp.ProductUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+	if err := validate.Struct(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Validation failed", err.Error())
+		return
+	}
+	if !domain.IsValidTaxClass(req.TaxClass) {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid tax class", "tax_class must be lowercase letters, digits or underscores")
+		return
+	}
+
+	product, err := h.productService.UpdateProduct(r.Context(), id, req)
+	if err != nil {
+		writeProductError(w, "Failed to update product", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Product updated successfully", toProductResponse(product))
+}
+
+// @Summary Patch product
+// @Description Change only the given fields of a product
+// @Tags products
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param input body reqresp.ProductPatchRequest true "Fields to change"
+// @Success 200 {object} reqresp.StandardResponse{data=reqresp.ProductResponse}
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/products/{id} [patch]
+func (h *ProductHandler) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid product ID", err.Error())
+		return
+	}
+
+	var req reqresp.ProductPatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+	if err := validate.Struct(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Validation failed", err.Error())
+		return
+	}
+	if req.TaxClass != nil && !domain.IsValidTaxClass(*req.TaxClass) {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid tax class", "tax_class must be lowercase letters, digits or underscores")
+		return
+	}
+
+	product, err := h.productService.PatchProduct(r.Context(), id, req)
+	if err != nil {
+		writeProductError(w, "Failed to update product", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Product updated successfully", toProductResponse(product))
+}
+
+// @Summary Delete product
+// @Description Archive a product: it leaves the catalogue and its offers go off sale, but orders still show it
+// @Tags products
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Product ID"
+// @Success 200 {object} reqresp.StandardResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/products/{id} [delete]
+func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid product ID", err.Error())
+		return
+	}
+
+	if err := h.productService.ArchiveProduct(r.Context(), id); err != nil {
+		writeProductError(w, "Failed to delete product", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Product deleted successfully", nil)
+}
+
+// parsePagination reads page and page_size, falling back to the first page of 10
+func parsePagination(r *http.Request) (page, pageSize int) {
+	page = 1
+	pageSize = 10
 
 	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
 		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
@@ -117,34 +633,130 @@ func (h *ProductHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
 			pageSize = ps
 		}
 	}
+	return page, pageSize
+}
+
+func toCategoryRefs(categories []domain.Category) []reqresp.CategoryRef {
+	refs := make([]reqresp.CategoryRef, 0, len(categories))
+	for _, c := range categories {
+		refs = append(refs, reqresp.CategoryRef{ID: c.ID, Name: c.Name, Slug: c.Slug})
+	}
+	return refs
+}
 
-	products, total, err := h.productService.ListProducts(r.Context(), page, pageSize)
-	if err != nil {
-		httpx.WriteError(w, http.StatusInternalServerError, "Failed to fetch products", err.Error())
-		return
+// parseProductFilter reads the listing filters from the query string
+func parseProductFilter(r *http.Request) (reqresp.ProductFilterRequest, error) {
+	q := r.URL.Query()
+	req := reqresp.ProductFilterRequest{
+		MinPrice: q.Get("min_price"),
+		MaxPrice: q.Get("max_price"),
+		Currency: q.Get("currency"),
+		Category: q.Get("category"),
+		Sort:     q.Get("sort"),
 	}
 
-	var productResponses []reqresp.ProductResponse
-	for _, p := range products {
-		productResponses = append(productResponses, reqresp.ProductResponse{
-			ID:          p.ID,
-			Name:        p.Name,
-			Description: p.Description,
-		})
+	var err error
+	if v := q.Get("seller_id"); v != "" {
+		if req.SellerID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return req, errors.New("seller_id must be a number")
+		}
 	}
+	if v := q.Get("in_stock"); v != "" {
+		if req.InStock, err = strconv.ParseBool(v); err != nil {
+			return req, errors.New("in_stock must be true or false")
+		}
+	}
+	return req, nil
+}
 
-	totalPages := int(total) / pageSize
+func totalPages(total int64, pageSize int) int {
+	pages := int(total) / pageSize
 	if int(total)%pageSize > 0 {
-		totalPages++
+		pages++
 	}
+	return pages
+}
 
-	response := reqresp.PaginatedResponse[reqresp.ProductResponse]{
-		Items:      productResponses,
-		Total:      total,
-		Page:       page,
-		PageSize:   pageSize,
-		TotalPages: totalPages,
+func writeProductError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrProductNotFound):
+		httpx.WriteError(w, http.StatusNotFound, "Product not found", err.Error())
+	case errors.Is(err, repositories.ErrProductExists):
+		httpx.WriteError(w, http.StatusConflict, message, err.Error())
+	default:
+		httpx.WriteError(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
+
+func writeVariantError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidVariant):
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid variant", err.Error())
+	case errors.Is(err, repositories.ErrProductNotFound):
+		httpx.WriteError(w, http.StatusNotFound, "Product not found", err.Error())
+	case errors.Is(err, repositories.ErrVariantNotFound):
+		httpx.WriteError(w, http.StatusNotFound, "Variant not found", err.Error())
+	case errors.Is(err, repositories.ErrVariantSKUExists),
+		errors.Is(err, repositories.ErrVariantExists),
+		errors.Is(err, repositories.ErrVariantInUse),
+		errors.Is(err, repositories.ErrVariantLocked),
+		errors.Is(err, repositories.ErrLastVariant):
+		httpx.WriteError(w, http.StatusConflict, message, err.Error())
+	default:
+		httpx.WriteError(w, http.StatusInternalServerError, message, err.Error())
 	}
+}
 
-	httpx.WriteSuccess(w, http.StatusOK, "Products fetched successfully", response)
+func toVariantResponse(v domain.ProductVariant) reqresp.VariantResponse {
+	return reqresp.VariantResponse{
+		ID:         v.ID,
+		ProductID:  v.ProductID,
+		SKU:        v.SKU,
+		Attributes: v.Attributes,
+	}
+}
+
+func toMatrixEntries(variants []*domain.VariantWithOffer) []reqresp.VariantMatrixEntry {
+	entries := make([]reqresp.VariantMatrixEntry, 0, len(variants))
+	for _, v := range variants {
+		entry := reqresp.VariantMatrixEntry{
+			ID:         v.ID,
+			SKU:        v.SKU,
+			Attributes: v.Attributes,
+			OfferCount: v.OfferCount,
+		}
+		if v.BestOffer != nil {
+			entry.BestOffer = &reqresp.VariantOfferResponse{
+				OfferID:  v.BestOffer.OfferID,
+				SellerID: v.BestOffer.SellerID,
+				Price:    v.BestOffer.Price,
+				Stock:    v.BestOffer.Stock,
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func toAxisResponses(axes []domain.VariantAxis) []reqresp.VariantAxisResponse {
+	resp := make([]reqresp.VariantAxisResponse, 0, len(axes))
+	for _, a := range axes {
+		resp = append(resp, reqresp.VariantAxisResponse{
+			Code:   a.Code,
+			Name:   a.Name,
+			Type:   string(a.Type),
+			Values: a.Values,
+		})
+	}
+	return resp
+}
+
+func toProductResponse(p *domain.Product) reqresp.ProductResponse {
+	return reqresp.ProductResponse{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		TaxClass:    p.TaxClass,
+		ArchivedAt:  p.ArchivedAt,
+	}
 }
@@ -10,12 +10,22 @@ func RegisterProductRoutes(r *mux.Router, handler *ProductHandler, jwtSecret []b
 	// Public endpoints
 	public := r.PathPrefix("/products").Subrouter()
 	public.HandleFunc("", handler.ListProducts).Methods("GET")
+	public.HandleFunc("/search", handler.SearchProducts).Methods("GET")
 	public.HandleFunc("/{id}", handler.GetProduct).Methods("GET")
 
+	r.HandleFunc("/categories/{slug}/products", handler.ListCategoryProducts).Methods("GET")
+
 	// Admin endpoints
 	admin := r.PathPrefix("/admin/products").Subrouter()
 	admin.Use(middleware.AuthMiddleware(jwtSecret))
 	admin.Use(middleware.RequireRoles(domain.UserRoleAdmin))
 
 	admin.HandleFunc("", handler.CreateProduct).Methods("POST")
+	admin.HandleFunc("/{id:[0-9]+}", handler.UpdateProduct).Methods("PUT")
+	admin.HandleFunc("/{id:[0-9]+}", handler.PatchProduct).Methods("PATCH")
+	admin.HandleFunc("/{id:[0-9]+}", handler.DeleteProduct).Methods("DELETE")
+	admin.HandleFunc("/{id:[0-9]+}/categories", handler.SetProductCategories).Methods("PUT")
+	admin.HandleFunc("/{id:[0-9]+}/variants", handler.CreateVariant).Methods("POST")
+	admin.HandleFunc("/{id:[0-9]+}/variants/{variantID:[0-9]+}", handler.UpdateVariant).Methods("PUT")
+	admin.HandleFunc("/{id:[0-9]+}/variants/{variantID:[0-9]+}", handler.DeleteVariant).Methods("DELETE")
 }
@@ -2,6 +2,7 @@ package refund
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/gorilla/mux"
 	"go-app-marketplace/internal/services"
 	"go-app-marketplace/pkg/httpx"
@@ -21,6 +22,7 @@ func NewHandler(s *services.RefundService) *Handler { return &Handler{s} }
 // @Produce   json
 // @Param     item_id  path   int                         true  "Order-item ID"
 // @Param     input    body   reqresp.RefundRequestBody   true  "Refund reason"
+// @Param     Idempotency-Key header string false "Replays the first response for retried requests"
 // @Success   201      {object} reqresp.StandardResponse
 // @Failure   400      {object} reqresp.StandardResponse
 // @Failure   401      {object} reqresp.StandardResponse
@@ -70,6 +72,7 @@ func (h *Handler) Request(w http.ResponseWriter, r *http.Request) {
 // @Success   200        {object} reqresp.StandardResponse
 // @Failure   400        {object} reqresp.StandardResponse
 // @Failure   401        {object} reqresp.StandardResponse
+// @Failure   502        {object} reqresp.StandardResponse "Approved, but the payment provider rejected the refund"
 // @Router    /api/refunds/{refund_id}/decide [patch]
 func (h *Handler) Decide(w http.ResponseWriter, r *http.Request) {
 
@@ -90,9 +93,50 @@ func (h *Handler) Decide(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.service.Approve(r.Context(), sellerID, refundID, approve); err != nil {
+		if errors.Is(err, services.ErrRefundPaymentFailed) {
+			httpx.WriteError(w, http.StatusBadGateway, "Refund approved but payment failed", err.Error())
+			return
+		}
 		httpx.WriteError(w, http.StatusBadRequest, "Cannot update refund", err.Error())
 		return
 	}
 
 	httpx.WriteSuccess(w, http.StatusOK, "Refund status updated successfully", nil)
 }
+
+// @Summary   Retry a failed refund
+// @Tags      refunds
+// @Security  BearerAuth
+// @Produce   json
+// @Param     refund_id  path   int    true  "Refund ID"
+// @Success   200        {object} reqresp.StandardResponse
+// @Failure   400        {object} reqresp.StandardResponse
+// @Failure   401        {object} reqresp.StandardResponse
+// @Failure   502        {object} reqresp.StandardResponse "The payment provider rejected the refund again"
+// @Router    /api/refunds/{refund_id}/retry [post]
+func (h *Handler) Retry(w http.ResponseWriter, r *http.Request) {
+
+	refundIDStr := mux.Vars(r)["refund_id"]
+	refundID, err := strconv.ParseInt(refundIDStr, 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid refund ID", err.Error())
+		return
+	}
+
+	sellerID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "Unauthorized", "Invalid user context")
+		return
+	}
+
+	if err := h.service.Retry(r.Context(), sellerID, refundID); err != nil {
+		if errors.Is(err, services.ErrRefundPaymentFailed) {
+			httpx.WriteError(w, http.StatusBadGateway, "Refund payment failed", err.Error())
+			return
+		}
+		httpx.WriteError(w, http.StatusBadRequest, "Cannot retry refund", err.Error())
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Refund resubmitted successfully", nil)
+}
@@ -11,6 +11,7 @@ func Register(r *mux.Router, h *Handler, jwt []byte) {
 	// /api/refunds
 	sub := r.PathPrefix("/refunds").Subrouter()
 	sub.Use(middleware.AuthMiddleware(jwt))
+	sub.Use(middleware.Idempotency())
 
 	// Customer
 	sub.HandleFunc("/{item_id:[0-9]+}", h.Request).Methods("POST")
@@ -19,5 +20,6 @@ func Register(r *mux.Router, h *Handler, jwt []byte) {
 	seller := sub.PathPrefix("").Subrouter()
 	seller.Use(middleware.RequireRoles(domain.UserRoleSeller))
 	seller.HandleFunc("/{refund_id:[0-9]+}/decide", h.Decide).Methods("PATCH")
+	seller.HandleFunc("/{refund_id:[0-9]+}/retry", h.Retry).Methods("POST")
 
 }
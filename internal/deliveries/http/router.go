@@ -1,29 +1,48 @@
 package http
 
 import (
+	"expvar"
 	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger"
 	_ "go-app-marketplace/docs"
+	"go-app-marketplace/internal/deliveries/http/address"
 	"go-app-marketplace/internal/deliveries/http/cart"
+	"go-app-marketplace/internal/deliveries/http/category"
+	"go-app-marketplace/internal/deliveries/http/exchangerate"
 	"go-app-marketplace/internal/deliveries/http/offer"
 	"go-app-marketplace/internal/deliveries/http/order"
+	"go-app-marketplace/internal/deliveries/http/payment"
 	"go-app-marketplace/internal/deliveries/http/product"
 	"go-app-marketplace/internal/deliveries/http/refund"
+	"go-app-marketplace/internal/deliveries/http/shipment"
+	"go-app-marketplace/internal/deliveries/http/shipping"
+	"go-app-marketplace/internal/deliveries/http/tax"
 	"go-app-marketplace/internal/deliveries/http/user"
 	"go-app-marketplace/internal/deliveries/http/webhook"
+	"go-app-marketplace/internal/middleware"
+	"go-app-marketplace/internal/payments"
 	"go-app-marketplace/internal/services"
+	"go-app-marketplace/pkg/domain"
 	"net/http"
 )
 
 type Services struct {
-	User    *services.UserService
-	Cart    *services.CartService
-	Product *services.ProductService
-	Offer   *services.OfferService
-	Order   *services.OrderService
-	Payment *services.PaymentService
-	Refund  *services.RefundService
-	JWTKey  []byte
+	User         *services.UserService
+	Address      *services.AddressService
+	Cart         *services.CartService
+	Product      *services.ProductService
+	Category     *services.CategoryService
+	Variant      *services.VariantService
+	Offer        *services.OfferService
+	Order        *services.OrderService
+	Payment      *services.PaymentService
+	Refund       *services.RefundService
+	Shipping     *services.ShippingService
+	Shipment     *services.ShipmentService
+	Webhook      *services.WebhookService
+	ExchangeRate *services.ExchangeRateService
+	Tax          *services.TaxService
+	JWTKey       []byte
 }
 
 func NewRouter(s *Services) http.Handler {
@@ -40,29 +59,68 @@ func NewRouter(s *Services) http.Handler {
 	// User routes
 	user.RegisterUserRoutes(api.PathPrefix("/").Subrouter(), s.User, s.JWTKey)
 
+	// Address book routes
+	addressHandler := address.NewAddressHandler(s.Address)
+	address.RegisterAddressRoutes(api.PathPrefix("/").Subrouter(), addressHandler, s.JWTKey)
+
 	// Cart routes
 	cartHandler := cart.NewCartHandler(s.Cart)
 	cart.RegisterCartRoutes(api.PathPrefix("/").Subrouter(), cartHandler, s.JWTKey)
 
 	// Product routes
-	productHandler := product.NewProductHandler(s.Product, s.Offer)
+	productHandler := product.NewProductHandler(s.Product, s.Offer, s.Category, s.Variant)
 	product.RegisterProductRoutes(api.PathPrefix("/").Subrouter(), productHandler, s.JWTKey)
 
+	// Category tree
+	categoryHandler := category.NewCategoryHandler(s.Category)
+	category.RegisterCategoryRoutes(api.PathPrefix("/").Subrouter(), categoryHandler, s.JWTKey)
+
 	// Offer routes
 	offerHandler := offer.NewOfferHandler(s.Offer)
 	offer.RegisterOfferRoutes(api.PathPrefix("/").Subrouter(), offerHandler, s.JWTKey)
 
+	// Seller shipping profiles
+	shippingHandler := shipping.NewShippingHandler(s.Shipping)
+	shipping.RegisterShippingRoutes(api.PathPrefix("/").Subrouter(), shippingHandler, s.JWTKey)
+
 	// Order routes
 	orderHandler := order.NewOrderHandler(s.Order)
 	order.RegisterOrderRoutes(api.PathPrefix("/").Subrouter(), orderHandler, s.JWTKey)
 
+	// Seller shipments of orders
+	shipmentHandler := shipment.NewShipmentHandler(s.Shipment)
+	shipment.RegisterShipmentRoutes(api.PathPrefix("/").Subrouter(), shipmentHandler, s.JWTKey)
+
+	// Payment ledger routes
+	paymentHandler := payment.NewPaymentHandler(s.Payment)
+	payment.RegisterPaymentRoutes(api.PathPrefix("/").Subrouter(), paymentHandler, s.JWTKey)
+
+	// Exchange rates for multi-currency checkout
+	exchangeRateHandler := exchangerate.NewExchangeRateHandler(s.ExchangeRate)
+	exchangerate.RegisterExchangeRateRoutes(api.PathPrefix("/").Subrouter(), exchangeRateHandler, s.JWTKey)
+
+	// Tax rates by jurisdiction and product tax class
+	taxHandler := tax.NewTaxHandler(s.Tax)
+	tax.RegisterTaxRoutes(api.PathPrefix("/").Subrouter(), taxHandler, s.JWTKey)
+
 	// Refund routes
 	refundHandler := refund.NewHandler(s.Refund)
 	refund.Register(api.PathPrefix("/").Subrouter(), refundHandler, s.JWTKey)
 
-	// Stripe Webhook Handler
-	stripeWebhookHandler := webhook.NewStripeWebhookHandler(s.Order, s.Payment.GetWebhookSecret())
-	r.HandleFunc("/api/webhook/stripe", stripeWebhookHandler.HandleWebhook).Methods("POST")
+	// Payment provider webhooks
+	webhookHandler := webhook.NewStripeWebhookHandler(s.Payment, s.Webhook)
+	webhook.RegisterWebhookRoutes(api.PathPrefix("/").Subrouter(), webhookHandler, s.Payment.ProviderName(), s.JWTKey)
+
+	// Hosted payment page of the local fake provider
+	if fake, ok := s.Payment.Gateway().(*payments.FakeGateway); ok {
+		fake.RegisterRoutes(r)
+	}
+
+	// Runtime and scheduler metrics, admins only
+	debug := r.PathPrefix("/debug").Subrouter()
+	debug.Use(middleware.AuthMiddleware(s.JWTKey))
+	debug.Use(middleware.RequireRoles(domain.UserRoleAdmin))
+	debug.Handle("/vars", expvar.Handler()).Methods("GET")
 
 	// Swagger
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
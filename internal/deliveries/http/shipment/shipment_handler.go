@@ -0,0 +1,109 @@
+package shipment
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/internal/services"
+	"go-app-marketplace/internal/usecases"
+	"go-app-marketplace/pkg/domain"
+	"go-app-marketplace/pkg/httpx"
+	"go-app-marketplace/pkg/reqresp"
+	"net/http"
+	"strconv"
+)
+
+var validate = validator.New()
+
+type ShipmentHandler struct {
+	shipmentService *services.ShipmentService
+}
+
+func NewShipmentHandler(shipmentService *services.ShipmentService) *ShipmentHandler {
+	return &ShipmentHandler{shipmentService: shipmentService}
+}
+
+// @Summary List my shipments
+// @Description The seller's part of every order, newest first
+// @Tags shipments
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "Filter by status: pending | packed | shipped | delivered | cancelled"
+// @Success 200 {array} reqresp.ShipmentResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/seller/shipments [get]
+func (h *ShipmentHandler) ListShipments(w http.ResponseWriter, r *http.Request) {
+	sellerID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "Unauthorized", "Invalid user context")
+		return
+	}
+
+	shipments, err := h.shipmentService.ListBySeller(r.Context(), sellerID, r.URL.Query().Get("status"))
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to fetch shipments", err.Error())
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Shipments retrieved successfully", shipments)
+}
+
+// @Summary Update a shipment
+// @Description Move the shipment pending → packed → shipped → delivered and set its carrier and tracking number.
+// @Description Shipping requires both; the shipment's items follow its status.
+// @Tags shipments
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Shipment ID"
+// @Param input body reqresp.ShipmentUpdateRequest true "New status and tracking"
+// @Success 200 {object} reqresp.ShipmentResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/seller/shipments/{id} [patch]
+func (h *ShipmentHandler) UpdateShipment(w http.ResponseWriter, r *http.Request) {
+	sellerID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "Unauthorized", "Invalid user context")
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid shipment ID", err.Error())
+		return
+	}
+
+	var req reqresp.ShipmentUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if err := validate.Struct(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Validation failed", err.Error())
+		return
+	}
+
+	shipment, err := h.shipmentService.Update(r.Context(), sellerID, id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrShipmentNotFound):
+			httpx.WriteError(w, http.StatusNotFound, "Shipment not found", err.Error())
+		case errors.Is(err, usecases.ErrShipmentOrderUnpaid), errors.Is(err, domain.ErrInvalidTransition):
+			httpx.WriteError(w, http.StatusConflict, "Failed to update shipment", err.Error())
+		default:
+			httpx.WriteError(w, http.StatusInternalServerError, "Failed to update shipment", err.Error())
+		}
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Shipment updated successfully", shipment)
+}
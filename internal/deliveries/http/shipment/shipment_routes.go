@@ -0,0 +1,18 @@
+package shipment
+
+import (
+	"github.com/gorilla/mux"
+	"go-app-marketplace/internal/middleware"
+	"go-app-marketplace/pkg/domain"
+	"net/http"
+)
+
+func RegisterShipmentRoutes(r *mux.Router, h *ShipmentHandler, jwtKey []byte) {
+
+	seller := r.PathPrefix("/seller/shipments").Subrouter()
+	seller.Use(middleware.AuthMiddleware(jwtKey))
+	seller.Use(middleware.RequireRoles(domain.UserRoleSeller))
+
+	seller.HandleFunc("", h.ListShipments).Methods(http.MethodGet)
+	seller.HandleFunc("/{id:[0-9]+}", h.UpdateShipment).Methods(http.MethodPatch)
+}
@@ -0,0 +1,246 @@
+package shipping
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/internal/services"
+	"go-app-marketplace/pkg/domain"
+	"go-app-marketplace/pkg/httpx"
+	"go-app-marketplace/pkg/reqresp"
+	"net/http"
+	"strconv"
+)
+
+var validate = validator.New()
+
+type ShippingHandler struct {
+	shippingService *services.ShippingService
+}
+
+func NewShippingHandler(shippingService *services.ShippingService) *ShippingHandler {
+	return &ShippingHandler{shippingService: shippingService}
+}
+
+// @Summary List my shipping profiles
+// @Description The default profile, listed first, prices delivery of the seller's items at checkout
+// @Tags shipping
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} reqresp.ShippingProfileResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/seller/shipping-profiles [get]
+func (h *ShippingHandler) ListProfiles(w http.ResponseWriter, r *http.Request) {
+	sellerID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "Unauthorized", "Invalid user context")
+		return
+	}
+
+	profiles, err := h.shippingService.ListProfiles(r.Context(), sellerID)
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to list shipping profiles", err.Error())
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Shipping profiles retrieved successfully", profiles)
+}
+
+// @Summary Get a shipping profile
+// @Tags shipping
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Shipping profile ID"
+// @Success 200 {object} reqresp.ShippingProfileResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Router /api/seller/shipping-profiles/{id} [get]
+func (h *ShippingHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	sellerID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "Unauthorized", "Invalid user context")
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid shipping profile ID", err.Error())
+		return
+	}
+
+	profile, err := h.shippingService.GetProfile(r.Context(), sellerID, id)
+	if err != nil {
+		writeShippingError(w, "Failed to get shipping profile", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Shipping profile retrieved successfully", profile)
+}
+
+// @Summary Create a shipping profile
+// @Description Flat or per-item rate, optionally free above a threshold and overridden per country or region. The first profile becomes the default.
+// @Tags shipping
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body reqresp.ShippingProfileRequest true "Shipping profile"
+// @Success 201 {object} reqresp.ShippingProfileResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/seller/shipping-profiles [post]
+func (h *ShippingHandler) CreateProfile(w http.ResponseWriter, r *http.Request) {
+	sellerID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "Unauthorized", "Invalid user context")
+		return
+	}
+
+	var req reqresp.ShippingProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if err := validate.Struct(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Validation failed", err.Error())
+		return
+	}
+
+	profile, err := h.shippingService.CreateProfile(r.Context(), sellerID, &req)
+	if err != nil {
+		writeShippingError(w, "Failed to create shipping profile", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusCreated, "Shipping profile created successfully", profile)
+}
+
+// @Summary Update a shipping profile
+// @Description Replaces the profile including its zone rates; placed orders keep the shipping they were charged
+// @Tags shipping
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Shipping profile ID"
+// @Param input body reqresp.ShippingProfileRequest true "Shipping profile"
+// @Success 200 {object} reqresp.ShippingProfileResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/seller/shipping-profiles/{id} [put]
+func (h *ShippingHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	sellerID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "Unauthorized", "Invalid user context")
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid shipping profile ID", err.Error())
+		return
+	}
+
+	var req reqresp.ShippingProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if err := validate.Struct(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Validation failed", err.Error())
+		return
+	}
+
+	profile, err := h.shippingService.UpdateProfile(r.Context(), sellerID, id, &req)
+	if err != nil {
+		writeShippingError(w, "Failed to update shipping profile", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Shipping profile updated successfully", profile)
+}
+
+// @Summary Make a shipping profile the default
+// @Tags shipping
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Shipping profile ID"
+// @Success 200 {object} reqresp.StandardResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Router /api/seller/shipping-profiles/{id}/default [post]
+func (h *ShippingHandler) SetDefaultProfile(w http.ResponseWriter, r *http.Request) {
+	sellerID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "Unauthorized", "Invalid user context")
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid shipping profile ID", err.Error())
+		return
+	}
+
+	if err := h.shippingService.SetDefaultProfile(r.Context(), sellerID, id); err != nil {
+		writeShippingError(w, "Failed to set default shipping profile", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Default shipping profile updated successfully", nil)
+}
+
+// @Summary Delete a shipping profile
+// @Description Deleting the default profile makes the oldest remaining one the default; without profiles the seller ships free
+// @Tags shipping
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Shipping profile ID"
+// @Success 200 {object} reqresp.StandardResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Router /api/seller/shipping-profiles/{id} [delete]
+func (h *ShippingHandler) DeleteProfile(w http.ResponseWriter, r *http.Request) {
+	sellerID, ok := r.Context().Value("user_id").(int64)
+	if !ok {
+		httpx.WriteError(w, http.StatusUnauthorized, "Unauthorized", "Invalid user context")
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid shipping profile ID", err.Error())
+		return
+	}
+
+	if err := h.shippingService.DeleteProfile(r.Context(), sellerID, id); err != nil {
+		writeShippingError(w, "Failed to delete shipping profile", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Shipping profile deleted successfully", nil)
+}
+
+func writeShippingError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, repositories.ErrShippingProfileNotFound):
+		httpx.WriteError(w, http.StatusNotFound, "Shipping profile not found", err.Error())
+	case errors.Is(err, domain.ErrInvalidShippingProfile):
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid shipping profile", err.Error())
+	default:
+		httpx.WriteError(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
@@ -0,0 +1,23 @@
+package shipping
+
+import (
+	"github.com/gorilla/mux"
+	"go-app-marketplace/internal/middleware"
+	"go-app-marketplace/pkg/domain"
+	"net/http"
+)
+
+func RegisterShippingRoutes(r *mux.Router, h *ShippingHandler, jwtKey []byte) {
+
+	seller := r.PathPrefix("/seller/shipping-profiles").Subrouter()
+	seller.Use(middleware.AuthMiddleware(jwtKey))
+	seller.Use(middleware.RequireRoles(domain.UserRoleSeller))
+
+	seller.HandleFunc("", h.ListProfiles).Methods(http.MethodGet)
+	seller.HandleFunc("", h.CreateProfile).Methods(http.MethodPost)
+
+	seller.HandleFunc("/{id:[0-9]+}", h.GetProfile).Methods(http.MethodGet)
+	seller.HandleFunc("/{id:[0-9]+}", h.UpdateProfile).Methods(http.MethodPut)
+	seller.HandleFunc("/{id:[0-9]+}", h.DeleteProfile).Methods(http.MethodDelete)
+	seller.HandleFunc("/{id:[0-9]+}/default", h.SetDefaultProfile).Methods(http.MethodPost)
+}
@@ -0,0 +1,163 @@
+package tax
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/internal/services"
+	"go-app-marketplace/pkg/domain"
+	"go-app-marketplace/pkg/httpx"
+	"go-app-marketplace/pkg/reqresp"
+	"net/http"
+	"strconv"
+)
+
+var validate = validator.New()
+
+type TaxHandler struct {
+	taxService *services.TaxService
+}
+
+func NewTaxHandler(taxService *services.TaxService) *TaxHandler {
+	return &TaxHandler{taxService: taxService}
+}
+
+// @Summary List tax rates
+// @Description Rates charged at checkout by destination and product tax class
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {array} domain.TaxRate
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/tax-rates [get]
+func (h *TaxHandler) List(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.taxService.List(r.Context())
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to list tax rates", err.Error())
+		return
+	}
+	if rates == nil {
+		rates = []domain.TaxRate{}
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Tax rates retrieved successfully", rates)
+}
+
+// @Summary Create a tax rate
+// @Description Rate is a percentage; an empty region applies to the whole country
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param input body reqresp.TaxRateRequest true "Tax rate"
+// @Success 201 {object} domain.TaxRate
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/tax-rates [post]
+func (h *TaxHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req reqresp.TaxRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if err := validate.Struct(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Validation failed", err.Error())
+		return
+	}
+
+	rate, err := h.taxService.Create(r.Context(), req)
+	if err != nil {
+		writeTaxError(w, "Failed to create tax rate", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusCreated, "Tax rate created successfully", rate)
+}
+
+// @Summary Update a tax rate
+// @Tags admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "Tax rate ID"
+// @Param input body reqresp.TaxRateRequest true "Tax rate"
+// @Success 200 {object} domain.TaxRate
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/tax-rates/{id} [put]
+func (h *TaxHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid tax rate ID", err.Error())
+		return
+	}
+
+	var req reqresp.TaxRateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if err := validate.Struct(&req); err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Validation failed", err.Error())
+		return
+	}
+
+	rate, err := h.taxService.Update(r.Context(), id, req)
+	if err != nil {
+		writeTaxError(w, "Failed to update tax rate", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Tax rate updated successfully", rate)
+}
+
+// @Summary Delete a tax rate
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "Tax rate ID"
+// @Success 200 {object} reqresp.StandardResponse
+// @Failure 400 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 500 {object} reqresp.StandardResponse
+// @Router /api/admin/tax-rates/{id} [delete]
+func (h *TaxHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid tax rate ID", err.Error())
+		return
+	}
+
+	if err := h.taxService.Delete(r.Context(), id); err != nil {
+		writeTaxError(w, "Failed to delete tax rate", err)
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Tax rate deleted successfully", nil)
+}
+
+func writeTaxError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidTaxRate):
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid tax rate", err.Error())
+	case errors.Is(err, repositories.ErrTaxRateNotFound):
+		httpx.WriteError(w, http.StatusNotFound, "Tax rate not found", err.Error())
+	case errors.Is(err, repositories.ErrTaxRateExists):
+		httpx.WriteError(w, http.StatusConflict, "Tax rate already exists", err.Error())
+	default:
+		httpx.WriteError(w, http.StatusInternalServerError, message, err.Error())
+	}
+}
@@ -0,0 +1,21 @@
+package tax
+
+import (
+	"github.com/gorilla/mux"
+	"go-app-marketplace/internal/middleware"
+	"go-app-marketplace/pkg/domain"
+	"net/http"
+)
+
+func RegisterTaxRoutes(r *mux.Router, h *TaxHandler, jwtKey []byte) {
+
+	admin := r.PathPrefix("/admin/tax-rates").Subrouter()
+	admin.Use(middleware.AuthMiddleware(jwtKey))
+	admin.Use(middleware.RequireRoles(domain.UserRoleAdmin))
+
+	admin.HandleFunc("", h.List).Methods(http.MethodGet)
+	admin.HandleFunc("", h.Create).Methods(http.MethodPost)
+
+	admin.HandleFunc("/{id:[0-9]+}", h.Update).Methods(http.MethodPut)
+	admin.HandleFunc("/{id:[0-9]+}", h.Delete).Methods(http.MethodDelete)
+}
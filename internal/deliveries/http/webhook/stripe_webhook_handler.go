@@ -2,8 +2,10 @@ package webhook
 
 import (
 	"encoding/json"
-	"github.com/stripe/stripe-go/v82"
-	"github.com/stripe/stripe-go/v82/webhook"
+	"errors"
+	"github.com/gorilla/mux"
+	"go-app-marketplace/internal/payments"
+	"go-app-marketplace/internal/repositories"
 	"go-app-marketplace/internal/services"
 	"go-app-marketplace/pkg/domain"
 	"go-app-marketplace/pkg/httpx"
@@ -11,32 +13,39 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 )
 
 var _ = reqresp.StandardResponse{}
 
 type StripeWebhookHandler struct {
-	orderService  *services.OrderService
-	signingSecret string
+	paymentService *services.PaymentService
+	webhookService *services.WebhookService
 }
 
-func NewStripeWebhookHandler(orderService *services.OrderService, secret string) *StripeWebhookHandler {
+func NewStripeWebhookHandler(
+	paymentService *services.PaymentService,
+	webhookService *services.WebhookService,
+) *StripeWebhookHandler {
 	return &StripeWebhookHandler{
-		orderService:  orderService,
-		signingSecret: secret,
+		paymentService: paymentService,
+		webhookService: webhookService,
 	}
 }
 
-// @Summary Handle Stripe webhook events
-// @Description Process Stripe webhook events for payment status updates
+// @Summary Handle payment provider webhook events
+// @Description Process Stripe (or fake provider) webhook events for payment status updates
 // @Tags webhooks
 // @Accept json
 // @Produce json
-// @Param Stripe-Signature header string true "Stripe webhook signature"
+// @Param provider path string true "Payment provider: stripe | fake"
+// @Param Stripe-Signature header string false "Stripe webhook signature"
+// @Param Fake-Signature header string false "Fake provider webhook signature"
 // @Success 200 {object} reqresp.StandardResponse "Webhook received"
 // @Failure 400 {object} reqresp.StandardResponse "Invalid webhook signature"
+// @Failure 500 {object} reqresp.StandardResponse "Processing failed, the provider will retry"
 // @Failure 503 {object} reqresp.StandardResponse "Service unavailable"
-// @Router /api/webhook/stripe [post]
+// @Router /api/webhook/{provider} [post]
 func (h *StripeWebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 	log.Println("Webhook triggered")
 
@@ -49,48 +58,128 @@ func (h *StripeWebhookHandler) HandleWebhook(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	event, err := webhook.ConstructEvent(payload, r.Header.Get("Stripe-Signature"), h.signingSecret)
+	event, err := h.paymentService.VerifyWebhook(payload, r.Header)
 	if err != nil {
-		httpx.WriteError(w, http.StatusBadRequest, "Invalid Stripe signature", err.Error())
+		if errors.Is(err, payments.ErrInvalidSignature) {
+			httpx.WriteError(w, http.StatusBadRequest, "Invalid webhook signature", err.Error())
+			return
+		}
+		httpx.WriteError(w, http.StatusBadRequest, "Invalid webhook payload", err.Error())
+		return
+	}
+
+	duplicate, err := h.webhookService.Process(r.Context(), event)
+	if err != nil {
+		// Non-2xx makes the provider deliver the event again
+		log.Printf("Webhook processing failed: %v", err)
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to process webhook", err.Error())
 		return
 	}
+	if duplicate {
+		log.Printf("Webhook event %s already handled", event.ID)
+		httpx.WriteSuccess(w, http.StatusOK, "Webhook already processed", nil)
+		return
+	}
+
+	log.Println("Webhook OK")
+	httpx.WriteSuccess(w, http.StatusOK, "Webhook processed", nil)
+}
+
+// @Summary List received webhook events
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param status query string false "Filter by status: processing | processed | failed" default(failed)
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Number of items per page" default(20)
+// @Success 200 {object} reqresp.PaginatedResponse[reqresp.WebhookEventResponse]
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 403 {object} reqresp.StandardResponse
+// @Router /api/admin/webhook-events [get]
+func (h *StripeWebhookHandler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	status := string(domain.WebhookEventFailed)
+	if query.Has("status") {
+		status = query.Get("status")
+	}
+
+	page := 1
+	pageSize := 20
 
-	switch event.Type {
-	case "payment_intent.succeeded":
-		var pi stripe.PaymentIntent
-		if err := json.Unmarshal(event.Data.Raw, &pi); err == nil {
-			orderIDStr := pi.Metadata["order_id"]
-			if orderIDStr == "" {
-				log.Println("Missing order_id in metadata")
-				break
-			}
-
-			log.Printf("Payment succeeded for Order ID: %s", orderIDStr)
-
-			err := h.orderService.UpdatePaymentStatusByOrderID(r.Context(), orderIDStr, domain.PaymentStatusSuccessful)
-			if err != nil {
-				log.Printf("Failed to update order status: %v", err)
-			}
+	if pageStr := query.Get("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
 		}
+	}
 
-	case "payment_intent.payment_failed":
-		var pi stripe.PaymentIntent
-		if err := json.Unmarshal(event.Data.Raw, &pi); err == nil {
-			orderIDStr := pi.Metadata["order_id"]
-			if orderIDStr == "" {
-				log.Println("Missing order_id in metadata")
-				break
-			}
-
-			log.Printf("Payment failed for Order ID: %s", orderIDStr)
-
-			err := h.orderService.UpdatePaymentStatusByOrderID(r.Context(), orderIDStr, domain.PaymentStatusFailed)
-			if err != nil {
-				log.Printf("Failed to update order status: %v", err)
-			}
+	if pageSizeStr := query.Get("page_size"); pageSizeStr != "" {
+		if ps, err := strconv.Atoi(pageSizeStr); err == nil && ps > 0 && ps <= 100 {
+			pageSize = ps
 		}
 	}
 
-	log.Println("Webhook OK")
-	httpx.WriteSuccess(w, http.StatusOK, "Webhook processed", nil)
+	events, total, err := h.webhookService.List(r.Context(), status, page, pageSize)
+	if err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Failed to fetch webhook events", err.Error())
+		return
+	}
+
+	items := make([]reqresp.WebhookEventResponse, 0, len(events))
+	for _, ev := range events {
+		items = append(items, reqresp.WebhookEventResponse{
+			ID:          ev.ID,
+			Provider:    ev.Provider,
+			EventType:   ev.EventType,
+			Status:      string(ev.Status),
+			Attempts:    ev.Attempts,
+			LastError:   ev.LastError,
+			Payload:     json.RawMessage(ev.Payload),
+			ReceivedAt:  ev.ReceivedAt,
+			ProcessedAt: ev.ProcessedAt,
+		})
+	}
+
+	totalPages := int(total) / pageSize
+	if int(total)%pageSize > 0 {
+		totalPages++
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Webhook events fetched successfully", reqresp.PaginatedResponse[reqresp.WebhookEventResponse]{
+		Items:      items,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	})
+}
+
+// @Summary Replay a failed webhook event
+// @Description Runs the stored payload through the same processing as a live delivery
+// @Tags admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path string true "Provider event ID"
+// @Success 200 {object} reqresp.StandardResponse
+// @Failure 401 {object} reqresp.StandardResponse
+// @Failure 404 {object} reqresp.StandardResponse
+// @Failure 409 {object} reqresp.StandardResponse "Event is not in the failed state"
+// @Failure 500 {object} reqresp.StandardResponse "Processing failed again"
+// @Router /api/admin/webhook-events/{id}/replay [post]
+func (h *StripeWebhookHandler) ReplayEvent(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	if err := h.webhookService.Replay(r.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrWebhookEventNotFound):
+			httpx.WriteError(w, http.StatusNotFound, "Webhook event not found", err.Error())
+		case errors.Is(err, services.ErrWebhookEventNotReplayable):
+			httpx.WriteError(w, http.StatusConflict, "Webhook event cannot be replayed", err.Error())
+		default:
+			httpx.WriteError(w, http.StatusInternalServerError, "Failed to replay webhook event", err.Error())
+		}
+		return
+	}
+
+	httpx.WriteSuccess(w, http.StatusOK, "Webhook event replayed successfully", nil)
 }
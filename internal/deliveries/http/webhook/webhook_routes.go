@@ -0,0 +1,21 @@
+package webhook
+
+import (
+	"github.com/gorilla/mux"
+	"go-app-marketplace/internal/middleware"
+	"go-app-marketplace/pkg/domain"
+	"net/http"
+)
+
+func RegisterWebhookRoutes(r *mux.Router, h *StripeWebhookHandler, provider string, jwtKey []byte) {
+	// Called by the provider, authenticated by the payload signature
+	r.HandleFunc("/webhook/"+provider, h.HandleWebhook).Methods(http.MethodPost)
+
+	admin := r.PathPrefix("/admin/webhook-events").Subrouter()
+	admin.Use(middleware.AuthMiddleware(jwtKey))
+	admin.Use(middleware.RequireRoles(domain.UserRoleAdmin))
+
+	admin.HandleFunc("", h.ListEvents).Methods(http.MethodGet)
+
+	admin.HandleFunc("/{id}/replay", h.ReplayEvent).Methods(http.MethodPost)
+}
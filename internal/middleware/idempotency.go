@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go-app-marketplace/internal/redisdb"
+	"go-app-marketplace/pkg/httpx"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	idempotencyTTL       = 24 * time.Hour
+	// idempotencyPendingTTL bounds how long a request that never finished,
+	// e.g. because the process died, blocks retries with the same key
+	idempotencyPendingTTL = 5 * time.Minute
+	idempotencyMaxKeyLen  = 255
+	idempotencyMaxBody    = 1 << 20
+)
+
+// idempotencyRecord is what is kept in Redis for every key
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Completed   bool   `json:"completed"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Idempotency replays the recorded response when a POST is retried with the same
+// Idempotency-Key. Keys are scoped per user, so it must run after AuthMiddleware.
+// Reusing a key with a different method, path or body is rejected with 422.
+func Idempotency() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > idempotencyMaxKeyLen {
+				httpx.WriteError(w, http.StatusBadRequest, "Invalid Idempotency-Key", "key must not exceed 255 characters")
+				return
+			}
+
+			// Read one byte past the limit to tell a body that fits from one that was cut off
+			body, err := io.ReadAll(io.LimitReader(r.Body, idempotencyMaxBody+1))
+			if err != nil {
+				httpx.WriteError(w, http.StatusBadRequest, "Invalid request body", err.Error())
+				return
+			}
+			if len(body) > idempotencyMaxBody {
+				httpx.WriteError(w, http.StatusRequestEntityTooLarge, "Request body too large",
+					"requests with an Idempotency-Key must not exceed 1MB")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			userID, _ := r.Context().Value("user_id").(int64)
+			redisKey := fmt.Sprintf("idempotency:%d:%s", userID, key)
+			fingerprint := requestFingerprint(r, body)
+
+			ctx := r.Context()
+			pending, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint})
+			acquired, err := redisdb.Rdb.SetNX(ctx, redisKey, pending, idempotencyPendingTTL).Result()
+			if err != nil {
+				httpx.WriteError(w, http.StatusServiceUnavailable, "Idempotency store unavailable", err.Error())
+				return
+			}
+
+			if !acquired {
+				replayIdempotent(w, r, redisKey, fingerprint)
+				return
+			}
+
+			// A panicking handler must not leave the key pending, retries would get 409
+			defer func() {
+				if p := recover(); p != nil {
+					_ = redisdb.Rdb.Del(ctx, redisKey)
+					panic(p)
+				}
+			}()
+
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			// Server errors are not recorded so that the client can retry with the same key
+			if rec.status >= http.StatusInternalServerError {
+				_ = redisdb.Rdb.Del(ctx, redisKey)
+				return
+			}
+
+			done, _ := json.Marshal(idempotencyRecord{
+				Fingerprint: fingerprint,
+				Completed:   true,
+				Status:      rec.status,
+				ContentType: rec.Header().Get("Content-Type"),
+				Body:        rec.body.Bytes(),
+			})
+			_ = redisdb.Rdb.Set(ctx, redisKey, done, idempotencyTTL)
+		})
+	}
+}
+
+func replayIdempotent(w http.ResponseWriter, r *http.Request, redisKey, fingerprint string) {
+	raw, err := redisdb.Rdb.Get(r.Context(), redisKey).Bytes()
+	if err != nil {
+		httpx.WriteError(w, http.StatusConflict, "Request in progress", "a request with this Idempotency-Key is being processed")
+		return
+	}
+
+	var stored idempotencyRecord
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		httpx.WriteError(w, http.StatusInternalServerError, "Corrupted idempotency record", err.Error())
+		return
+	}
+
+	if stored.Fingerprint != fingerprint {
+		httpx.WriteError(w, http.StatusUnprocessableEntity, "Idempotency-Key reused",
+			"this key was already used with a different request")
+		return
+	}
+	if !stored.Completed {
+		httpx.WriteError(w, http.StatusConflict, "Request in progress", "a request with this Idempotency-Key is being processed")
+		return
+	}
+
+	if stored.ContentType != "" {
+		w.Header().Set("Content-Type", stored.ContentType)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.Status)
+	_, _ = w.Write(stored.Body)
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.Path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder passes the response through while keeping a copy of it
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	rr.status = status
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
@@ -0,0 +1,453 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	FakeSignatureHeader = "Fake-Signature"
+	fakeSignatureMaxAge = 5 * time.Minute
+	// Refund confirmations are delivered later, like a real provider does
+	fakeRefundDelay = 2 * time.Second
+)
+
+// FakeGateway is a fully local provider for development and demos. It keeps
+// sessions in memory, serves its own hosted payment page under /fakepay and
+// delivers signed webhooks to /api/webhook/fake like Stripe would.
+type FakeGateway struct {
+	baseURL       string
+	webhookSecret string
+	httpClient    *http.Client
+
+	mu       sync.Mutex
+	sessions map[string]*fakeSession
+	refunds  map[string]*Refund
+	// refunded sums the refunds per payment intent
+	refunded map[string]int64
+	// idempotent maps refund idempotency keys to the refund they created
+	idempotent map[string]string
+}
+
+type fakeSession struct {
+	ID              string
+	OrderID         int64
+	PaymentIntentID string
+	Amount          int64
+	TaxAmount       int64
+	Currency        string
+	LineItems       []LineItem
+	SuccessURL      string
+	CancelURL       string
+	ExpiresAt       time.Time
+	Status          string
+}
+
+// fakeEvent mirrors the shape of a Stripe event so payloads look familiar
+type fakeEvent struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object fakeEventObject `json:"object"`
+	} `json:"data"`
+}
+
+type fakeEventObject struct {
+	ID             string            `json:"id"`
+	PaymentIntent  string            `json:"payment_intent,omitempty"`
+	Amount         int64             `json:"amount"`
+	AmountRefunded int64             `json:"amount_refunded,omitempty"`
+	Currency       string            `json:"currency"`
+	Metadata       map[string]string `json:"metadata"`
+}
+
+func NewFakeGateway(baseURL, webhookSecret string) *FakeGateway {
+	return &FakeGateway{
+		baseURL:       strings.TrimRight(baseURL, "/"),
+		webhookSecret: webhookSecret,
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
+		sessions:      make(map[string]*fakeSession),
+		refunds:       make(map[string]*Refund),
+		refunded:      make(map[string]int64),
+		idempotent:    make(map[string]string),
+	}
+}
+
+func (g *FakeGateway) Name() string { return ProviderFake }
+
+func (g *FakeGateway) CreateCheckout(_ context.Context, p CheckoutParams) (*CheckoutSession, error) {
+	s := &fakeSession{
+		ID:              "cs_fake_" + randomID(),
+		OrderID:         p.OrderID,
+		PaymentIntentID: "pi_fake_" + randomID(),
+		Amount:          p.Amount,
+		TaxAmount:       p.TaxAmount,
+		Currency:        p.Currency,
+		LineItems:       p.LineItems,
+		SuccessURL:      p.SuccessURL,
+		CancelURL:       p.CancelURL,
+		ExpiresAt:       p.ExpiresAt,
+		Status:          SessionStatusOpen,
+	}
+
+	g.mu.Lock()
+	g.sessions[s.ID] = s
+	g.mu.Unlock()
+
+	raw, _ := json.Marshal(s)
+	return &CheckoutSession{
+		ID:  s.ID,
+		URL: fmt.Sprintf("%s/fakepay/checkout/%s", g.baseURL, s.ID),
+		Raw: raw,
+	}, nil
+}
+
+func (g *FakeGateway) Refund(_ context.Context, p RefundParams) (*Refund, error) {
+	if p.PaymentIntentID == "" {
+		return nil, fmt.Errorf("fake refund: payment intent is required")
+	}
+	if p.Amount <= 0 {
+		return nil, fmt.Errorf("fake refund: amount must be positive")
+	}
+
+	g.mu.Lock()
+	if id, ok := g.idempotent[p.IdempotencyKey]; ok && p.IdempotencyKey != "" {
+		cp := *g.refunds[id]
+		g.mu.Unlock()
+		return &cp, nil
+	}
+	refund := &Refund{ID: "re_fake_" + randomID(), Status: RefundStatusSucceeded}
+	g.refunds[refund.ID] = refund
+	if p.IdempotencyKey != "" {
+		g.idempotent[p.IdempotencyKey] = refund.ID
+	}
+	g.refunded[p.PaymentIntentID] += p.Amount
+	charge := fakeEventObject{
+		ID: "ch_fake_" + randomID(), PaymentIntent: p.PaymentIntentID, AmountRefunded: g.refunded[p.PaymentIntentID],
+	}
+	for _, s := range g.sessions {
+		if s.PaymentIntentID == p.PaymentIntentID {
+			charge.Amount, charge.Currency, charge.Metadata = s.Amount, s.Currency, s.metadata()
+		}
+	}
+	g.mu.Unlock()
+
+	go func() {
+		time.Sleep(fakeRefundDelay)
+		g.emit(string(EventChargeRefunded), charge)
+	}()
+
+	cp := *refund
+	return &cp, nil
+}
+
+func (g *FakeGateway) GetRefund(_ context.Context, refundID string) (*Refund, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	refund, ok := g.refunds[refundID]
+	if !ok {
+		return nil, fmt.Errorf("fake refund %s not found", refundID)
+	}
+	cp := *refund
+	return &cp, nil
+}
+
+func (g *FakeGateway) GetPaymentStatus(_ context.Context, sessionID string) (*PaymentStatus, error) {
+	s, ok := g.session(sessionID)
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return &PaymentStatus{
+		SessionID:       s.ID,
+		PaymentIntentID: s.PaymentIntentID,
+		Status:          s.Status,
+		Amount:          s.Amount,
+		Currency:        s.Currency,
+	}, nil
+}
+
+func (g *FakeGateway) ExpireCheckout(_ context.Context, sessionID string) error {
+	g.mu.Lock()
+	s, ok := g.sessions[sessionID]
+	if !ok {
+		g.mu.Unlock()
+		return ErrSessionNotFound
+	}
+	if s.Status != SessionStatusOpen {
+		g.mu.Unlock()
+		return fmt.Errorf("fake checkout session %s is %s", sessionID, s.Status)
+	}
+	s.Status = SessionStatusExpired
+	obj := fakeEventObject{ID: s.ID, Amount: s.Amount, Currency: s.Currency, Metadata: s.metadata()}
+	g.mu.Unlock()
+
+	go g.emit(string(EventCheckoutExpired), obj)
+	return nil
+}
+
+func (g *FakeGateway) VerifyWebhook(payload []byte, header http.Header) (*Event, error) {
+	var ts int64
+	var sig string
+	for _, part := range strings.Split(header.Get(FakeSignatureHeader), ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			ts, _ = strconv.ParseInt(kv[1], 10, 64)
+		case "v1":
+			sig = kv[1]
+		}
+	}
+	if ts == 0 || sig == "" {
+		return nil, fmt.Errorf("%w: malformed %s header", ErrInvalidSignature, FakeSignatureHeader)
+	}
+	if time.Since(time.Unix(ts, 0)) > fakeSignatureMaxAge {
+		return nil, fmt.Errorf("%w: timestamp too old", ErrInvalidSignature)
+	}
+	if !hmac.Equal([]byte(sig), []byte(g.sign(ts, payload))) {
+		return nil, ErrInvalidSignature
+	}
+	return g.ParseEvent(payload)
+}
+
+func (g *FakeGateway) ParseEvent(payload []byte) (*Event, error) {
+	var fe fakeEvent
+	if err := json.Unmarshal(payload, &fe); err != nil {
+		return nil, err
+	}
+
+	obj := fe.Data.Object
+	ev := &Event{
+		ID:             fe.ID,
+		Type:           EventType(fe.Type),
+		OrderID:        obj.Metadata["order_id"],
+		Amount:         obj.Amount,
+		AmountRefunded: obj.AmountRefunded,
+		Currency:       obj.Currency,
+		Payload:        payload,
+	}
+	switch {
+	case strings.HasPrefix(obj.ID, "cs_"):
+		ev.SessionID = obj.ID
+		ev.PaymentIntentID = obj.PaymentIntent
+	case strings.HasPrefix(obj.ID, "ch_"):
+		ev.PaymentIntentID = obj.PaymentIntent
+	default:
+		ev.PaymentIntentID = obj.ID
+	}
+	return ev, nil
+}
+
+// RegisterRoutes mounts the hosted payment page
+func (g *FakeGateway) RegisterRoutes(r *mux.Router) {
+	sub := r.PathPrefix("/fakepay/checkout/{id}").Subrouter()
+	sub.HandleFunc("", g.showCheckout).Methods(http.MethodGet)
+	sub.HandleFunc("/pay", g.pay).Methods(http.MethodPost)
+	sub.HandleFunc("/decline", g.decline).Methods(http.MethodPost)
+	sub.HandleFunc("/cancel", g.cancel).Methods(http.MethodPost)
+}
+
+var fakeCheckoutPage = template.Must(template.New("checkout").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Fake payment – Order #{{.OrderID}}</title></head>
+<body style="font-family: sans-serif; max-width: 28rem; margin: 4rem auto;">
+	<h1>Order #{{.OrderID}}</h1>
+	<table>
+		{{range .LineItems}}<tr><td>{{.Name}}</td><td>&times; {{.Quantity}}</td><td>{{.Price}}</td></tr>{{end}}
+	</table>
+	<p>Amount due: <strong>{{.Amount}} {{.Currency}}</strong></p>
+	{{if .Tax}}<p>Including tax: {{.Tax}} {{.Currency}}</p>{{end}}
+	<p>Session: <code>{{.ID}}</code> ({{.Status}})</p>
+	{{if eq .Status "open"}}
+	<form method="post" action="{{.Base}}/pay"><button type="submit">Pay</button></form>
+	<form method="post" action="{{.Base}}/decline"><button type="submit">Decline card</button></form>
+	<form method="post" action="{{.Base}}/cancel"><button type="submit">Cancel</button></form>
+	{{end}}
+</body>
+</html>`))
+
+func (g *FakeGateway) showCheckout(w http.ResponseWriter, r *http.Request) {
+	s, ok := g.openSession(w, r)
+	if !ok {
+		return
+	}
+
+	type line struct {
+		Name     string
+		Quantity int64
+		Price    string
+	}
+	lines := make([]line, 0, len(s.LineItems))
+	for _, item := range s.LineItems {
+		lines = append(lines, line{Name: item.Name, Quantity: item.Quantity, Price: formatMinor(item.UnitAmount)})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = fakeCheckoutPage.Execute(w, map[string]interface{}{
+		"ID":        s.ID,
+		"OrderID":   s.OrderID,
+		"LineItems": lines,
+		"Amount":    formatMinor(s.Amount),
+		"Tax":       fakeTax(s.TaxAmount),
+		"Currency":  strings.ToUpper(s.Currency),
+		"Status":    s.Status,
+		"Base":      fmt.Sprintf("%s/fakepay/checkout/%s", g.baseURL, s.ID),
+	})
+}
+
+func (g *FakeGateway) pay(w http.ResponseWriter, r *http.Request) {
+	s, ok := g.openSession(w, r)
+	if !ok {
+		return
+	}
+	g.setStatus(s.ID, SessionStatusPaid)
+
+	g.emit(string(EventCheckoutCompleted), fakeEventObject{
+		ID: s.ID, PaymentIntent: s.PaymentIntentID, Amount: s.Amount, Currency: s.Currency, Metadata: s.metadata(),
+	})
+	g.emit(string(EventPaymentSucceeded), fakeEventObject{
+		ID: s.PaymentIntentID, Amount: s.Amount, Currency: s.Currency, Metadata: s.metadata(),
+	})
+
+	http.Redirect(w, r, s.SuccessURL, http.StatusSeeOther)
+}
+
+func (g *FakeGateway) decline(w http.ResponseWriter, r *http.Request) {
+	s, ok := g.openSession(w, r)
+	if !ok {
+		return
+	}
+
+	g.emit(string(EventPaymentFailed), fakeEventObject{
+		ID: s.PaymentIntentID, Amount: s.Amount, Currency: s.Currency, Metadata: s.metadata(),
+	})
+
+	http.Redirect(w, r, s.CancelURL, http.StatusSeeOther)
+}
+
+func (g *FakeGateway) cancel(w http.ResponseWriter, r *http.Request) {
+	s, ok := g.openSession(w, r)
+	if !ok {
+		return
+	}
+	http.Redirect(w, r, s.CancelURL, http.StatusSeeOther)
+}
+
+// openSession loads the session from the path and rejects finished or expired ones
+func (g *FakeGateway) openSession(w http.ResponseWriter, r *http.Request) (*fakeSession, bool) {
+	s, ok := g.session(mux.Vars(r)["id"])
+	if !ok {
+		http.Error(w, ErrSessionNotFound.Error(), http.StatusNotFound)
+		return nil, false
+	}
+	if s.Status == SessionStatusOpen && !s.ExpiresAt.IsZero() && time.Now().After(s.ExpiresAt) {
+		g.setStatus(s.ID, SessionStatusExpired)
+		s.Status = SessionStatusExpired
+		g.emit(string(EventCheckoutExpired), fakeEventObject{
+			ID: s.ID, Amount: s.Amount, Currency: s.Currency, Metadata: s.metadata(),
+		})
+	}
+	if r.Method != http.MethodGet && s.Status != SessionStatusOpen {
+		http.Error(w, "checkout session is "+s.Status, http.StatusConflict)
+		return nil, false
+	}
+	return s, true
+}
+
+func (g *FakeGateway) session(id string) (*fakeSession, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	s, ok := g.sessions[id]
+	if !ok {
+		return nil, false
+	}
+	cp := *s
+	return &cp, true
+}
+
+func (g *FakeGateway) setStatus(id, status string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if s, ok := g.sessions[id]; ok {
+		s.Status = status
+	}
+}
+
+// emit signs the event and posts it to our own webhook endpoint
+func (g *FakeGateway) emit(eventType string, obj fakeEventObject) {
+	ev := fakeEvent{ID: "evt_fake_" + randomID(), Type: eventType, Created: time.Now().Unix()}
+	ev.Data.Object = obj
+
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("Fake gateway: failed to encode %s: %v", eventType, err)
+		return
+	}
+
+	ts := time.Now().Unix()
+	req, err := http.NewRequest(http.MethodPost, g.baseURL+"/api/webhook/"+ProviderFake, bytes.NewReader(payload))
+	if err != nil {
+		log.Printf("Fake gateway: failed to build webhook request: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(FakeSignatureHeader, fmt.Sprintf("t=%d,v1=%s", ts, g.sign(ts, payload)))
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		log.Printf("Fake gateway: webhook %s delivery failed: %v", eventType, err)
+		return
+	}
+	_ = resp.Body.Close()
+	log.Printf("Fake gateway: delivered %s (%s), status %d", eventType, ev.ID, resp.StatusCode)
+}
+
+func (g *FakeGateway) sign(ts int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(g.webhookSecret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *fakeSession) metadata() map[string]string {
+	return map[string]string{
+		"order_id":   strconv.FormatInt(s.OrderID, 10),
+		"tax_amount": strconv.FormatInt(s.TaxAmount, 10),
+	}
+}
+
+// fakeTax is the tax shown on the checkout page, empty when the order is untaxed
+func fakeTax(amount int64) string {
+	if amount == 0 {
+		return ""
+	}
+	return formatMinor(amount)
+}
+
+func formatMinor(amount int64) string {
+	return fmt.Sprintf("%d.%02d", amount/100, amount%100)
+}
+
+func randomID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
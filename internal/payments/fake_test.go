@@ -0,0 +1,37 @@
+package payments
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFakeGatewayRefundIdempotencyKey(t *testing.T) {
+	// Nothing listens on the base URL, the delayed charge.refunded is just dropped
+	g := NewFakeGateway("http://127.0.0.1:0", "whsec_test")
+	ctx := context.Background()
+
+	params := RefundParams{PaymentIntentID: "pi_fake_1", Amount: 1500, IdempotencyKey: "orphaned-payment-pi_fake_1"}
+	first, err := g.Refund(ctx, params)
+	if err != nil {
+		t.Fatalf("first Refund() error = %v", err)
+	}
+	second, err := g.Refund(ctx, params)
+	if err != nil {
+		t.Fatalf("second Refund() error = %v", err)
+	}
+
+	if first.ID != second.ID {
+		t.Fatalf("refund IDs differ: %s and %s", first.ID, second.ID)
+	}
+	if got := g.refunded["pi_fake_1"]; got != 1500 {
+		t.Fatalf("refunded %d, want 1500", got)
+	}
+
+	params.IdempotencyKey = ""
+	if _, err := g.Refund(ctx, params); err != nil {
+		t.Fatalf("unkeyed Refund() error = %v", err)
+	}
+	if got := g.refunded["pi_fake_1"]; got != 3000 {
+		t.Fatalf("refunded %d after an unkeyed refund, want 3000", got)
+	}
+}
@@ -0,0 +1,125 @@
+// Package payments hides the payment provider behind the Gateway interface.
+// Amounts are always integer minor units (cents).
+package payments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrSessionNotFound  = errors.New("checkout session not found")
+)
+
+// Provider names used in config and in the webhook path /api/webhook/{provider}
+const (
+	ProviderStripe = "stripe"
+	ProviderFake   = "fake"
+)
+
+// Event types follow Stripe naming so every provider reports the same events
+type EventType string
+
+const (
+	EventPaymentSucceeded  EventType = "payment_intent.succeeded"
+	EventPaymentFailed     EventType = "payment_intent.payment_failed"
+	EventCheckoutCompleted EventType = "checkout.session.completed"
+	EventCheckoutExpired   EventType = "checkout.session.expired"
+	EventChargeRefunded    EventType = "charge.refunded"
+)
+
+// LineItem is one row the buyer sees on the hosted checkout page
+type LineItem struct {
+	Name       string
+	UnitAmount int64
+	Quantity   int64
+}
+
+// CheckoutParams describe a session. Amount must equal the sum of the line items.
+// TaxAmount is the tax contained in Amount, for the provider's records only:
+// exclusive tax is charged through its own line item, never computed by the provider.
+type CheckoutParams struct {
+	OrderID    int64
+	Amount     int64
+	TaxAmount  int64
+	Currency   string
+	LineItems  []LineItem
+	SuccessURL string
+	CancelURL  string
+	ExpiresAt  time.Time
+}
+
+type CheckoutSession struct {
+	ID  string
+	URL string
+	// Raw is the provider response, kept for the payments ledger
+	Raw []byte
+}
+
+// RefundParams describe a refund. Refunds with the same non-empty
+// IdempotencyKey are issued only once and return the first refund.
+type RefundParams struct {
+	PaymentIntentID string
+	Amount          int64
+	Metadata        map[string]string
+	IdempotencyKey  string
+}
+
+// Refund statuses as reported by the provider
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusSucceeded = "succeeded"
+	RefundStatusFailed    = "failed"
+	RefundStatusCanceled  = "canceled"
+)
+
+type Refund struct {
+	ID     string
+	Status string
+}
+
+// Session payment statuses as reported by GetPaymentStatus
+const (
+	SessionStatusOpen     = "open"
+	SessionStatusPaid     = "paid"
+	SessionStatusExpired  = "expired"
+	SessionStatusCanceled = "canceled"
+)
+
+type PaymentStatus struct {
+	SessionID       string
+	PaymentIntentID string
+	Status          string
+	Amount          int64
+	Currency        string
+}
+
+// Event is a verified webhook notification reduced to what the app needs
+type Event struct {
+	ID              string
+	Type            EventType
+	OrderID         string
+	SessionID       string
+	PaymentIntentID string
+	Amount          int64
+	// AmountRefunded is the total refunded so far, set on charge.refunded
+	AmountRefunded int64
+	Currency       string
+	Payload        []byte
+}
+
+type Gateway interface {
+	Name() string
+	CreateCheckout(ctx context.Context, params CheckoutParams) (*CheckoutSession, error)
+	Refund(ctx context.Context, params RefundParams) (*Refund, error)
+	GetRefund(ctx context.Context, refundID string) (*Refund, error)
+	GetPaymentStatus(ctx context.Context, sessionID string) (*PaymentStatus, error)
+	// ExpireCheckout closes an open session so it can no longer be paid
+	ExpireCheckout(ctx context.Context, sessionID string) error
+	VerifyWebhook(payload []byte, header http.Header) (*Event, error)
+	// ParseEvent decodes an already verified payload, used to replay stored events
+	ParseEvent(payload []byte) (*Event, error)
+}
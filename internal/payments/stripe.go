@@ -0,0 +1,235 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stripe/stripe-go/v82"
+	"github.com/stripe/stripe-go/v82/client"
+	"github.com/stripe/stripe-go/v82/webhook"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Stripe only accepts session expiry between 30 minutes and 24 hours from now
+const (
+	minSessionTTL = 30 * time.Minute
+	maxSessionTTL = 24 * time.Hour
+	// sessionTTLSlack covers the time between computing a deadline and creating the session
+	sessionTTLSlack = time.Minute
+)
+
+// ErrInvalidSessionExpiry means the session deadline falls outside Stripe's window
+var ErrInvalidSessionExpiry = errors.New("checkout session expiry outside the provider's window")
+
+// ValidateStripeSessionTTL checks that sessions created ttl before their
+// deadline are accepted by Stripe. Sessions share the stock reservation's
+// deadline, so a TTL Stripe rejects would leave them payable after the stock
+// was released.
+func ValidateStripeSessionTTL(ttl time.Duration) error {
+	if ttl < minSessionTTL+sessionTTLSlack || ttl > maxSessionTTL {
+		return fmt.Errorf("%w: %s, must be between %s and %s",
+			ErrInvalidSessionExpiry, ttl, minSessionTTL+sessionTTLSlack, maxSessionTTL)
+	}
+	return nil
+}
+
+type StripeGateway struct {
+	api           *client.API
+	webhookSecret string
+}
+
+func NewStripeGateway(secretKey, webhookSecret string) *StripeGateway {
+	return &StripeGateway{
+		api:           client.New(secretKey, nil),
+		webhookSecret: webhookSecret,
+	}
+}
+
+func (g *StripeGateway) Name() string { return ProviderStripe }
+
+// CreateCheckout opens a hosted session with the order's lines. Tax is ours,
+// not Stripe Tax: no tax_rates or tax_behavior are set, exclusive tax arrives
+// as the caller's "Tax: <name>" line items and the total tax as tax_amount
+// metadata, so Stripe's reports count it as an ordinary line.
+func (g *StripeGateway) CreateCheckout(ctx context.Context, p CheckoutParams) (*CheckoutSession, error) {
+	metadata := map[string]string{
+		"order_id":   strconv.FormatInt(p.OrderID, 10),
+		"tax_amount": strconv.FormatInt(p.TaxAmount, 10),
+	}
+
+	lineItems := make([]*stripe.CheckoutSessionLineItemParams, 0, len(p.LineItems))
+	for _, item := range p.LineItems {
+		lineItems = append(lineItems, &stripe.CheckoutSessionLineItemParams{
+			PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+				Currency: stripe.String(p.Currency),
+				ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+					Name: stripe.String(item.Name),
+				},
+				UnitAmount: stripe.Int64(item.UnitAmount),
+			},
+			Quantity: stripe.Int64(item.Quantity),
+		})
+	}
+
+	params := &stripe.CheckoutSessionParams{
+		LineItems:         lineItems,
+		ClientReferenceID: stripe.String(strconv.FormatInt(p.OrderID, 10)),
+		Metadata:          metadata,
+		PaymentIntentData: &stripe.CheckoutSessionPaymentIntentDataParams{
+			Metadata: metadata,
+		},
+		Mode:               stripe.String(string(stripe.CheckoutSessionModePayment)),
+		SuccessURL:         stripe.String(p.SuccessURL),
+		CancelURL:          stripe.String(p.CancelURL),
+		PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
+	}
+	params.Context = ctx
+	// Never leave the expiry to Stripe's 24h default, the session must not outlive the reservation
+	if ttl := time.Until(p.ExpiresAt); ttl < minSessionTTL || ttl > maxSessionTTL {
+		return nil, fmt.Errorf("%w: expires in %s", ErrInvalidSessionExpiry, ttl.Round(time.Second))
+	}
+	params.ExpiresAt = stripe.Int64(p.ExpiresAt.Unix())
+
+	s, err := g.api.CheckoutSessions.New(params)
+	if err != nil {
+		return nil, err
+	}
+	cs := &CheckoutSession{ID: s.ID, URL: s.URL}
+	if s.LastResponse != nil {
+		cs.Raw = s.LastResponse.RawJSON
+	}
+	return cs, nil
+}
+
+func (g *StripeGateway) Refund(ctx context.Context, p RefundParams) (*Refund, error) {
+	params := &stripe.RefundParams{
+		PaymentIntent: stripe.String(p.PaymentIntentID),
+		Amount:        stripe.Int64(p.Amount),
+		Metadata:      p.Metadata,
+	}
+	params.Context = ctx
+	if p.IdempotencyKey != "" {
+		params.SetIdempotencyKey(p.IdempotencyKey)
+	}
+
+	r, err := g.api.Refunds.New(params)
+	if err != nil {
+		return nil, err
+	}
+	return &Refund{ID: r.ID, Status: string(r.Status)}, nil
+}
+
+func (g *StripeGateway) GetRefund(ctx context.Context, refundID string) (*Refund, error) {
+	params := &stripe.RefundParams{}
+	params.Context = ctx
+
+	r, err := g.api.Refunds.Get(refundID, params)
+	if err != nil {
+		return nil, err
+	}
+	return &Refund{ID: r.ID, Status: string(r.Status)}, nil
+}
+
+func (g *StripeGateway) GetPaymentStatus(ctx context.Context, sessionID string) (*PaymentStatus, error) {
+	params := &stripe.CheckoutSessionParams{}
+	params.Context = ctx
+
+	s, err := g.api.CheckoutSessions.Get(sessionID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	status := SessionStatusOpen
+	switch {
+	case s.PaymentStatus == stripe.CheckoutSessionPaymentStatusPaid:
+		status = SessionStatusPaid
+	case s.Status == stripe.CheckoutSessionStatusExpired:
+		status = SessionStatusExpired
+	}
+
+	ps := &PaymentStatus{
+		SessionID: s.ID,
+		Status:    status,
+		Amount:    s.AmountTotal,
+		Currency:  string(s.Currency),
+	}
+	if s.PaymentIntent != nil {
+		ps.PaymentIntentID = s.PaymentIntent.ID
+	}
+	return ps, nil
+}
+
+func (g *StripeGateway) ExpireCheckout(ctx context.Context, sessionID string) error {
+	params := &stripe.CheckoutSessionExpireParams{}
+	params.Context = ctx
+
+	_, err := g.api.CheckoutSessions.Expire(sessionID, params)
+	return err
+}
+
+func (g *StripeGateway) VerifyWebhook(payload []byte, header http.Header) (*Event, error) {
+	event, err := webhook.ConstructEvent(payload, header.Get("Stripe-Signature"), g.webhookSecret)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return parseStripeEvent(event, payload)
+}
+
+func (g *StripeGateway) ParseEvent(payload []byte) (*Event, error) {
+	var event stripe.Event
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return nil, err
+	}
+	return parseStripeEvent(event, payload)
+}
+
+func parseStripeEvent(event stripe.Event, payload []byte) (*Event, error) {
+	ev := &Event{
+		ID:      event.ID,
+		Type:    EventType(event.Type),
+		Payload: payload,
+	}
+
+	switch ev.Type {
+	case EventPaymentSucceeded, EventPaymentFailed:
+		var pi stripe.PaymentIntent
+		if err := json.Unmarshal(event.Data.Raw, &pi); err != nil {
+			return nil, err
+		}
+		ev.OrderID = pi.Metadata["order_id"]
+		ev.PaymentIntentID = pi.ID
+		ev.Amount = pi.Amount
+		ev.Currency = string(pi.Currency)
+
+	case EventCheckoutCompleted, EventCheckoutExpired:
+		var s stripe.CheckoutSession
+		if err := json.Unmarshal(event.Data.Raw, &s); err != nil {
+			return nil, err
+		}
+		ev.OrderID = s.Metadata["order_id"]
+		ev.SessionID = s.ID
+		ev.Amount = s.AmountTotal
+		ev.Currency = string(s.Currency)
+		if s.PaymentIntent != nil {
+			ev.PaymentIntentID = s.PaymentIntent.ID
+		}
+
+	case EventChargeRefunded:
+		var ch stripe.Charge
+		if err := json.Unmarshal(event.Data.Raw, &ch); err != nil {
+			return nil, err
+		}
+		ev.Amount = ch.Amount
+		ev.AmountRefunded = ch.AmountRefunded
+		ev.OrderID = ch.Metadata["order_id"]
+		ev.Currency = string(ch.Currency)
+		if ch.PaymentIntent != nil {
+			ev.PaymentIntentID = ch.PaymentIntent.ID
+		}
+	}
+
+	return ev, nil
+}
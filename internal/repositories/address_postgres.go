@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"go-app-marketplace/pkg/domain"
+)
+
+var ErrAddressNotFound = errors.New("address not found")
+
+type AddressRepository struct {
+	db DBTX
+}
+
+func NewAddressRepository(db *sqlx.DB) *AddressRepository {
+	return &AddressRepository{db: db}
+}
+
+const addressColumns = `id, user_id, full_name, phone, line1, line2, city, region, postal_code, country,
+	is_default, created_at, updated_at`
+
+// ListByUser returns the address book with the default address first
+func (r *AddressRepository) ListByUser(ctx context.Context, userID int64) ([]domain.UserAddress, error) {
+	var rows []domain.UserAddress
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT `+addressColumns+`
+		FROM user_addresses
+		WHERE user_id = $1
+		ORDER BY is_default DESC, created_at DESC
+	`, userID)
+	return rows, err
+}
+
+// Get returns the address only if it belongs to the user
+func (r *AddressRepository) Get(ctx context.Context, userID, id int64) (*domain.UserAddress, error) {
+	var a domain.UserAddress
+	err := r.db.GetContext(ctx, &a, `
+		SELECT `+addressColumns+`
+		FROM user_addresses
+		WHERE id = $1 AND user_id = $2
+	`, id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAddressNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// Create adds the address; the user's first address becomes the default
+func (r *AddressRepository) Create(ctx context.Context, a *domain.UserAddress) (int64, error) {
+	var id int64
+	err := inTx(ctx, r.db, func(tx DBTX) error {
+		var count int
+		if err := tx.GetContext(ctx, &count, `SELECT COUNT(*) FROM user_addresses WHERE user_id = $1`, a.UserID); err != nil {
+			return err
+		}
+		isDefault := a.IsDefault || count == 0
+		if isDefault {
+			if err := clearDefaultAddress(ctx, tx, a.UserID); err != nil {
+				return err
+			}
+		}
+
+		return tx.GetContext(ctx, &id, `
+			INSERT INTO user_addresses (user_id, full_name, phone, line1, line2, city, region, postal_code, country, is_default)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			RETURNING id
+		`, a.UserID, a.FullName, a.Phone, a.Line1, a.Line2, a.City, a.Region, a.PostalCode, a.Country, isDefault)
+	})
+	return id, err
+}
+
+// Update rewrites the address fields; orders keep the snapshot they were placed with
+func (r *AddressRepository) Update(ctx context.Context, a *domain.UserAddress) error {
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		if a.IsDefault {
+			if err := clearDefaultAddress(ctx, tx, a.UserID); err != nil {
+				return err
+			}
+		}
+
+		res, err := tx.ExecContext(ctx, `
+			UPDATE user_addresses
+			SET full_name = $1, phone = $2, line1 = $3, line2 = $4, city = $5, region = $6,
+			    postal_code = $7, country = $8, is_default = is_default OR $9, updated_at = NOW()
+			WHERE id = $10 AND user_id = $11
+		`, a.FullName, a.Phone, a.Line1, a.Line2, a.City, a.Region, a.PostalCode, a.Country, a.IsDefault, a.ID, a.UserID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrAddressNotFound
+		}
+		return nil
+	})
+}
+
+func (r *AddressRepository) SetDefault(ctx context.Context, userID, id int64) error {
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		if err := clearDefaultAddress(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, `
+			UPDATE user_addresses
+			SET is_default = TRUE, updated_at = NOW()
+			WHERE id = $1 AND user_id = $2
+		`, id, userID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrAddressNotFound
+		}
+		return nil
+	})
+}
+
+// Delete removes the address; when it was the default, the newest remaining one takes over
+func (r *AddressRepository) Delete(ctx context.Context, userID, id int64) error {
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		var wasDefault bool
+		err := tx.GetContext(ctx, &wasDefault, `
+			DELETE FROM user_addresses
+			WHERE id = $1 AND user_id = $2
+			RETURNING is_default
+		`, id, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAddressNotFound
+		}
+		if err != nil || !wasDefault {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE user_addresses
+			SET is_default = TRUE, updated_at = NOW()
+			WHERE id = (SELECT id FROM user_addresses WHERE user_id = $1 ORDER BY created_at DESC LIMIT 1)
+		`, userID)
+		return err
+	})
+}
+
+func clearDefaultAddress(ctx context.Context, tx DBTX, userID int64) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE user_addresses
+		SET is_default = FALSE, updated_at = NOW()
+		WHERE user_id = $1 AND is_default
+	`, userID)
+	return err
+}
@@ -8,7 +8,7 @@ import (
 )
 
 type CartRepository struct {
-	db *sqlx.DB
+	db DBTX
 }
 
 func NewCartRepository(db *sqlx.DB) *CartRepository {
@@ -18,9 +18,10 @@ func NewCartRepository(db *sqlx.DB) *CartRepository {
 func (r *CartRepository) checkStockAvailability(ctx context.Context, offerID int64, requestedQuantity int) error {
 	var currentStock int
 	err := r.db.GetContext(ctx, &currentStock, `
-		SELECT stock 
-		FROM offers 
-		WHERE id = $1 AND is_available = true
+		SELECT o.stock
+		FROM offers o
+		WHERE o.id = $1 AND o.is_available = true
+		  AND EXISTS (SELECT 1 FROM products p WHERE p.id = o.product_id AND p.archived_at IS NULL)
 	`, offerID)
 	if err != nil {
 		return err
@@ -50,12 +51,23 @@ func (r *CartRepository) checkCartQuantity(ctx context.Context, userID, offerID
 	return nil
 }
 
+// AddItem puts the offer into the cart at its current price, with the SKU and
+// attributes of its variant; adding it again raises the quantity and refreshes
+// what the buyer has seen
 func (r *CartRepository) AddItem(ctx context.Context, userID, offerID int64, quantity int) error {
 	_, err := r.db.ExecContext(ctx, `
-		INSERT INTO cart_items (user_id, offer_id, quantity) 
-		VALUES ($1, $2, $3)
+		INSERT INTO cart_items (user_id, offer_id, quantity, unit_price, currency, variant_id, sku, attributes)
+		SELECT $1, o.id, $3, o.price, o.currency, v.id, v.sku, v.attributes
+		FROM offers o
+		JOIN product_variants v ON v.id = o.variant_id
+		WHERE o.id = $2
 		ON CONFLICT (user_id, offer_id) 
-		DO UPDATE SET quantity = cart_items.quantity + $3
+		DO UPDATE SET quantity = cart_items.quantity + $3,
+		              unit_price = EXCLUDED.unit_price,
+		              currency = EXCLUDED.currency,
+		              variant_id = EXCLUDED.variant_id,
+		              sku = EXCLUDED.sku,
+		              attributes = EXCLUDED.attributes
 	`, userID, offerID, quantity)
 	return err
 }
@@ -63,9 +75,11 @@ func (r *CartRepository) AddItem(ctx context.Context, userID, offerID int64, qua
 func (r *CartRepository) GetItems(ctx context.Context, userID int64) ([]domain.CartItem, error) {
 	var items []domain.CartItem
 	err := r.db.SelectContext(ctx, &items, `
-		SELECT id, user_id, offer_id, quantity
+		SELECT id, user_id, offer_id, quantity, (unit_price, currency) AS unit_price, currency,
+		       variant_id, sku, attributes
 		FROM cart_items
 		WHERE user_id = $1
+		ORDER BY id
 	`, userID)
 	return items, err
 }
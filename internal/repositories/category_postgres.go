@@ -0,0 +1,322 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go-app-marketplace/pkg/domain"
+)
+
+var (
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrCategoryExists      = errors.New("a category with this slug already exists")
+	ErrCategoryHasChildren = errors.New("category still has subcategories")
+	ErrCategoryCycle       = errors.New("a category cannot move under itself or its subcategories")
+)
+
+type CategoryRepository struct {
+	db DBTX
+}
+
+func NewCategoryRepository(db *sqlx.DB) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+const categoryColumns = `c.id, c.parent_id, c.name, c.slug, c.position, c.created_at, c.updated_at`
+
+// categoryOrder is the order siblings are shown in
+const categoryOrder = `c.position, c.name, c.id`
+
+func (r *CategoryRepository) List(ctx context.Context) ([]domain.Category, error) {
+	var categories []domain.Category
+	err := r.db.SelectContext(ctx, &categories, `
+		SELECT `+categoryColumns+`
+		FROM categories c
+		ORDER BY `+categoryOrder)
+	return categories, err
+}
+
+// ListWithProductCounts returns every category with the number of live
+// products in it or its descendants, a product in several of them counted once
+func (r *CategoryRepository) ListWithProductCounts(ctx context.Context) ([]*domain.CategoryNode, error) {
+	var nodes []*domain.CategoryNode
+	err := r.db.SelectContext(ctx, &nodes, `
+		WITH RECURSIVE subtree AS (
+			SELECT id AS root_id, id FROM categories
+			UNION ALL
+			SELECT s.root_id, c.id FROM subtree s JOIN categories c ON c.parent_id = s.id
+		), counts AS (
+			SELECT s.root_id, COUNT(DISTINCT pc.product_id) AS product_count
+			FROM subtree s
+			JOIN product_categories pc ON pc.category_id = s.id
+			JOIN products p ON p.id = pc.product_id AND p.archived_at IS NULL
+			GROUP BY s.root_id
+		)
+		SELECT `+categoryColumns+`, COALESCE(n.product_count, 0) AS product_count
+		FROM categories c
+		LEFT JOIN counts n ON n.root_id = c.id
+		ORDER BY `+categoryOrder)
+	return nodes, err
+}
+
+func (r *CategoryRepository) GetBySlug(ctx context.Context, slug string) (domain.Category, error) {
+	var category domain.Category
+	err := r.db.GetContext(ctx, &category, `
+		SELECT `+categoryColumns+`
+		FROM categories c
+		WHERE c.slug = $1
+	`, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Category{}, ErrCategoryNotFound
+	}
+	return category, err
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, category domain.Category) (domain.Category, error) {
+	var saved domain.Category
+	err := inTx(ctx, r.db, func(tx DBTX) error {
+		if err := checkParent(ctx, tx, category); err != nil {
+			return err
+		}
+		return tx.GetContext(ctx, &saved, `
+			INSERT INTO categories AS c (parent_id, name, slug, position)
+			VALUES ($1, $2, $3, $4)
+			RETURNING `+categoryColumns,
+			category.ParentID, category.Name, category.Slug, category.Position)
+	})
+	if isUniqueViolation(err) {
+		return domain.Category{}, ErrCategoryExists
+	}
+	return saved, err
+}
+
+func (r *CategoryRepository) Update(ctx context.Context, category domain.Category) (domain.Category, error) {
+	var saved domain.Category
+	err := inTx(ctx, r.db, func(tx DBTX) error {
+		if err := checkParent(ctx, tx, category); err != nil {
+			return err
+		}
+		return tx.GetContext(ctx, &saved, `
+			UPDATE categories AS c
+			SET parent_id = $2, name = $3, slug = $4, position = $5, updated_at = NOW()
+			WHERE c.id = $1
+			RETURNING `+categoryColumns,
+			category.ID, category.ParentID, category.Name, category.Slug, category.Position)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Category{}, ErrCategoryNotFound
+	}
+	if isUniqueViolation(err) {
+		return domain.Category{}, ErrCategoryExists
+	}
+	return saved, err
+}
+
+// checkParent makes sure the parent exists and is not the category itself or
+// one of its descendants. The tree is locked against concurrent moves, which
+// could otherwise close a cycle between them.
+func checkParent(ctx context.Context, tx DBTX, category domain.Category) error {
+	if category.ParentID == nil {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE categories IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+
+	var parent struct {
+		Exists     bool `db:"parent_exists"`
+		Descendant bool `db:"descendant"`
+	}
+	err := tx.GetContext(ctx, &parent, `
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE id = $1
+			UNION ALL
+			SELECT c.id FROM subtree s JOIN categories c ON c.parent_id = s.id
+		)
+		SELECT EXISTS (SELECT 1 FROM categories WHERE id = $2) AS parent_exists,
+		       EXISTS (SELECT 1 FROM subtree WHERE id = $2) AS descendant
+	`, category.ID, *category.ParentID)
+	switch {
+	case err != nil:
+		return err
+	case !parent.Exists:
+		return fmt.Errorf("%w: parent category does not exist", domain.ErrInvalidCategory)
+	case parent.Descendant:
+		return ErrCategoryCycle
+	}
+	return nil
+}
+
+// Delete removes a category without subcategories; its products simply leave it
+func (r *CategoryRepository) Delete(ctx context.Context, id int64) error {
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		var hasChildren bool
+		err := tx.GetContext(ctx, &hasChildren, `
+			SELECT EXISTS (SELECT 1 FROM categories WHERE parent_id = $1)
+		`, id)
+		if err != nil {
+			return err
+		}
+		if hasChildren {
+			return ErrCategoryHasChildren
+		}
+
+		res, err := tx.ExecContext(ctx, `DELETE FROM categories WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrCategoryNotFound
+		}
+		return nil
+	})
+}
+
+// ListByProduct returns the categories the product was put in
+func (r *CategoryRepository) ListByProduct(ctx context.Context, productID int64) ([]domain.Category, error) {
+	var categories []domain.Category
+	err := r.db.SelectContext(ctx, &categories, `
+		SELECT `+categoryColumns+`
+		FROM categories c
+		JOIN product_categories pc ON pc.category_id = c.id
+		WHERE pc.product_id = $1
+		ORDER BY `+categoryOrder,
+		productID)
+	return categories, err
+}
+
+// SetProductCategories replaces the categories of the product
+func (r *CategoryRepository) SetProductCategories(ctx context.Context, productID int64, categoryIDs []int64) error {
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		var found struct {
+			Product    bool `db:"product"`
+			Categories int  `db:"categories"`
+		}
+		err := tx.GetContext(ctx, &found, `
+			SELECT EXISTS (SELECT 1 FROM products WHERE id = $1) AS product,
+			       (SELECT COUNT(*) FROM categories WHERE id = ANY($2)) AS categories
+		`, productID, pq.Array(categoryIDs))
+		switch {
+		case err != nil:
+			return err
+		case !found.Product:
+			return ErrProductNotFound
+		case found.Categories != len(categoryIDs):
+			return ErrCategoryNotFound
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM product_categories
+			WHERE product_id = $1 AND NOT category_id = ANY($2)
+		`, productID, pq.Array(categoryIDs))
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO product_categories (product_id, category_id)
+			SELECT $1, unnest($2::bigint[])
+			ON CONFLICT DO NOTHING
+		`, productID, pq.Array(categoryIDs))
+		return err
+	})
+}
+
+// Breadcrumb returns the path from the root down to the product's deepest
+// category. Of equally deep categories the oldest one wins.
+func (r *CategoryRepository) Breadcrumb(ctx context.Context, productID int64) ([]domain.Category, error) {
+	var path []domain.Category
+	err := r.db.SelectContext(ctx, &path, `
+		WITH RECURSIVE up AS (
+			SELECT pc.category_id AS leaf_id, c.id, c.parent_id, 0 AS depth
+			FROM product_categories pc
+			JOIN categories c ON c.id = pc.category_id
+			WHERE pc.product_id = $1
+			UNION ALL
+			SELECT up.leaf_id, c.id, c.parent_id, up.depth + 1
+			FROM up
+			JOIN categories c ON c.id = up.parent_id
+		), leaf AS (
+			SELECT leaf_id
+			FROM up
+			GROUP BY leaf_id
+			ORDER BY MAX(depth) DESC, leaf_id
+			LIMIT 1
+		)
+		SELECT `+categoryColumns+`
+		FROM up
+		JOIN leaf ON leaf.leaf_id = up.leaf_id
+		JOIN categories c ON c.id = up.id
+		ORDER BY up.depth DESC
+	`, productID)
+	return path, err
+}
+
+var (
+	ErrAttributeNotFound = errors.New("attribute not found")
+	ErrAttributeExists   = errors.New("the category already has an attribute with this code")
+)
+
+const attributeColumns = `a.id, a.category_id, a.code, a.name, a.type, a.options, a.position, a.created_at, a.updated_at`
+
+// ListAttributes returns the attributes the category itself defines
+func (r *CategoryRepository) ListAttributes(ctx context.Context, categoryID int64) ([]domain.CategoryAttribute, error) {
+	var attributes []domain.CategoryAttribute
+	err := r.db.SelectContext(ctx, &attributes, `
+		SELECT `+attributeColumns+`
+		FROM category_attributes a
+		WHERE a.category_id = $1
+		ORDER BY a.position, a.name, a.id
+	`, categoryID)
+	return attributes, err
+}
+
+func (r *CategoryRepository) CreateAttribute(ctx context.Context, attr domain.CategoryAttribute) (domain.CategoryAttribute, error) {
+	var saved domain.CategoryAttribute
+	err := r.db.GetContext(ctx, &saved, `
+		INSERT INTO category_attributes AS a (category_id, code, name, type, options, position)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+attributeColumns,
+		attr.CategoryID, attr.Code, attr.Name, attr.Type, attr.Options, attr.Position)
+	switch {
+	case isForeignKeyViolation(err):
+		return domain.CategoryAttribute{}, ErrCategoryNotFound
+	case isUniqueViolation(err):
+		return domain.CategoryAttribute{}, ErrAttributeExists
+	}
+	return saved, err
+}
+
+// UpdateAttribute replaces the attribute. Values variants already have are
+// kept even if they no longer fit; they are checked when a variant is saved.
+func (r *CategoryRepository) UpdateAttribute(ctx context.Context, attr domain.CategoryAttribute) (domain.CategoryAttribute, error) {
+	var saved domain.CategoryAttribute
+	err := r.db.GetContext(ctx, &saved, `
+		UPDATE category_attributes AS a
+		SET code = $3, name = $4, type = $5, options = $6, position = $7, updated_at = NOW()
+		WHERE a.id = $1 AND a.category_id = $2
+		RETURNING `+attributeColumns,
+		attr.ID, attr.CategoryID, attr.Code, attr.Name, attr.Type, attr.Options, attr.Position)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.CategoryAttribute{}, ErrAttributeNotFound
+	}
+	if isUniqueViolation(err) {
+		return domain.CategoryAttribute{}, ErrAttributeExists
+	}
+	return saved, err
+}
+
+func (r *CategoryRepository) DeleteAttribute(ctx context.Context, categoryID, id int64) error {
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM category_attributes WHERE id = $1 AND category_id = $2
+	`, id, categoryID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAttributeNotFound
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"go-app-marketplace/pkg/domain"
+)
+
+var ErrExchangeRateNotFound = errors.New("exchange rate not found")
+
+type ExchangeRateRepository struct {
+	db DBTX
+}
+
+func NewExchangeRateRepository(db *sqlx.DB) *ExchangeRateRepository {
+	return &ExchangeRateRepository{db: db}
+}
+
+func (r *ExchangeRateRepository) List(ctx context.Context) ([]domain.ExchangeRate, error) {
+	var rates []domain.ExchangeRate
+	err := r.db.SelectContext(ctx, &rates, `
+		SELECT base_currency, quote_currency, rate::text AS rate, updated_at
+		FROM exchange_rates
+		ORDER BY base_currency, quote_currency
+	`)
+	return rates, err
+}
+
+// Get returns the base to quote rate, deriving it from the stored quote to
+// base rate when only the opposite direction is maintained.
+func (r *ExchangeRateRepository) Get(ctx context.Context, base, quote string) (domain.ExchangeRate, error) {
+	var rates []domain.ExchangeRate
+	err := r.db.SelectContext(ctx, &rates, `
+		SELECT base_currency, quote_currency, rate::text AS rate, updated_at
+		FROM exchange_rates
+		WHERE (base_currency = $1 AND quote_currency = $2)
+		   OR (base_currency = $2 AND quote_currency = $1)
+	`, base, quote)
+	if err != nil {
+		return domain.ExchangeRate{}, err
+	}
+
+	for _, rate := range rates {
+		if rate.Base == base {
+			return rate, nil
+		}
+	}
+	if len(rates) > 0 {
+		return rates[0].Inverse()
+	}
+	return domain.ExchangeRate{}, ErrExchangeRateNotFound
+}
+
+func (r *ExchangeRateRepository) Upsert(ctx context.Context, rate domain.ExchangeRate) (domain.ExchangeRate, error) {
+	var saved domain.ExchangeRate
+	err := r.db.GetContext(ctx, &saved, `
+		INSERT INTO exchange_rates (base_currency, quote_currency, rate)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (base_currency, quote_currency)
+		DO UPDATE SET rate = EXCLUDED.rate, updated_at = NOW()
+		RETURNING base_currency, quote_currency, rate::text AS rate, updated_at
+	`, rate.Base, rate.Quote, rate.Rate)
+	return saved, err
+}
+
+func (r *ExchangeRateRepository) Delete(ctx context.Context, base, quote string) error {
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM exchange_rates
+		WHERE base_currency = $1 AND quote_currency = $2
+	`, base, quote)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrExchangeRateNotFound
+	}
+	return nil
+}
@@ -2,34 +2,80 @@ package repositories
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"github.com/jmoiron/sqlx"
 	"go-app-marketplace/pkg/domain"
 )
 
 type OfferRepository struct {
-	db *sqlx.DB
+	db DBTX
 }
 
 func NewOfferRepository(db *sqlx.DB) *OfferRepository {
 	return &OfferRepository{db: db}
 }
 
+var (
+	ErrOfferExists          = errors.New("the seller already offers this variant")
+	ErrOfferProductArchived = errors.New("the offer's product is archived")
+	ErrOfferNotFound        = errors.New("offer not found")
+)
+
+// CreateOffer puts a variant on sale and fills in its product. A variant of an
+// archived product, or of another product than the one given, returns
+// ErrVariantNotFound.
 func (r *OfferRepository) CreateOffer(ctx context.Context, offer *domain.Offer) (int64, error) {
 	var id int64
 	err := r.db.QueryRowContext(ctx, `
-		INSERT INTO offers (product_id, seller_id, price, stock, is_available)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id
-	`, offer.ProductID, offer.SellerID, offer.Price, offer.Stock, offer.IsAvailable).Scan(&id)
+		INSERT INTO offers (product_id, variant_id, seller_id, price, currency, stock, is_available)
+		SELECT v.product_id, v.id, $3, $4, $5, $6, $7
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id AND p.archived_at IS NULL
+		WHERE v.id = $1 AND ($2::bigint = 0 OR v.product_id = $2)
+		RETURNING id, product_id
+	`, offer.VariantID, offer.ProductID, offer.SellerID, offer.Price, offer.Price.Currency, offer.Stock, offer.IsAvailable).Scan(&id, &offer.ProductID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrVariantNotFound
+	}
+	if isUniqueViolation(err) {
+		return 0, ErrOfferExists
+	}
 	return id, err
 }
 
+// SoleVariantID returns the variant of a live product that has just one, so
+// offers for it can still name the product alone
+func (r *OfferRepository) SoleVariantID(ctx context.Context, productID int64) (int64, error) {
+	var ids []int64
+	err := r.db.SelectContext(ctx, &ids, `
+		SELECT v.id
+		FROM product_variants v
+		JOIN products p ON p.id = v.product_id AND p.archived_at IS NULL
+		WHERE v.product_id = $1
+		LIMIT 2
+	`, productID)
+	switch {
+	case err != nil:
+		return 0, err
+	case len(ids) == 0:
+		return 0, ErrProductNotFound
+	case len(ids) > 1:
+		return 0, ErrVariantRequired
+	}
+	return ids[0], nil
+}
+
+// offerColumns selects an offer from "offers o"
+const offerColumns = `o.id, o.product_id, o.variant_id, o.seller_id, (o.price, o.currency) AS price, o.currency,
+	o.stock, o.is_available, o.created_at, o.updated_at`
+
 func (r *OfferRepository) GetOfferByID(ctx context.Context, id int64) (*domain.Offer, error) {
 	var offer domain.Offer
 	err := r.db.GetContext(ctx, &offer, `
-		SELECT id, product_id, seller_id, price, stock, is_available, created_at, updated_at
-		FROM offers
-		WHERE id = $1
+		SELECT `+offerColumns+`
+		FROM offers o
+		WHERE o.id = $1
 	`, id)
 	if err != nil {
 		return nil, err
@@ -37,24 +83,51 @@ func (r *OfferRepository) GetOfferByID(ctx context.Context, id int64) (*domain.O
 	return &offer, nil
 }
 
-func (r *OfferRepository) ListOffersByProduct(ctx context.Context, productID int64) ([]*domain.Offer, error) {
+// ListOffersByProduct returns the product's offers with their prices also
+// converted into the display currency, cheapest first. Offers in a currency
+// without an exchange rate have no display price and come last.
+func (r *OfferRepository) ListOffersByProduct(ctx context.Context, productID int64, currency string) ([]*domain.Offer, error) {
 	var offers []*domain.Offer
 	err := r.db.SelectContext(ctx, &offers, `
-		SELECT id, product_id, seller_id, price, stock, is_available, created_at, updated_at
-		FROM offers
-		WHERE product_id = $1
-		ORDER BY price ASC
-	`, productID)
+		WITH `+offerRates+`
+		SELECT `+offerColumns+`, `+moneyColumn("ROUND(o.price * r.rate, 2)", "$1::varchar")+` AS display_price
+		FROM offers o
+		LEFT JOIN rates r ON r.currency = o.currency
+		WHERE o.product_id = $2
+		ORDER BY ROUND(o.price * r.rate, 2) ASC NULLS LAST, o.id
+	`, currency, productID)
 	return offers, err
 }
 
+// UpdateOffer saves the seller's offer. Offers of an archived product stay off
+// sale, updating one returns ErrOfferProductArchived; an offer the seller no
+// longer has returns ErrOfferNotFound.
 func (r *OfferRepository) UpdateOffer(ctx context.Context, offer *domain.Offer) error {
-	_, err := r.db.ExecContext(ctx, `
-		UPDATE offers
-		SET price = $1, stock = $2, is_available = $3, updated_at = NOW()
-		WHERE id = $4 AND seller_id = $5
-	`, offer.Price, offer.Stock, offer.IsAvailable, offer.ID, offer.SellerID)
-	return err
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		var live bool
+		err := tx.GetContext(ctx, &live, `
+			SELECT EXISTS (SELECT 1 FROM products p WHERE p.id = o.product_id AND p.archived_at IS NULL)
+			FROM offers o
+			WHERE o.id = $1 AND o.seller_id = $2
+			FOR UPDATE
+		`, offer.ID, offer.SellerID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrOfferNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !live {
+			return ErrOfferProductArchived
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE offers
+			SET price = $1, currency = $2, stock = $3, is_available = $4, updated_at = NOW()
+			WHERE id = $5
+		`, offer.Price, offer.Price.Currency, offer.Stock, offer.IsAvailable, offer.ID)
+		return err
+	})
 }
 
 func (r *OfferRepository) DeleteOffer(ctx context.Context, id int64, sellerID int64) error {
@@ -68,10 +141,10 @@ func (r *OfferRepository) DeleteOffer(ctx context.Context, id int64, sellerID in
 func (r *OfferRepository) ListOffersBySeller(ctx context.Context, sellerID int64) ([]*domain.Offer, error) {
 	var offers []*domain.Offer
 	err := r.db.SelectContext(ctx, &offers, `
-		SELECT id, product_id, seller_id, price, stock, is_available, created_at, updated_at
-		FROM offers
-		WHERE seller_id = $1
-		ORDER BY updated_at DESC
+		SELECT `+offerColumns+`
+		FROM offers o
+		WHERE o.seller_id = $1
+		ORDER BY o.updated_at DESC
 	`, sellerID)
 	return offers, err
 }
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"github.com/jmoiron/sqlx"
+	"go-app-marketplace/pkg/domain"
+)
+
+type OrderEventRepository struct {
+	db DBTX
+}
+
+func NewOrderEventRepository(db *sqlx.DB) *OrderEventRepository {
+	return &OrderEventRepository{db: db}
+}
+
+// ListByOrder returns the order's events, oldest first. With a seller only
+// the events of that seller's items, shipment and refunds are returned.
+func (r *OrderEventRepository) ListByOrder(ctx context.Context, orderID int64, sellerID *int64) ([]domain.OrderEvent, error) {
+	var events []domain.OrderEvent
+	err := r.db.SelectContext(ctx, &events, `
+		SELECT id, order_id, entity, entity_id, seller_id, from_status, to_status, actor_role, actor_id, created_at
+		FROM order_events
+		WHERE order_id = $1 AND ($2::bigint IS NULL OR seller_id = $2)
+		ORDER BY created_at, id
+	`, orderID, sellerID)
+	return events, err
+}
+
+// statusChange is a row touched by a status update, as its RETURNING clause
+// reports it. From is nil for rows that were just created.
+type statusChange struct {
+	ID       int64   `db:"id"`
+	OrderID  int64   `db:"order_id"`
+	SellerID *int64  `db:"seller_id"`
+	From     *string `db:"from_status"`
+	To       string  `db:"to_status"`
+}
+
+func fromStatus(s string) *string {
+	return &s
+}
+
+// recordChanges appends an order event for every change that moved a status,
+// made by the actor of ctx
+func recordChanges(ctx context.Context, db DBTX, entity domain.OrderEventEntity, changes []statusChange) error {
+	role, actorID := actorFrom(ctx)
+	for _, c := range changes {
+		if c.From != nil && *c.From == c.To {
+			continue
+		}
+		_, err := db.ExecContext(ctx, `
+			INSERT INTO order_events (order_id, entity, entity_id, seller_id, from_status, to_status, actor_role, actor_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, c.OrderID, entity, c.ID, c.SellerID, c.From, c.To, role, actorID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// actorFrom is the authenticated user of the request, or the system when the
+// change does not come from one
+func actorFrom(ctx context.Context) (string, *int64) {
+	userID, ok := ctx.Value("user_id").(int64)
+	role, _ := ctx.Value("role").(string)
+	if !ok || role == "" {
+		return domain.ActorSystem, nil
+	}
+	return role, &userID
+}
@@ -2,84 +2,194 @@ package repositories
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/jmoiron/sqlx"
 	"go-app-marketplace/pkg/domain"
 	"go-app-marketplace/pkg/reqresp"
+	"time"
 )
 
+var (
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrOrderItemNotFound = errors.New("order item not found")
+	ErrQuoteRedeemed     = errors.New("the quote has already been used for an order")
+)
+
+// orderColumns selects an order with its amounts in the order's currency
+const orderColumns = `id, user_id, (total_amount, currency) AS total_amount, (shipping_amount, currency) AS shipping_amount,
+	(tax_amount, currency) AS tax_amount, currency, exchange_rates, shipping_address, status, payment_status, created_at, updated_at`
+
+// orderItemColumns selects an item from "order_items oi"
+const orderItemColumns = `oi.id, oi.order_id, oi.offer_id, oi.product_id, oi.seller_id, oi.quantity,
+	(oi.unit_price, oi.currency) AS unit_price, oi.status, (oi.tax_amount, oi.currency) AS tax_amount,
+	oi.tax_rate::text AS tax_rate, oi.tax_name, oi.tax_inclusive, oi.shipment_id, oi.cancellation_reason,
+	oi.variant_id, oi.sku, oi.attributes, oi.created_at, oi.updated_at`
+
 type OrderRepository struct {
-	db *sqlx.DB
+	db DBTX
 }
 
 func NewOrderRepository(db *sqlx.DB) *OrderRepository {
 	return &OrderRepository{db: db}
 }
 
-func (r *OrderRepository) CreateOrder(ctx context.Context, userID int64, totalAmount float64, items []domain.OrderItem) (int64, error) {
-	tx, err := r.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return 0, err
-	}
-	defer func() {
-		_ = tx.Rollback() // will rollback only if Commit hasn't been called
-	}()
+// CreateOrder inserts the order with its items and shipping lines, opens one
+// shipment per seller and reserves offer stock for every item until
+// reservedUntil, all in one transaction. An order for a quote that already
+// placed one returns ErrQuoteRedeemed.
+func (r *OrderRepository) CreateOrder(ctx context.Context, order *domain.Order, items []domain.OrderItem,
+	shipping []domain.ShippingLine, reservedUntil time.Time) (int64, error) {
 
 	var orderID int64
-	err = tx.GetContext(ctx, &orderID, `
-		INSERT INTO orders (user_id, total_amount, status, payment_status)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id
-	`, userID, totalAmount, domain.OrderStatusPending, domain.PaymentStatusPending)
-	if err != nil {
-		return 0, err
-	}
-
-	for _, item := range items {
-		_, err := tx.ExecContext(ctx, `
-			INSERT INTO order_items (order_id, offer_id, product_id, seller_id, quantity, unit_price, status)
-			VALUES ($1, $2, $3, $4, $5, $6, $7)
-		`, orderID, item.OfferID, item.ProductID, item.SellerID, item.Quantity, item.UnitPrice, domain.OrderItemStatusPending)
+	err := inTx(ctx, r.db, func(tx DBTX) error {
+		err := tx.GetContext(ctx, &orderID, `
+			INSERT INTO orders (user_id, total_amount, shipping_amount, tax_amount, currency, exchange_rates, shipping_address, status, payment_status, quote_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			RETURNING id
+		`, order.UserID, order.TotalAmount, order.ShippingAmount, order.TaxAmount, order.TotalAmount.Currency, order.ExchangeRates,
+			order.ShippingAddress, domain.OrderStatusPending, domain.PaymentStatusPending, order.QuoteID)
+		if isUniqueViolation(err) {
+			return ErrQuoteRedeemed
+		}
 		if err != nil {
-			return 0, err
+			return err
 		}
-	}
 
-	if err := tx.Commit(); err != nil {
+		created := []statusChange{{ID: orderID, OrderID: orderID, To: string(domain.OrderStatusPending)}}
+		if err := recordChanges(ctx, tx, domain.OrderEventOrder, created); err != nil {
+			return err
+		}
+
+		for _, line := range shipping {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO order_shipping_lines (order_id, seller_id, profile_id, name, method, quantity, amount, currency)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			`, orderID, line.SellerID, line.ProfileID, line.Name, line.Method, line.Quantity, line.Amount, line.Amount.Currency)
+			if err != nil {
+				return err
+			}
+		}
+
+		shipments := make(map[int64]int64)
+		for _, item := range items {
+			shipmentID, ok := shipments[item.SellerID]
+			if !ok {
+				err := tx.GetContext(ctx, &shipmentID, `
+					INSERT INTO shipments (order_id, seller_id, status)
+					VALUES ($1, $2, $3)
+					RETURNING id
+				`, orderID, item.SellerID, domain.ShipmentStatusPending)
+				if err != nil {
+					return err
+				}
+				shipments[item.SellerID] = shipmentID
+			}
+
+			var itemID int64
+			err := tx.GetContext(ctx, &itemID, `
+				INSERT INTO order_items (order_id, offer_id, product_id, seller_id, quantity, unit_price, currency, status,
+					tax_amount, tax_rate, tax_name, tax_inclusive, shipment_id, variant_id, sku, attributes)
+				SELECT $1, o.id, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, v.id, v.sku, v.attributes
+				FROM offers o
+				JOIN product_variants v ON v.id = o.variant_id
+				WHERE o.id = $2
+				RETURNING id
+			`, orderID, item.OfferID, item.ProductID, item.SellerID, item.Quantity, item.UnitPrice, item.UnitPrice.Currency, domain.OrderItemStatusPending,
+				item.TaxAmount, item.TaxRate, item.TaxName, item.TaxInclusive, shipmentID)
+			if err != nil {
+				return err
+			}
+
+			created := []statusChange{{ID: itemID, OrderID: orderID, SellerID: &item.SellerID, To: string(domain.OrderItemStatusPending)}}
+			if err := recordChanges(ctx, tx, domain.OrderEventItem, created); err != nil {
+				return err
+			}
+
+			if err := reserveStock(ctx, tx, orderID, itemID, item.OfferID, item.Quantity, reservedUntil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return 0, err
 	}
 	return orderID, nil
 }
 
-func (r *OrderRepository) CancelOrderItem(ctx context.Context, userID, itemID int64) error {
+// CancelOrderItem cancels the item, and its shipment once nothing is left in
+// it. Whether the item may be cancelled is up to the caller; an item that is
+// already cancelled returns domain.ErrInvalidTransition.
+func (r *OrderRepository) CancelOrderItem(ctx context.Context, itemID int64, reason string) error {
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		var changes []statusChange
+		err := tx.SelectContext(ctx, &changes, `
+			UPDATE order_items oi
+			SET status = $1, cancellation_reason = $3
+			FROM (SELECT id, status FROM order_items WHERE id = $2 FOR UPDATE) old
+			WHERE oi.id = old.id AND oi.status != $1
+			RETURNING oi.id, oi.order_id, oi.seller_id, old.status AS from_status, oi.status AS to_status
+		`, domain.OrderItemStatusCancelled, itemID, reason)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			return fmt.Errorf("%w: item %d is already cancelled", domain.ErrInvalidTransition, itemID)
+		}
+		if err := recordChanges(ctx, tx, domain.OrderEventItem, changes); err != nil {
+			return err
+		}
+		return cancelEmptyShipments(ctx, tx, changes[0].OrderID)
+	})
+}
+
+// RecomputeTotals sets the order's amounts from its live items: their prices
+// and tax, plus the shipping of every seller that still ships something
+func (r *OrderRepository) RecomputeTotals(ctx context.Context, orderID int64) error {
 	_, err := r.db.ExecContext(ctx, `
-		UPDATE order_items 
-		SET status = $1 
-		WHERE id = $2 
-		  AND order_id IN (SELECT id FROM orders WHERE user_id = $3)
-		  AND status != $1
-	`, domain.OrderItemStatusCancelled, itemID, userID)
+		WITH live AS (
+			SELECT COALESCE(SUM(unit_price * quantity), 0) AS subtotal,
+			       COALESCE(SUM(tax_amount), 0) AS tax,
+			       COALESCE(SUM(tax_amount) FILTER (WHERE NOT tax_inclusive), 0) AS exclusive_tax
+			FROM order_items
+			WHERE order_id = $1 AND status != $2
+		), shipping AS (
+			SELECT COALESCE(SUM(sl.amount), 0) AS amount
+			FROM order_shipping_lines sl
+			WHERE sl.order_id = $1
+			  AND EXISTS (
+				SELECT 1 FROM order_items oi
+				WHERE oi.order_id = $1 AND oi.seller_id = sl.seller_id AND oi.status != $2
+			  )
+		)
+		UPDATE orders
+		SET total_amount = live.subtotal + live.exclusive_tax + shipping.amount,
+		    tax_amount = live.tax,
+		    shipping_amount = shipping.amount,
+		    updated_at = NOW()
+		FROM live, shipping
+		WHERE orders.id = $1
+	`, orderID, domain.OrderItemStatusCancelled)
 	return err
 }
 
 func (r *OrderRepository) ListOrders(ctx context.Context, userID int64) ([]*domain.Order, error) {
 	var orders []*domain.Order
 	err := r.db.SelectContext(ctx, &orders, `
-		SELECT id, user_id, total_amount, status, payment_status, created_at, updated_at
+		SELECT `+orderColumns+`
 		FROM orders
 		WHERE user_id = $1
 		ORDER BY created_at DESC
 	`, userID)
-	if err != nil {
-		return nil, err
-	}
-	return orders, nil
+	return orders, err
 }
 
 func (r *OrderRepository) GetOrderByID(ctx context.Context, orderID int64) (*domain.Order, []domain.OrderItem, error) {
 	var order domain.Order
 	err := r.db.GetContext(ctx, &order, `
-		SELECT id, user_id, total_amount, status, payment_status, created_at, updated_at
+		SELECT `+orderColumns+`, payment_intent_id
 		FROM orders
 		WHERE id = $1
 	`, orderID)
@@ -89,9 +199,9 @@ func (r *OrderRepository) GetOrderByID(ctx context.Context, orderID int64) (*dom
 
 	var items []domain.OrderItem
 	err = r.db.SelectContext(ctx, &items, `
-		SELECT id, order_id, offer_id, product_id, seller_id, quantity, unit_price, status, created_at, updated_at
-		FROM order_items
-		WHERE order_id = $1
+		SELECT `+orderItemColumns+`
+		FROM order_items oi
+		WHERE oi.order_id = $1
 	`, orderID)
 	if err != nil {
 		return nil, nil, err
@@ -103,66 +213,196 @@ func (r *OrderRepository) GetOrderByID(ctx context.Context, orderID int64) (*dom
 func (r *OrderRepository) ListOrderItems(ctx context.Context, orderID int64) ([]domain.OrderItem, error) {
 	var items []domain.OrderItem
 	query := `
-		SELECT id, order_id, offer_id, product_id, seller_id, quantity, unit_price, status, created_at, updated_at
-		FROM order_items
-		WHERE order_id = $1
+		SELECT `+orderItemColumns+`
+		FROM order_items oi
+		WHERE oi.order_id = $1
 	`
 	err := r.db.SelectContext(ctx, &items, query, orderID)
 	return items, err
 }
 
-func (r *OrderRepository) UpdatePaymentStatusByOrderID(ctx context.Context, orderIDStr string, status domain.PaymentStatus) error {
-	query := `
-		UPDATE orders
-		SET payment_status = $1, updated_at = NOW()
-		WHERE CAST(id AS TEXT) = $2
-	`
-	result, err := r.db.ExecContext(ctx, query, status, orderIDStr)
-	if err != nil {
-		return err
-	}
+// ListOrderLines groups the live items of an order by product and price
+func (r *OrderRepository) ListOrderLines(ctx context.Context, orderID int64) ([]domain.OrderLine, error) {
+	var lines []domain.OrderLine
+	err := r.db.SelectContext(ctx, &lines, `
+		SELECT p.name AS product_name, (oi.unit_price, oi.currency) AS unit_price, oi.currency, SUM(oi.quantity) AS quantity
+		FROM order_items oi
+		JOIN products p ON p.id = oi.product_id
+		WHERE oi.order_id = $1 AND oi.status != $2
+		GROUP BY oi.product_id, p.name, oi.unit_price, oi.currency
+		ORDER BY MIN(oi.id)
+	`, orderID, domain.OrderItemStatusCancelled)
+	return lines, err
+}
 
-	affected, err := result.RowsAffected()
+// ListShippingLines returns what each seller group of the order pays for delivery
+func (r *OrderRepository) ListShippingLines(ctx context.Context, orderID int64) ([]domain.ShippingLine, error) {
+	var lines []domain.ShippingLine
+	err := r.db.SelectContext(ctx, &lines, `
+		SELECT id, order_id, seller_id, profile_id, name, method, quantity, (amount, currency) AS amount, currency, created_at
+		FROM order_shipping_lines
+		WHERE order_id = $1
+		ORDER BY id
+	`, orderID)
+	return lines, err
+}
+
+// ListPayableShippingLines returns the shipping lines still charged: those of
+// sellers with at least one item left, matching RecomputeTotals
+func (r *OrderRepository) ListPayableShippingLines(ctx context.Context, orderID int64) ([]domain.ShippingLine, error) {
+	var lines []domain.ShippingLine
+	err := r.db.SelectContext(ctx, &lines, `
+		SELECT sl.id, sl.order_id, sl.seller_id, sl.profile_id, sl.name, sl.method, sl.quantity, (sl.amount, sl.currency) AS amount, sl.currency, sl.created_at
+		FROM order_shipping_lines sl
+		WHERE sl.order_id = $1
+		  AND EXISTS (
+			SELECT 1 FROM order_items oi
+			WHERE oi.order_id = $1 AND oi.seller_id = sl.seller_id AND oi.status != $2
+		  )
+		ORDER BY sl.id
+	`, orderID, domain.OrderItemStatusCancelled)
+	return lines, err
+}
+
+// ListTaxLines sums the tax charged on top of the prices of the order's live
+// items, one line per tax name. Inclusive tax is already part of the prices.
+func (r *OrderRepository) ListTaxLines(ctx context.Context, orderID int64) ([]domain.OrderLine, error) {
+	var lines []domain.OrderLine
+	err := r.db.SelectContext(ctx, &lines, `
+		SELECT tax_name AS product_name, (SUM(tax_amount), currency) AS unit_price, currency, 1 AS quantity
+		FROM order_items
+		WHERE order_id = $1 AND status != $2 AND NOT tax_inclusive AND tax_amount > 0
+		GROUP BY tax_name, currency
+		ORDER BY MIN(id)
+	`, orderID, domain.OrderItemStatusCancelled)
+	return lines, err
+}
+
+// GetOrderForUpdate loads the order and locks it until the transaction ends
+func (r *OrderRepository) GetOrderForUpdate(ctx context.Context, orderID int64) (*domain.Order, error) {
+	var order domain.Order
+	err := r.db.GetContext(ctx, &order, `
+		SELECT `+orderColumns+`, payment_intent_id
+		FROM orders
+		WHERE id = $1
+		FOR UPDATE
+	`, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOrderNotFound
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return &order, nil
+}
+
+// ListStalePendingOrderIDs finds unpaid orders whose latest checkout attempt is older than cutoff
+func (r *OrderRepository) ListStalePendingOrderIDs(ctx context.Context, cutoff time.Time, limit int) ([]int64, error) {
+	var ids []int64
+	err := r.db.SelectContext(ctx, &ids, `
+		SELECT o.id
+		FROM orders o
+		WHERE o.status = $1
+		  AND o.payment_status IN ($2, $3)
+		  AND COALESCE((SELECT MAX(p.created_at) FROM payments p WHERE p.order_id = o.id), o.created_at) < $4
+		ORDER BY o.id
+		LIMIT $5
+	`, domain.OrderStatusPending, domain.PaymentStatusPending, domain.PaymentStatusFailed, cutoff, limit)
+	return ids, err
+}
+
+// CancelItemsByOrder cancels every item of the order that is still pending,
+// and the shipments left empty
+func (r *OrderRepository) CancelItemsByOrder(ctx context.Context, orderID int64) error {
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		var changes []statusChange
+		err := tx.SelectContext(ctx, &changes, `
+			UPDATE order_items
+			SET status = $1, updated_at = NOW()
+			WHERE order_id = $2 AND status = $3
+			RETURNING id, order_id, seller_id, status AS to_status
+		`, domain.OrderItemStatusCancelled, orderID, domain.OrderItemStatusPending)
+		if err != nil {
+			return err
+		}
+		for i := range changes {
+			changes[i].From = fromStatus(string(domain.OrderItemStatusPending))
+		}
+		if err := recordChanges(ctx, tx, domain.OrderEventItem, changes); err != nil {
+			return err
+		}
+		return cancelEmptyShipments(ctx, tx, orderID)
+	})
+}
 
-	if affected == 0 {
-		return errors.New("order not found for given order_id")
+func (r *OrderRepository) GetOrderIDByPaymentIntent(ctx context.Context, paymentIntentID string) (int64, error) {
+	var orderID int64
+	err := r.db.GetContext(ctx, &orderID, `SELECT id FROM orders WHERE payment_intent_id = $1`, paymentIntentID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrOrderNotFound
 	}
+	return orderID, err
+}
+
+// UpdateStatus moves the order and its payment, recording each status that changed
+func (r *OrderRepository) UpdateStatus(ctx context.Context, orderID int64, orderStatus domain.OrderStatus, paymentStatus domain.PaymentStatus) error {
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		var old struct {
+			Status        string `db:"status"`
+			PaymentStatus string `db:"payment_status"`
+		}
+		err := tx.GetContext(ctx, &old, `
+			UPDATE orders o
+			SET status = $1, payment_status = $2, updated_at = NOW()
+			FROM (SELECT id, status, payment_status FROM orders WHERE id = $3 FOR UPDATE) old
+			WHERE o.id = old.id
+			RETURNING old.status, old.payment_status
+		`, orderStatus, paymentStatus, orderID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrOrderNotFound
+		}
+		if err != nil {
+			return err
+		}
 
-	return nil
+		order := []statusChange{{ID: orderID, OrderID: orderID, From: &old.Status, To: string(orderStatus)}}
+		if err := recordChanges(ctx, tx, domain.OrderEventOrder, order); err != nil {
+			return err
+		}
+		payment := []statusChange{{ID: orderID, OrderID: orderID, From: &old.PaymentStatus, To: string(paymentStatus)}}
+		return recordChanges(ctx, tx, domain.OrderEventPayment, payment)
+	})
+}
+
+func (r *OrderRepository) SetPaymentIntentID(ctx context.Context, orderID int64, paymentIntentID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE orders
+		SET payment_intent_id = $1, updated_at = NOW()
+		WHERE id = $2
+	`, paymentIntentID, orderID)
+	return err
 }
 
 // Get order-item by id
 func (r *OrderRepository) GetOrderItemByID(ctx context.Context, itemID int64) (*domain.OrderItem, error) {
-	const q = `
-		SELECT 
-			oi.id, oi.order_id, oi.offer_id, oi.product_id,
-			oi.seller_id, oi.quantity, oi.unit_price, oi.status,
-			oi.created_at, oi.updated_at,
+	q := `
+		SELECT `+orderItemColumns+`,
 			o.user_id AS order_user_id     -- <-- ключевая строка
 		FROM order_items oi
 		JOIN orders o ON o.id = oi.order_id
 		WHERE oi.id = $1
 	`
 	var item domain.OrderItem
-	if err := r.db.GetContext(ctx, &item, q, itemID); err != nil {
+	err := r.db.GetContext(ctx, &item, q, itemID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOrderItemNotFound
+	}
+	if err != nil {
 		return nil, err
 	}
 	return &item, nil
 }
 
-// Seller changes status
-func (r *OrderRepository) UpdateOrderItemStatus(ctx context.Context, itemID int64, status domain.OrderItemStatus) error {
-	_, err := r.db.ExecContext(ctx, `
-		UPDATE order_items
-		SET status = $1, updated_at = now()
-		WHERE id = $2
-	`, status, itemID)
-	return err
-}
-
 // List all order-items that belong to the given seller
 func (r *OrderRepository) ListOrderItemsBySeller(
 	ctx context.Context,
@@ -175,18 +415,25 @@ func (r *OrderRepository) ListOrderItemsBySeller(
 		oi.order_id      AS order_id,
 		oi.product_id    AS product_id,
 		p.name           AS product_name,
+		oi.sku           AS sku,
+		oi.attributes    AS attributes,
 		oi.quantity      AS quantity,
-		oi.unit_price    AS unit_price,
+		(oi.unit_price, oi.currency) AS unit_price,
+		oi.currency      AS currency,
 		oi.status        AS status,
-		(o.payment_status = 'successful') AS paid,
+		oi.shipment_id   AS shipment_id,
+		sh.status        AS shipment_status,
+		(o.payment_status IN ($2, $3, $4)) AS paid,
 		o.created_at     AS placed_at,
 		o.user_id        AS customer_id,
 		u.username       AS customer_name,
+		o.shipping_address AS shipping_address,
 		r.id             AS refund_id,
 		r.status         AS refund_status,
 		r.reason         AS refund_reason
 	FROM order_items oi
 	JOIN orders  o ON o.id  = oi.order_id
+	JOIN shipments sh ON sh.id = oi.shipment_id
 	JOIN products p ON p.id = oi.product_id
 	JOIN users    u ON u.id = o.user_id
 	LEFT JOIN refunds r ON r.order_item_id = oi.id
@@ -194,7 +441,10 @@ func (r *OrderRepository) ListOrderItemsBySeller(
 	ORDER BY o.created_at DESC`
 
 	var rows []reqresp.SellerOrderItem
-	if err := r.db.SelectContext(ctx, &rows, q, sellerID); err != nil {
+	// A refund, full or partial, does not undo the payment
+	err := r.db.SelectContext(ctx, &rows, q, sellerID,
+		domain.PaymentStatusSuccessful, domain.PaymentStatusPartiallyRefunded, domain.PaymentStatusRefunded)
+	if err != nil {
 		return nil, err
 	}
 	return rows, nil
@@ -0,0 +1,197 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"go-app-marketplace/pkg/domain"
+)
+
+var ErrPaymentNotFound = errors.New("payment not found")
+
+type PaymentRepository struct {
+	db DBTX
+}
+
+func NewPaymentRepository(db *sqlx.DB) *PaymentRepository {
+	return &PaymentRepository{db: db}
+}
+
+const paymentColumns = `id, order_id, provider, checkout_session_id, payment_intent_id,
+	(amount, currency) AS amount, currency, status, raw_payload, created_at, updated_at`
+
+// Create records a new checkout attempt together with its first transition
+func (r *PaymentRepository) Create(ctx context.Context, p *domain.Payment, eventType string) (int64, error) {
+	var id int64
+	err := inTx(ctx, r.db, func(tx DBTX) error {
+		err := tx.GetContext(ctx, &id, `
+			INSERT INTO payments (order_id, provider, checkout_session_id, payment_intent_id, amount, currency, status, raw_payload)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id
+		`, p.OrderID, p.Provider, p.CheckoutSessionID, p.PaymentIntentID, p.Amount, p.Currency, p.Status, nullJSON(p.RawPayload))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO payment_transitions (payment_id, from_status, to_status, event_type, raw_payload)
+			VALUES ($1, NULL, $2, $3, $4)
+		`, id, p.Status, eventType, nullJSON(p.RawPayload))
+		return err
+	})
+	return id, err
+}
+
+// FindForEvent locates the attempt a provider event belongs to: by session,
+// then by payment intent, then the latest attempt of the order not yet bound
+// to a payment intent.
+func (r *PaymentRepository) FindForEvent(ctx context.Context, sessionID, paymentIntentID string, orderID int64) (*domain.Payment, error) {
+	var p domain.Payment
+	err := r.db.GetContext(ctx, &p, `
+		SELECT `+paymentColumns+`
+		FROM payments
+		WHERE ($1 <> '' AND checkout_session_id = $1)
+		   OR ($2 <> '' AND payment_intent_id = $2)
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, sessionID, paymentIntentID)
+	if err == nil {
+		return &p, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	err = r.db.GetContext(ctx, &p, `
+		SELECT `+paymentColumns+`
+		FROM payments
+		WHERE order_id = $1 AND payment_intent_id IS NULL
+		ORDER BY created_at DESC
+		LIMIT 1
+	`, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Transition stores the latest provider payload on the payment and appends a
+// transition row when the status actually changes.
+func (r *PaymentRepository) Transition(ctx context.Context, paymentID int64, to domain.PaymentAttemptStatus,
+	paymentIntentID, eventType string, payload []byte) error {
+
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		var from domain.PaymentAttemptStatus
+		err := tx.GetContext(ctx, &from, `SELECT status FROM payments WHERE id = $1 FOR UPDATE`, paymentID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE payments
+			SET status = $1,
+			    payment_intent_id = COALESCE(NULLIF($2, ''), payment_intent_id),
+			    raw_payload = COALESCE($3::jsonb, raw_payload),
+			    updated_at = NOW()
+			WHERE id = $4
+		`, to, paymentIntentID, nullJSON(payload), paymentID)
+		if err != nil {
+			return err
+		}
+
+		if from == to {
+			return nil
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO payment_transitions (payment_id, from_status, to_status, event_type, raw_payload)
+			VALUES ($1, $2, $3, $4, $5)
+		`, paymentID, from, to, eventType, nullJSON(payload))
+		return err
+	})
+}
+
+func (r *PaymentRepository) ListOpenByOrder(ctx context.Context, orderID int64) ([]domain.Payment, error) {
+	var rows []domain.Payment
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT `+paymentColumns+`
+		FROM payments
+		WHERE order_id = $1 AND status = $2
+	`, orderID, domain.PaymentAttemptOpen)
+	return rows, err
+}
+
+func (r *PaymentRepository) HasOpen(ctx context.Context, orderID int64) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, `
+		SELECT EXISTS (SELECT 1 FROM payments WHERE order_id = $1 AND status = $2)
+	`, orderID, domain.PaymentAttemptOpen)
+	return exists, err
+}
+
+func (r *PaymentRepository) ListByOrder(ctx context.Context, orderID int64) ([]domain.Payment, error) {
+	var rows []domain.Payment
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT `+paymentColumns+`
+		FROM payments
+		WHERE order_id = $1
+		ORDER BY created_at DESC
+	`, orderID)
+	return rows, err
+}
+
+func (r *PaymentRepository) GetByID(ctx context.Context, id int64) (*domain.Payment, error) {
+	var p domain.Payment
+	err := r.db.GetContext(ctx, &p, `SELECT `+paymentColumns+` FROM payments WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPaymentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// List returns the ledger page newest first, optionally filtered by status and order
+func (r *PaymentRepository) List(ctx context.Context, status string, orderID int64, page, pageSize int) ([]domain.Payment, int64, error) {
+	var total int64
+	err := r.db.GetContext(ctx, &total, `
+		SELECT COUNT(*) FROM payments
+		WHERE ($1 = '' OR status = $1) AND ($2 = 0 OR order_id = $2)
+	`, status, orderID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var rows []domain.Payment
+	err = r.db.SelectContext(ctx, &rows, `
+		SELECT `+paymentColumns+`
+		FROM payments
+		WHERE ($1 = '' OR status = $1) AND ($2 = 0 OR order_id = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`, status, orderID, pageSize, (page-1)*pageSize)
+	return rows, total, err
+}
+
+func (r *PaymentRepository) ListTransitions(ctx context.Context, paymentID int64) ([]domain.PaymentTransition, error) {
+	var rows []domain.PaymentTransition
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT id, payment_id, from_status, to_status, event_type, raw_payload, created_at
+		FROM payment_transitions
+		WHERE payment_id = $1
+		ORDER BY created_at, id
+	`, paymentID)
+	return rows, err
+}
+
+// nullJSON stores an empty payload as NULL instead of an invalid JSONB value
+func nullJSON(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
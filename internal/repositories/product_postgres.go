@@ -2,10 +2,18 @@ package repositories
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"github.com/jmoiron/sqlx"
 	"go-app-marketplace/pkg/domain"
 )
 
+var (
+	ErrProductNotFound = errors.New("product not found")
+	ErrProductExists   = errors.New("a product with this name already exists")
+)
+
 type ProductRepository struct {
 	db *sqlx.DB
 }
@@ -14,46 +22,293 @@ func NewProductRepository(db *sqlx.DB) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
+// CreateProduct also gives the product its plain variant, so offers can be
+// made for it before it has any attribute variants
 func (r *ProductRepository) CreateProduct(ctx context.Context, product *domain.Product) (int64, error) {
 	var id int64
-	err := r.db.QueryRowContext(ctx, `
-		INSERT INTO products (name, description)
-		VALUES ($1, $2)
-		RETURNING id
-	`, product.Name, product.Description).Scan(&id)
+	err := inTx(ctx, r.db, func(tx DBTX) error {
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO products (name, description, tax_class)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`, product.Name, product.Description, product.TaxClass).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO product_variants (product_id, sku)
+			VALUES ($1, $2)
+		`, id, domain.PlainVariantSKU(id))
+		return err
+	})
 	return id, err
 }
 
 func (r *ProductRepository) GetProductByID(ctx context.Context, id int64) (*domain.Product, error) {
 	var product domain.Product
 	err := r.db.GetContext(ctx, &product, `
-		SELECT id, name, description, created_at, updated_at
+		SELECT id, name, description, tax_class, created_at, updated_at, archived_at
 		FROM products
 		WHERE id = $1
 	`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrProductNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 	return &product, nil
 }
 
-func (r *ProductRepository) ListProducts(ctx context.Context, page, pageSize int) ([]*domain.Product, error) {
-	var products []*domain.Product
+// offerRates is a "rates" CTE with the rate converting each currency into $1.
+// A pair stored in one direction is used for the other as well.
+const offerRates = `
+	rates AS (
+		SELECT DISTINCT ON (currency) currency, rate
+		FROM (
+			SELECT $1::varchar AS currency, 1::numeric AS rate, 0 AS pref
+			UNION ALL
+			SELECT base_currency, rate, 1 FROM exchange_rates WHERE quote_currency = $1
+			UNION ALL
+			SELECT quote_currency, 1 / rate, 2 FROM exchange_rates WHERE base_currency = $1
+		) r
+		ORDER BY currency, pref
+	)`
+
+// productListing prices the available offers of live products in $1 and sums
+// them up per product, counting only the offers of seller $2 when it is set.
+// With a category slug in $6 only products in that category or below it are
+// listed. Offers in a currency without an exchange rate have no price. Every
+// listing query starts with it, so they all take the same arguments:
+// currency, seller, in stock, min price, max price, category.
+const productListing = `
+	WITH RECURSIVE subtree AS (
+		SELECT id FROM categories WHERE slug = $6::varchar
+		UNION ALL
+		SELECT c.id FROM subtree s JOIN categories c ON c.parent_id = s.id
+	), catalog AS (
+		SELECT p.id
+		FROM products p
+		WHERE p.archived_at IS NULL
+		  AND ($6 IS NULL OR EXISTS (
+			SELECT 1 FROM product_categories pc JOIN subtree s ON s.id = pc.category_id
+			WHERE pc.product_id = p.id
+		  ))
+	), ` + offerRates + `, priced AS (
+		SELECT o.product_id, o.seller_id, o.stock, ROUND(o.price * r.rate, 2) AS price
+		FROM offers o
+		JOIN catalog p ON p.id = o.product_id
+		LEFT JOIN rates r ON r.currency = o.currency
+		WHERE o.is_available
+	), summary AS (
+		SELECT p.id,
+		       MIN(o.price) AS price,
+		       MIN(o.price) FILTER (WHERE o.stock > 0) AS stock_price,
+		       COUNT(o.product_id) AS offers,
+		       COALESCE(BOOL_OR(o.stock > 0), FALSE) AS in_stock
+		FROM catalog p
+		LEFT JOIN priced o ON o.product_id = p.id AND ($2::bigint IS NULL OR o.seller_id = $2)
+		GROUP BY p.id
+	), bounds AS (
+		SELECT $4::numeric AS min_price, $5::numeric AS max_price
+	), filtered AS (
+		SELECT id, in_stock, CASE WHEN $3::boolean THEN stock_price ELSE price END AS best_price
+		FROM summary
+		WHERE ($2 IS NULL OR offers > 0) AND (NOT $3 OR in_stock)
+	)
+`
+
+// moneyColumn selects a nullable amount together with its currency, as the
+// one value domain.Money scans; a NULL amount stays NULL
+func moneyColumn(amount, currency string) string {
+	return fmt.Sprintf(`CASE WHEN %[1]s IS NOT NULL THEN (%[1]s, %[2]s) END`, amount, currency)
+}
+
+// priceBetween applies the min and max price filter to a price column
+func priceBetween(column string) string {
+	return fmt.Sprintf(`(SELECT (min_price IS NULL OR %[1]s >= min_price) AND (max_price IS NULL OR %[1]s <= max_price) FROM bounds)`, column)
+}
+
+var productSortOrder = map[domain.ProductSort]string{
+	domain.ProductSortNewest:      "p.created_at DESC, p.id DESC",
+	domain.ProductSortPriceAsc:    "f.best_price ASC NULLS LAST, p.id DESC",
+	domain.ProductSortPriceDesc:   "f.best_price DESC NULLS LAST, p.id DESC",
+	domain.ProductSortBestSelling: "sold_count DESC, p.created_at DESC, p.id DESC",
+}
+
+func listingArgs(filter domain.ProductFilter) []interface{} {
+	category := sql.NullString{String: filter.CategorySlug, Valid: filter.CategorySlug != ""}
+	return []interface{}{filter.Currency, filter.SellerID, filter.InStock, filter.MinPrice, filter.MaxPrice, category}
+}
+
+// ListProducts returns a page of live products matching the filter. Sold
+// counts only take paid orders' items that were not cancelled.
+func (r *ProductRepository) ListProducts(ctx context.Context, filter domain.ProductFilter, page, pageSize int) ([]*domain.ProductListing, error) {
+	order, ok := productSortOrder[filter.Sort]
+	if !ok {
+		return nil, domain.ErrInvalidProductFilter
+	}
+
+	var products []*domain.ProductListing
 	offset := (page - 1) * pageSize
-	err := r.db.SelectContext(ctx, &products, `
-		SELECT id, name, description, created_at, updated_at
-		FROM products
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
-	`, pageSize, offset)
-	return products, err
+	err := r.db.SelectContext(ctx, &products, productListing+`
+		SELECT p.id, p.name, p.description, p.tax_class, p.created_at, p.updated_at, p.archived_at,
+		       `+moneyColumn("f.best_price", "$1::varchar")+` AS best_price, f.in_stock, COALESCE(sold.quantity, 0) AS sold_count
+		FROM filtered f
+		JOIN products p ON p.id = f.id
+		LEFT JOIN (
+			SELECT oi.product_id, SUM(oi.quantity) AS quantity
+			FROM order_items oi
+			JOIN orders o ON o.id = oi.order_id
+			WHERE o.status = 'paid' AND oi.status <> 'cancelled'
+			GROUP BY oi.product_id
+		) sold ON sold.product_id = p.id
+		WHERE `+priceBetween("f.best_price")+`
+		ORDER BY `+order+`
+		LIMIT $7 OFFSET $8
+	`, append(listingArgs(filter), pageSize, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (r *ProductRepository) CountProducts(ctx context.Context, filter domain.ProductFilter) (int64, error) {
+	var total int64
+	err := r.db.GetContext(ctx, &total, productListing+`
+		SELECT COUNT(*)
+		FROM filtered
+		WHERE `+priceBetween("best_price")+`
+	`, listingArgs(filter)...)
+	return total, err
+}
+
+// ProductFacets counts, for each filter, what choosing it would list given
+// the others: the price range without the price filter, the products in stock
+// and the products of each seller
+func (r *ProductRepository) ProductFacets(ctx context.Context, filter domain.ProductFilter) (*domain.ProductFacets, error) {
+	args := listingArgs(filter)
+
+	var prices struct {
+		Min *domain.Money `db:"min_price"`
+		Max *domain.Money `db:"max_price"`
+	}
+	err := r.db.GetContext(ctx, &prices, productListing+`
+		SELECT `+moneyColumn("MIN(best_price)", "$1::varchar")+` AS min_price,
+		       `+moneyColumn("MAX(best_price)", "$1::varchar")+` AS max_price
+		FROM filtered
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	facets := &domain.ProductFacets{MinPrice: prices.Min, MaxPrice: prices.Max}
+
+	err = r.db.GetContext(ctx, &facets.InStockCount, productListing+`
+		SELECT COUNT(*)
+		FROM summary
+		WHERE ($2 IS NULL OR offers > 0) AND in_stock AND `+priceBetween("stock_price")+`
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.SelectContext(ctx, &facets.Sellers, productListing+`
+		SELECT o.seller_id, u.username, COUNT(DISTINCT o.product_id) AS count
+		FROM priced o
+		JOIN users u ON u.id = o.seller_id
+		WHERE (NOT $3 OR o.stock > 0) AND `+priceBetween("o.price")+`
+		GROUP BY o.seller_id, u.username
+		ORDER BY count DESC, o.seller_id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	return facets, nil
+}
+
+// htmlEscaped escapes a text column for HTML. Headlines are built from the
+// escaped text, so the only markup they carry is our own <mark> tags.
+func htmlEscaped(column string) string {
+	return fmt.Sprintf(`replace(replace(replace(replace(replace(%s, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;')`, column)
 }
 
-func (r *ProductRepository) GetTotalProducts(ctx context.Context) (int64, error) {
+// searchHeadline marks the matched words of the highlighted name and snippet
+const searchHeadline = `StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MinWords=5, MaxWords=20, FragmentDelimiter=" … "`
+
+// SearchProducts returns a page of live products matching the tsquery, best
+// ranked first; name matches weigh more than description matches. The
+// highlighted name and snippet are HTML.
+func (r *ProductRepository) SearchProducts(ctx context.Context, tsquery string, page, pageSize int) ([]*domain.ProductSearchResult, error) {
+	var results []*domain.ProductSearchResult
+	offset := (page - 1) * pageSize
+	err := r.db.SelectContext(ctx, &results, `
+		SELECT p.id, p.name, p.description, p.tax_class, p.created_at, p.updated_at, p.archived_at,
+		       ts_rank(p.search_vector, q) AS rank,
+		       ts_headline('english', `+htmlEscaped("p.name")+`, q, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS name_highlight,
+		       ts_headline('english', `+htmlEscaped("coalesce(p.description, '')")+`, q, $4) AS snippet
+		FROM products p, to_tsquery('english', $1) q
+		WHERE p.archived_at IS NULL AND p.search_vector @@ q
+		ORDER BY rank DESC, p.created_at DESC, p.id DESC
+		LIMIT $2 OFFSET $3
+	`, tsquery, pageSize, offset, searchHeadline)
+	return results, err
+}
+
+func (r *ProductRepository) CountSearchResults(ctx context.Context, tsquery string) (int64, error) {
 	var total int64
 	err := r.db.GetContext(ctx, &total, `
 		SELECT COUNT(*)
 		FROM products
-	`)
+		WHERE archived_at IS NULL AND search_vector @@ to_tsquery('english', $1)
+	`, tsquery)
 	return total, err
 }
+
+// UpdateProduct saves the product's name, description and tax class
+func (r *ProductRepository) UpdateProduct(ctx context.Context, product *domain.Product) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE products
+		SET name = $2, description = $3, tax_class = $4, updated_at = NOW()
+		WHERE id = $1
+	`, product.ID, product.Name, product.Description, product.TaxClass)
+	if isUniqueViolation(err) {
+		return ErrProductExists
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+// ArchiveProduct takes the product out of the catalogue and its offers off sale.
+// The row stays, since order items keep referring to it. Archiving an archived
+// product changes nothing.
+func (r *ProductRepository) ArchiveProduct(ctx context.Context, id int64) error {
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		var archivedAt sql.NullTime
+		err := tx.GetContext(ctx, &archivedAt, `
+			UPDATE products
+			SET archived_at = COALESCE(archived_at, NOW()), updated_at = NOW()
+			WHERE id = $1
+			RETURNING archived_at
+		`, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrProductNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE offers
+			SET is_available = FALSE, updated_at = NOW()
+			WHERE product_id = $1 AND is_available
+		`, id)
+		return err
+	})
+}
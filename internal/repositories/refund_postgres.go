@@ -14,14 +14,15 @@ var (
 	ErrRefundAlreadyExists   = errors.New("refund already requested for this item")
 	ErrRefundNotFound        = sql.ErrNoRows
 	ErrRefundStatusForbidden = errors.New("illegal refund status transition")
+	ErrRefundNoPayment       = errors.New("order has no captured payment to refund")
 )
 
-type RefundRepository struct{ db *sqlx.DB }
+type RefundRepository struct{ db DBTX }
 
 func NewRefundRepository(db *sqlx.DB) *RefundRepository { return &RefundRepository{db} }
 
-// customer side — request refund
-func (r *RefundRepository) Create(ctx context.Context, item domain.OrderItem, amount float64, reason string) (int64, error) {
+// customer side — request refund; tax is the part of amount that reverses the item's tax
+func (r *RefundRepository) Create(ctx context.Context, item domain.OrderItem, amount, tax domain.Money, reason string) (int64, error) {
 	// 14-days rule
 	if time.Since(item.UpdatedAt) > 14*24*time.Hour {
 		return 0, ErrRefundTooLate
@@ -33,33 +34,169 @@ func (r *RefundRepository) Create(ctx context.Context, item domain.OrderItem, am
 		return 0, ErrRefundAlreadyExists
 	}
 	var id int64
-	err := r.db.GetContext(ctx, &id, `
-		INSERT INTO refunds (order_item_id, requester_id, seller_id, amount, reason)
-		VALUES ($1,$2,$3,$4,$5) RETURNING id`,
-		item.ID, item.OrderID, item.SellerID, amount, reason)
+	err := inTx(ctx, r.db, func(tx DBTX) error {
+		err := tx.GetContext(ctx, &id, `
+			INSERT INTO refunds (order_item_id, requester_id, seller_id, amount, currency, tax_amount, reason)
+			VALUES ($1,$2,$3,$4,$5,$6,$7) RETURNING id`,
+			item.ID, item.OrderUserID, item.SellerID, amount, amount.Currency, tax, reason)
+		if err != nil {
+			return err
+		}
+		created := []statusChange{{ID: id, OrderID: item.OrderID, SellerID: &item.SellerID, To: string(domain.RefundPending)}}
+		return recordChanges(ctx, tx, domain.OrderEventRefund, created)
+	})
+	return id, err
+}
+
+// CreateApproved records the refund of a cancelled item, approved right away
+// so it can be sent to the payment provider
+func (r *RefundRepository) CreateApproved(ctx context.Context, item domain.OrderItem, amount, tax domain.Money, reason string) (int64, error) {
+	var id int64
+	err := inTx(ctx, r.db, func(tx DBTX) error {
+		err := tx.GetContext(ctx, &id, `
+			INSERT INTO refunds (order_item_id, requester_id, seller_id, amount, currency, tax_amount, reason, status)
+			VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING id`,
+			item.ID, item.OrderUserID, item.SellerID, amount, amount.Currency, tax, reason, domain.RefundApproved)
+		if isUniqueViolation(err) {
+			return ErrRefundAlreadyExists
+		}
+		if err != nil {
+			return err
+		}
+		created := []statusChange{{ID: id, OrderID: item.OrderID, SellerID: &item.SellerID, To: string(domain.RefundApproved)}}
+		return recordChanges(ctx, tx, domain.OrderEventRefund, created)
+	})
 	return id, err
 }
 
 // seller side — approve / reject
 func (r *RefundRepository) UpdateStatus(ctx context.Context, refundID int64, next domain.RefundStatus) error {
 	// allowed only from pending
-	res, err := r.db.ExecContext(ctx,
+	return r.transition(ctx, domain.RefundPending,
 		`UPDATE refunds SET status=$1, updated_at=now()
 		  WHERE id=$2 AND status='pending'`, next, refundID)
+}
+
+// refundColumns selects a refund from "refunds rf"
+const refundColumns = `rf.id, rf.order_item_id, rf.requester_id, rf.seller_id,
+	(rf.amount, rf.currency) AS amount, rf.currency, (rf.tax_amount, rf.currency) AS tax_amount,
+	rf.reason, rf.status, rf.created_at, rf.updated_at, rf.provider_refund_id, rf.failure_reason`
+
+func (r *RefundRepository) GetByID(ctx context.Context, id int64) (*domain.Refund, error) {
+	var rf domain.Refund
+	err := r.db.GetContext(ctx, &rf, `SELECT `+refundColumns+` FROM refunds rf WHERE rf.id=$1`, id)
 	if err != nil {
-		return err
-	}
-	if n, _ := res.RowsAffected(); n == 0 {
-		return ErrRefundStatusForbidden
+		return nil, err
 	}
-	return nil
+	return &rf, nil
 }
 
-func (r *RefundRepository) GetByID(ctx context.Context, id int64) (*domain.Refund, error) {
+// Locks the refund row until the surrounding transaction ends
+func (r *RefundRepository) GetByIDForUpdate(ctx context.Context, id int64) (*domain.Refund, error) {
 	var rf domain.Refund
-	err := r.db.GetContext(ctx, &rf, `SELECT * FROM refunds WHERE id=$1`, id)
+	err := r.db.GetContext(ctx, &rf, `SELECT `+refundColumns+` FROM refunds rf WHERE rf.id=$1 FOR UPDATE`, id)
 	if err != nil {
 		return nil, err
 	}
 	return &rf, nil
 }
+
+// RefundTarget is what the payment provider needs to execute a refund
+type RefundTarget struct {
+	RefundID        int64        `db:"refund_id"`
+	Amount          domain.Money `db:"amount"`
+	Currency        string       `db:"currency"`
+	PaymentIntentID *string      `db:"payment_intent_id"`
+}
+
+func (r *RefundRepository) GetTarget(ctx context.Context, refundID int64) (*RefundTarget, error) {
+	var t RefundTarget
+	err := r.db.GetContext(ctx, &t, `
+		SELECT rf.id AS refund_id, (rf.amount, rf.currency) AS amount, rf.currency, o.payment_intent_id
+		FROM refunds rf
+		JOIN order_items oi ON oi.id = rf.order_item_id
+		JOIN orders o ON o.id = oi.order_id
+		WHERE rf.id = $1`, refundID)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// refundChange completes a refund update so it reports the change for the order's history
+const refundChange = `
+		  RETURNING id, seller_id, status AS to_status,
+		            (SELECT order_id FROM order_items WHERE order_items.id = refunds.order_item_id) AS order_id`
+
+// transition moves the refund only if it is currently in the from state, and
+// records the move in the order's history
+func (r *RefundRepository) transition(ctx context.Context, from domain.RefundStatus, query string, args ...interface{}) error {
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		var changes []statusChange
+		if err := tx.SelectContext(ctx, &changes, query+refundChange, args...); err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			return ErrRefundStatusForbidden
+		}
+		for i := range changes {
+			changes[i].From = fromStatus(string(from))
+		}
+		return recordChanges(ctx, tx, domain.OrderEventRefund, changes)
+	})
+}
+
+// Provider accepted the refund; it stays approved until the provider confirms it
+func (r *RefundRepository) MarkSubmitted(ctx context.Context, refundID int64, providerRefundID string) error {
+	return r.transition(ctx, domain.RefundApproved,
+		`UPDATE refunds SET provider_refund_id=$1, failure_reason=NULL, updated_at=now()
+		  WHERE id=$2 AND status='approved'`, providerRefundID, refundID)
+}
+
+func (r *RefundRepository) MarkFailed(ctx context.Context, refundID int64, reason string) error {
+	return r.transition(ctx, domain.RefundApproved,
+		`UPDATE refunds SET status='failed', failure_reason=$1, updated_at=now()
+		  WHERE id=$2 AND status='approved'`, reason, refundID)
+}
+
+func (r *RefundRepository) MarkCompleted(ctx context.Context, refundID int64) error {
+	return r.transition(ctx, domain.RefundApproved,
+		`UPDATE refunds SET status='completed', failure_reason=NULL, updated_at=now()
+		  WHERE id=$1 AND status='approved'`, refundID)
+}
+
+// failed -> approved, so the refund can be sent to the provider again
+func (r *RefundRepository) MarkRetrying(ctx context.Context, refundID int64) error {
+	return r.transition(ctx, domain.RefundFailed,
+		`UPDATE refunds SET status='approved', updated_at=now()
+		  WHERE id=$1 AND status='failed'`, refundID)
+}
+
+// ListUnsubmittedIDs returns approved refunds last touched before cutoff that
+// have no provider refund: the provider call or recording its result failed
+func (r *RefundRepository) ListUnsubmittedIDs(ctx context.Context, cutoff time.Time, limit int) ([]int64, error) {
+	var ids []int64
+	err := r.db.SelectContext(ctx, &ids, `
+		SELECT id
+		FROM refunds
+		WHERE status = 'approved'
+		  AND provider_refund_id IS NULL
+		  AND updated_at < $1
+		ORDER BY id
+		LIMIT $2`, cutoff, limit)
+	return ids, err
+}
+
+// Refunds sent to the provider for the given payment and not confirmed yet
+func (r *RefundRepository) ListAwaitingCompletion(ctx context.Context, paymentIntentID string) ([]domain.Refund, error) {
+	var rows []domain.Refund
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT `+refundColumns+`
+		FROM refunds rf
+		JOIN order_items oi ON oi.id = rf.order_item_id
+		JOIN orders o ON o.id = oi.order_id
+		WHERE o.payment_intent_id = $1
+		  AND rf.status = 'approved'
+		  AND rf.provider_refund_id IS NOT NULL`, paymentIntentID)
+	return rows, err
+}
@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"go-app-marketplace/pkg/domain"
+	"time"
+)
+
+var (
+	ErrInsufficientStock  = errors.New("insufficient stock for offer")
+	ErrReservationExpired = errors.New("stock reservation for this order has expired")
+)
+
+type ReservationRepository struct {
+	db DBTX
+}
+
+func NewReservationRepository(db *sqlx.DB) *ReservationRepository {
+	return &ReservationRepository{db: db}
+}
+
+// reserveStock decrements offer stock and records the reservation. It must run
+// inside the transaction that creates the order item. An offer that is off sale,
+// or whose product is archived, has no stock to reserve.
+func reserveStock(ctx context.Context, tx DBTX, orderID, itemID, offerID int64, quantity int, expiresAt time.Time) error {
+	res, err := tx.ExecContext(ctx, `
+		UPDATE offers o
+		SET stock = o.stock - $1, updated_at = NOW()
+		WHERE o.id = $2 AND o.is_available = TRUE AND o.stock >= $1
+		  AND EXISTS (SELECT 1 FROM products p WHERE p.id = o.product_id AND p.archived_at IS NULL)
+	`, quantity, offerID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrInsufficientStock
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO stock_reservations (order_id, order_item_id, offer_id, quantity, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, orderID, itemID, offerID, quantity, domain.ReservationReserved, expiresAt)
+	return err
+}
+
+// releaseQuery flips matching reservations to released and puts their quantity
+// back on the offers in a single statement. The caller supplies the WHERE clause
+// for the reservations, including the statuses that may be released; it returns
+// the number of released reservations.
+const releaseQuery = `
+	WITH released AS (
+		UPDATE stock_reservations
+		SET status = 'released', updated_at = NOW()
+		WHERE %s
+		RETURNING offer_id, quantity
+	), restocked AS (
+		UPDATE offers o
+		SET stock = o.stock + r.quantity, updated_at = NOW()
+		FROM (SELECT offer_id, SUM(quantity) AS quantity FROM released GROUP BY offer_id) r
+		WHERE o.id = r.offer_id
+		RETURNING o.id
+	)
+	SELECT COUNT(*) FROM released`
+
+func (r *ReservationRepository) release(ctx context.Context, where string, args ...interface{}) (int64, error) {
+	var n int64
+	err := r.db.GetContext(ctx, &n, fmt.Sprintf(releaseQuery, where), args...)
+	return n, err
+}
+
+// Payment confirmed — the stock is sold for good
+func (r *ReservationRepository) CommitByOrder(ctx context.Context, orderID int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE stock_reservations
+		SET status = $1, updated_at = NOW()
+		WHERE order_id = $2 AND status = $3
+	`, domain.ReservationCommitted, orderID, domain.ReservationReserved)
+	return err
+}
+
+func (r *ReservationRepository) ReleaseByOrder(ctx context.Context, orderID int64) (int64, error) {
+	return r.release(ctx, `status = 'reserved' AND order_id = $1`, orderID)
+}
+
+// Returns the stock of an item only once the item is actually cancelled, both
+// while it is reserved and after it was sold
+func (r *ReservationRepository) ReleaseByOrderItem(ctx context.Context, itemID int64) (int64, error) {
+	return r.release(ctx, `status IN ('reserved', 'committed')
+		AND order_item_id = (SELECT id FROM order_items WHERE id = $1 AND status = 'cancelled')`, itemID)
+}
+
+func (r *ReservationRepository) ReleaseExpired(ctx context.Context) (int64, error) {
+	return r.release(ctx, `status = 'reserved' AND expires_at < NOW()`)
+}
+
+// ExtendByOrder pushes the expiry of the order's active reservations forward.
+// It fails if any live item of the order no longer holds a reservation.
+func (r *ReservationRepository) ExtendByOrder(ctx context.Context, orderID int64, expiresAt time.Time) error {
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		var missing bool
+		err := tx.GetContext(ctx, &missing, `
+			SELECT EXISTS (
+				SELECT 1
+				FROM order_items oi
+				LEFT JOIN stock_reservations sr
+					ON sr.order_item_id = oi.id AND sr.status = 'reserved'
+				WHERE oi.order_id = $1 AND oi.status != 'cancelled' AND sr.id IS NULL
+			)
+		`, orderID)
+		if err != nil {
+			return err
+		}
+		if missing {
+			return ErrReservationExpired
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE stock_reservations
+			SET expires_at = $1, updated_at = NOW()
+			WHERE order_id = $2 AND status = 'reserved'
+		`, expiresAt, orderID)
+		return err
+	})
+}
@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go-app-marketplace/pkg/domain"
+)
+
+var ErrShipmentNotFound = errors.New("shipment not found")
+
+type ShipmentRepository struct {
+	db DBTX
+}
+
+func NewShipmentRepository(db *sqlx.DB) *ShipmentRepository {
+	return &ShipmentRepository{db: db}
+}
+
+const shipmentColumns = `id, order_id, seller_id, status, carrier, tracking_number, shipped_at, delivered_at, created_at, updated_at`
+
+func (r *ShipmentRepository) ListByOrder(ctx context.Context, orderID int64) ([]domain.Shipment, error) {
+	var shipments []domain.Shipment
+	err := r.db.SelectContext(ctx, &shipments, `
+		SELECT `+shipmentColumns+`
+		FROM shipments
+		WHERE order_id = $1
+		ORDER BY id
+	`, orderID)
+	return shipments, err
+}
+
+// ListBySeller returns the seller's shipments, newest first, optionally only those in status
+func (r *ShipmentRepository) ListBySeller(ctx context.Context, sellerID int64, status domain.ShipmentStatus) ([]domain.Shipment, error) {
+	var shipments []domain.Shipment
+	err := r.db.SelectContext(ctx, &shipments, `
+		SELECT `+shipmentColumns+`
+		FROM shipments
+		WHERE seller_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC, id DESC
+	`, sellerID, status)
+	return shipments, err
+}
+
+// GetForUpdate loads the shipment and locks it until the transaction ends
+func (r *ShipmentRepository) GetForUpdate(ctx context.Context, id int64) (*domain.Shipment, error) {
+	var s domain.Shipment
+	err := r.db.GetContext(ctx, &s, `
+		SELECT `+shipmentColumns+`
+		FROM shipments
+		WHERE id = $1
+		FOR UPDATE
+	`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrShipmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Update saves the shipment and moves its live items to the status it implies
+func (r *ShipmentRepository) Update(ctx context.Context, s *domain.Shipment) error {
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		var shipment []statusChange
+		err := tx.SelectContext(ctx, &shipment, `
+			UPDATE shipments s
+			SET status = $2, carrier = $3, tracking_number = $4, shipped_at = $5, delivered_at = $6, updated_at = NOW()
+			FROM (SELECT id, status FROM shipments WHERE id = $1 FOR UPDATE) old
+			WHERE s.id = old.id
+			RETURNING s.id, s.order_id, s.seller_id, old.status AS from_status, s.status AS to_status
+		`, s.ID, s.Status, s.Carrier, s.TrackingNumber, s.ShippedAt, s.DeliveredAt)
+		if err != nil {
+			return err
+		}
+		if err := recordChanges(ctx, tx, domain.OrderEventShipment, shipment); err != nil {
+			return err
+		}
+
+		var items []statusChange
+		err = tx.SelectContext(ctx, &items, `
+			UPDATE order_items oi
+			SET status = $2, updated_at = NOW()
+			FROM (SELECT id, status FROM order_items WHERE shipment_id = $1 FOR UPDATE) old
+			WHERE oi.id = old.id AND old.status NOT IN ($2, $3)
+			RETURNING oi.id, oi.order_id, oi.seller_id, old.status AS from_status, oi.status AS to_status
+		`, s.ID, s.Status.ItemStatus(), domain.OrderItemStatusCancelled)
+		if err != nil {
+			return err
+		}
+		return recordChanges(ctx, tx, domain.OrderEventItem, items)
+	})
+}
+
+// ListItemIDs returns the IDs of the items in each of the shipments
+func (r *ShipmentRepository) ListItemIDs(ctx context.Context, shipmentIDs []int64) (map[int64][]int64, error) {
+	var rows []struct {
+		ShipmentID int64 `db:"shipment_id"`
+		ItemID     int64 `db:"id"`
+	}
+	err := r.db.SelectContext(ctx, &rows, `
+		SELECT shipment_id, id
+		FROM order_items
+		WHERE shipment_id = ANY($1)
+		ORDER BY id
+	`, pq.Array(shipmentIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	items := make(map[int64][]int64, len(shipmentIDs))
+	for _, row := range rows {
+		items[row.ShipmentID] = append(items[row.ShipmentID], row.ItemID)
+	}
+	return items, nil
+}
+
+// cancelEmptyShipments cancels the order's shipments that have not left yet and
+// have no live items left
+func cancelEmptyShipments(ctx context.Context, db DBTX, orderID int64) error {
+	var changes []statusChange
+	err := db.SelectContext(ctx, &changes, `
+		UPDATE shipments s
+		SET status = $2, updated_at = NOW()
+		FROM (SELECT id, status FROM shipments WHERE order_id = $1 AND status IN ($3, $4) FOR UPDATE) old
+		WHERE s.id = old.id
+		  AND NOT EXISTS (
+			SELECT 1 FROM order_items oi WHERE oi.shipment_id = s.id AND oi.status != $5
+		  )
+		RETURNING s.id, s.order_id, s.seller_id, old.status AS from_status, s.status AS to_status
+	`, orderID, domain.ShipmentStatusCancelled, domain.ShipmentStatusPending, domain.ShipmentStatusPacked, domain.OrderItemStatusCancelled)
+	if err != nil {
+		return err
+	}
+	return recordChanges(ctx, db, domain.OrderEventShipment, changes)
+}
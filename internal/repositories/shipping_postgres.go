@@ -0,0 +1,204 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"go-app-marketplace/pkg/domain"
+)
+
+var ErrShippingProfileNotFound = errors.New("shipping profile not found")
+
+type ShippingRepository struct {
+	db DBTX
+}
+
+func NewShippingRepository(db *sqlx.DB) *ShippingRepository {
+	return &ShippingRepository{db: db}
+}
+
+// shippingProfileColumns selects a profile with its prices in the profile's currency
+const shippingProfileColumns = `id, seller_id, name, method, (rate, currency) AS rate,
+	CASE WHEN free_above IS NOT NULL THEN (free_above, currency) END AS free_above, currency, is_default, created_at, updated_at`
+
+// ListProfiles returns the seller's profiles with their zones, default first
+func (r *ShippingRepository) ListProfiles(ctx context.Context, sellerID int64) ([]domain.ShippingProfile, error) {
+	var profiles []domain.ShippingProfile
+	err := r.db.SelectContext(ctx, &profiles, `
+		SELECT `+shippingProfileColumns+`
+		FROM shipping_profiles
+		WHERE seller_id = $1
+		ORDER BY is_default DESC, name
+	`, sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range profiles {
+		if err := r.loadZones(ctx, &profiles[i]); err != nil {
+			return nil, err
+		}
+	}
+	return profiles, nil
+}
+
+// GetProfile returns the profile only if it belongs to the seller
+func (r *ShippingRepository) GetProfile(ctx context.Context, sellerID, id int64) (*domain.ShippingProfile, error) {
+	return r.getProfile(ctx, `
+		SELECT `+shippingProfileColumns+`
+		FROM shipping_profiles
+		WHERE id = $1 AND seller_id = $2
+	`, id, sellerID)
+}
+
+func (r *ShippingRepository) getProfile(ctx context.Context, query string, args ...interface{}) (*domain.ShippingProfile, error) {
+	var p domain.ShippingProfile
+	err := r.db.GetContext(ctx, &p, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrShippingProfileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadZones(ctx, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreateProfile stores the profile and its zones; the seller's first profile becomes the default
+func (r *ShippingRepository) CreateProfile(ctx context.Context, p *domain.ShippingProfile) (int64, error) {
+	var id int64
+	err := inTx(ctx, r.db, func(tx DBTX) error {
+		var count int
+		if err := tx.GetContext(ctx, &count, `SELECT COUNT(*) FROM shipping_profiles WHERE seller_id = $1`, p.SellerID); err != nil {
+			return err
+		}
+		isDefault := p.IsDefault || count == 0
+		if isDefault {
+			if err := clearDefaultShippingProfile(ctx, tx, p.SellerID); err != nil {
+				return err
+			}
+		}
+
+		err := tx.GetContext(ctx, &id, `
+			INSERT INTO shipping_profiles (seller_id, name, method, rate, free_above, currency, is_default)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id
+		`, p.SellerID, p.Name, p.Method, p.Rate, p.FreeAbove, p.Currency, isDefault)
+		if err != nil {
+			return err
+		}
+		return insertZones(ctx, tx, id, p.Zones)
+	})
+	return id, err
+}
+
+// UpdateProfile rewrites the profile and replaces its zones
+func (r *ShippingRepository) UpdateProfile(ctx context.Context, p *domain.ShippingProfile) error {
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		if p.IsDefault {
+			if err := clearDefaultShippingProfile(ctx, tx, p.SellerID); err != nil {
+				return err
+			}
+		}
+
+		res, err := tx.ExecContext(ctx, `
+			UPDATE shipping_profiles
+			SET name = $1, method = $2, rate = $3, free_above = $4, currency = $5,
+			    is_default = is_default OR $6, updated_at = NOW()
+			WHERE id = $7 AND seller_id = $8
+		`, p.Name, p.Method, p.Rate, p.FreeAbove, p.Currency, p.IsDefault, p.ID, p.SellerID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrShippingProfileNotFound
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM shipping_zone_rates WHERE profile_id = $1`, p.ID); err != nil {
+			return err
+		}
+		return insertZones(ctx, tx, p.ID, p.Zones)
+	})
+}
+
+func (r *ShippingRepository) SetDefaultProfile(ctx context.Context, sellerID, id int64) error {
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		if err := clearDefaultShippingProfile(ctx, tx, sellerID); err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, `
+			UPDATE shipping_profiles
+			SET is_default = TRUE, updated_at = NOW()
+			WHERE id = $1 AND seller_id = $2
+		`, id, sellerID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrShippingProfileNotFound
+		}
+		return nil
+	})
+}
+
+// DeleteProfile removes the profile; when it was the default, the oldest remaining one takes over
+func (r *ShippingRepository) DeleteProfile(ctx context.Context, sellerID, id int64) error {
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		var wasDefault bool
+		err := tx.GetContext(ctx, &wasDefault, `
+			DELETE FROM shipping_profiles
+			WHERE id = $1 AND seller_id = $2
+			RETURNING is_default
+		`, id, sellerID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrShippingProfileNotFound
+		}
+		if err != nil || !wasDefault {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE shipping_profiles
+			SET is_default = TRUE, updated_at = NOW()
+			WHERE id = (SELECT id FROM shipping_profiles WHERE seller_id = $1 ORDER BY created_at LIMIT 1)
+		`, sellerID)
+		return err
+	})
+}
+
+// loadZones attaches the zone rates, priced in the profile currency
+func (r *ShippingRepository) loadZones(ctx context.Context, p *domain.ShippingProfile) error {
+	return r.db.SelectContext(ctx, &p.Zones, `
+		SELECT z.id, z.profile_id, z.country, z.region, (z.rate, p.currency) AS rate
+		FROM shipping_zone_rates z
+		JOIN shipping_profiles p ON p.id = z.profile_id
+		WHERE z.profile_id = $1
+		ORDER BY z.country, z.region
+	`, p.ID)
+}
+
+func insertZones(ctx context.Context, tx DBTX, profileID int64, zones []domain.ShippingZoneRate) error {
+	for _, z := range zones {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO shipping_zone_rates (profile_id, country, region, rate)
+			VALUES ($1, $2, $3, $4)
+		`, profileID, z.Country, z.Region, z.Rate)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func clearDefaultShippingProfile(ctx context.Context, tx DBTX, sellerID int64) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE shipping_profiles
+		SET is_default = FALSE, updated_at = NOW()
+		WHERE seller_id = $1 AND is_default
+	`, sellerID)
+	return err
+}
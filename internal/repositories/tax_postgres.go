@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go-app-marketplace/pkg/domain"
+)
+
+var (
+	ErrTaxRateNotFound = errors.New("tax rate not found")
+	ErrTaxRateExists   = errors.New("a tax rate for this country, region and tax class already exists")
+)
+
+type TaxRepository struct {
+	db DBTX
+}
+
+func NewTaxRepository(db *sqlx.DB) *TaxRepository {
+	return &TaxRepository{db: db}
+}
+
+const taxRateColumns = `id, country, region, tax_class, name, rate::text AS rate, inclusive, created_at, updated_at`
+
+func (r *TaxRepository) List(ctx context.Context) ([]domain.TaxRate, error) {
+	var rates []domain.TaxRate
+	err := r.db.SelectContext(ctx, &rates, `
+		SELECT `+taxRateColumns+`
+		FROM tax_rates
+		ORDER BY country, region, tax_class
+	`)
+	return rates, err
+}
+
+func (r *TaxRepository) Create(ctx context.Context, rate domain.TaxRate) (domain.TaxRate, error) {
+	var saved domain.TaxRate
+	err := r.db.GetContext(ctx, &saved, `
+		INSERT INTO tax_rates (country, region, tax_class, name, rate, inclusive)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+taxRateColumns,
+		rate.Country, rate.Region, rate.TaxClass, rate.Name, rate.Rate, rate.Inclusive)
+	if isUniqueViolation(err) {
+		return domain.TaxRate{}, ErrTaxRateExists
+	}
+	return saved, err
+}
+
+func (r *TaxRepository) Update(ctx context.Context, rate domain.TaxRate) (domain.TaxRate, error) {
+	var saved domain.TaxRate
+	err := r.db.GetContext(ctx, &saved, `
+		UPDATE tax_rates
+		SET country = $2, region = $3, tax_class = $4, name = $5, rate = $6, inclusive = $7, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+taxRateColumns,
+		rate.ID, rate.Country, rate.Region, rate.TaxClass, rate.Name, rate.Rate, rate.Inclusive)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.TaxRate{}, ErrTaxRateNotFound
+	}
+	if isUniqueViolation(err) {
+		return domain.TaxRate{}, ErrTaxRateExists
+	}
+	return saved, err
+}
+
+func (r *TaxRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM tax_rates WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTaxRateNotFound
+	}
+	return nil
+}
+
+// ResolveForProduct picks the rate for the product's tax class at the
+// destination, preferring a rate for the region over the country-wide one.
+// It returns nil when no rate is configured, i.e. the line is untaxed.
+func (r *TaxRepository) ResolveForProduct(ctx context.Context, productID int64, country, region string) (*domain.TaxRate, error) {
+	var rate domain.TaxRate
+	err := r.db.GetContext(ctx, &rate, `
+		SELECT t.id, t.country, t.region, t.tax_class, t.name, t.rate::text AS rate, t.inclusive, t.created_at, t.updated_at
+		FROM tax_rates t
+		JOIN products p ON p.tax_class = t.tax_class
+		WHERE p.id = $1
+		  AND t.country = UPPER($2)
+		  AND (t.region = '' OR LOWER(t.region) = LOWER($3))
+		ORDER BY t.region = '' ASC
+		LIMIT 1
+	`, productID, country, region)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// 23505 unique_violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"log"
+	"time"
+)
+
+// DBTX is what the repositories need from a connection. Both *sqlx.DB and
+// *sqlx.Tx satisfy it, so a repository can run on its own or inside a UnitOfWork.
+type DBTX interface {
+	sqlx.ExtContext
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+// inTx runs fn in a transaction of its own, or simply joins the transaction
+// when the repository is already bound to one.
+func inTx(ctx context.Context, db DBTX, fn func(tx DBTX) error) error {
+	conn, ok := db.(*sqlx.DB)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback() // will rollback only if Commit hasn't been called
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// TxRepositories are the repositories bound to the transaction of a unit of work
+type TxRepositories struct {
+	Addresses     *AddressRepository
+	Cart          *CartRepository
+	ExchangeRates *ExchangeRateRepository
+	Offers        *OfferRepository
+	Orders        *OrderRepository
+	Refunds       *RefundRepository
+	Reservations  *ReservationRepository
+	Shipments     *ShipmentRepository
+	Shipping      *ShippingRepository
+	Taxes         *TaxRepository
+}
+
+func newTxRepositories(tx *sqlx.Tx) *TxRepositories {
+	return &TxRepositories{
+		Addresses:     &AddressRepository{db: tx},
+		Cart:          &CartRepository{db: tx},
+		ExchangeRates: &ExchangeRateRepository{db: tx},
+		Offers:        &OfferRepository{db: tx},
+		Orders:        &OrderRepository{db: tx},
+		Refunds:       &RefundRepository{db: tx},
+		Reservations:  &ReservationRepository{db: tx},
+		Shipments:     &ShipmentRepository{db: tx},
+		Shipping:      &ShippingRepository{db: tx},
+		Taxes:         &TaxRepository{db: tx},
+	}
+}
+
+const (
+	uowMaxAttempts = 3
+	uowBaseBackoff = 20 * time.Millisecond
+)
+
+type UnitOfWork struct {
+	db *sqlx.DB
+}
+
+func NewUnitOfWork(db *sqlx.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Do runs fn on one REPEATABLE READ transaction and commits it if fn succeeds.
+// Serialization failures and deadlocks roll back and re-run fn from scratch,
+// so fn must not have side effects outside the database.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, tx *TxRepositories) error) error {
+	for attempt := 1; ; attempt++ {
+		err := u.run(ctx, fn)
+		if err == nil || !isRetryableTxError(err) || attempt == uowMaxAttempts {
+			return err
+		}
+
+		log.Printf("Retrying transaction (attempt %d): %v", attempt+1, err)
+		select {
+		case <-time.After(uowBaseBackoff * time.Duration(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (u *UnitOfWork) run(ctx context.Context, fn func(ctx context.Context, tx *TxRepositories) error) error {
+	tx, err := u.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := fn(ctx, newTxRepositories(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// 40001 serialization_failure, 40P01 deadlock_detected
+func isRetryableTxError(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "40001" || pqErr.Code == "40P01"
+	}
+	return false
+}
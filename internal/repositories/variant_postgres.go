@@ -0,0 +1,217 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go-app-marketplace/pkg/domain"
+)
+
+var (
+	ErrVariantNotFound  = errors.New("variant not found")
+	ErrVariantSKUExists = errors.New("a variant with this sku already exists")
+	ErrVariantExists    = errors.New("the product already has a variant with these attribute values")
+	ErrVariantInUse     = errors.New("variant still has offers or orders")
+	ErrVariantLocked    = errors.New("the attributes of a variant with offers or orders cannot change")
+	ErrLastVariant      = errors.New("a product keeps at least one variant")
+	ErrVariantRequired  = errors.New("the product has several variants; choose one")
+)
+
+type VariantRepository struct {
+	db DBTX
+}
+
+func NewVariantRepository(db *sqlx.DB) *VariantRepository {
+	return &VariantRepository{db: db}
+}
+
+const variantColumns = `v.id, v.product_id, v.sku, v.attributes, v.created_at, v.updated_at`
+
+func (r *VariantRepository) ListByProduct(ctx context.Context, productID int64) ([]domain.ProductVariant, error) {
+	var variants []domain.ProductVariant
+	err := r.db.SelectContext(ctx, &variants, `
+		SELECT `+variantColumns+`
+		FROM product_variants v
+		WHERE v.product_id = $1
+		ORDER BY v.id
+	`, productID)
+	return variants, err
+}
+
+// Matrix returns the product's variants with their number of available offers
+// and their best offer, priced in the currency
+func (r *VariantRepository) Matrix(ctx context.Context, productID int64, currency string) ([]*domain.VariantWithOffer, error) {
+	var rows []struct {
+		domain.ProductVariant
+		OfferCount int64         `db:"offer_count"`
+		OfferID    sql.NullInt64 `db:"offer_id"`
+		SellerID   sql.NullInt64 `db:"seller_id"`
+		Price      *domain.Money `db:"price"`
+		Stock      sql.NullInt64 `db:"stock"`
+	}
+	err := r.db.SelectContext(ctx, &rows, `
+		WITH `+offerRates+`
+		SELECT `+variantColumns+`,
+		       (SELECT COUNT(*) FROM offers o WHERE o.variant_id = v.id AND o.is_available) AS offer_count,
+		       best.id AS offer_id, best.seller_id, `+moneyColumn("best.price", "$1::varchar")+` AS price, best.stock
+		FROM product_variants v
+		LEFT JOIN LATERAL (
+			SELECT o.id, o.seller_id, o.stock, ROUND(o.price * r.rate, 2) AS price
+			FROM offers o
+			JOIN rates r ON r.currency = o.currency
+			WHERE o.variant_id = v.id AND o.is_available AND o.stock > 0
+			ORDER BY price, o.id
+			LIMIT 1
+		) best ON TRUE
+		WHERE v.product_id = $2
+		ORDER BY v.id
+	`, currency, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	matrix := make([]*domain.VariantWithOffer, 0, len(rows))
+	for _, row := range rows {
+		variant := &domain.VariantWithOffer{ProductVariant: row.ProductVariant, OfferCount: row.OfferCount}
+		if row.OfferID.Valid && row.Price != nil {
+			variant.BestOffer = &domain.VariantOffer{
+				OfferID:  row.OfferID.Int64,
+				SellerID: row.SellerID.Int64,
+				Price:    *row.Price,
+				Stock:    int(row.Stock.Int64),
+			}
+		}
+		matrix = append(matrix, variant)
+	}
+	return matrix, nil
+}
+
+// Create adds a variant to a live product
+func (r *VariantRepository) Create(ctx context.Context, variant domain.ProductVariant) (domain.ProductVariant, error) {
+	var saved domain.ProductVariant
+	err := r.db.GetContext(ctx, &saved, `
+		INSERT INTO product_variants AS v (product_id, sku, attributes)
+		SELECT $1, $2, $3
+		WHERE EXISTS (SELECT 1 FROM products WHERE id = $1 AND archived_at IS NULL)
+		RETURNING `+variantColumns,
+		variant.ProductID, variant.SKU, variant.Attributes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ProductVariant{}, ErrProductNotFound
+	}
+	return saved, variantError(err)
+}
+
+// Update changes the variant's SKU and attributes. Once the variant is offered
+// or ordered its attributes stay as they are, since sellers and buyers agreed
+// on them; its SKU may still change, order lines keep the one they were made with.
+func (r *VariantRepository) Update(ctx context.Context, variant domain.ProductVariant) (domain.ProductVariant, error) {
+	var saved domain.ProductVariant
+	err := inTx(ctx, r.db, func(tx DBTX) error {
+		var current struct {
+			Changed bool `db:"changed"`
+			InUse   bool `db:"in_use"`
+		}
+		err := tx.GetContext(ctx, &current, `
+			SELECT v.attributes <> $3::jsonb AS changed,
+			       EXISTS (SELECT 1 FROM offers o WHERE o.variant_id = v.id)
+			       OR EXISTS (SELECT 1 FROM order_items oi WHERE oi.variant_id = v.id) AS in_use
+			FROM product_variants v
+			WHERE v.id = $1 AND v.product_id = $2
+			FOR UPDATE
+		`, variant.ID, variant.ProductID, variant.Attributes)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrVariantNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if current.Changed && current.InUse {
+			return ErrVariantLocked
+		}
+
+		return tx.GetContext(ctx, &saved, `
+			UPDATE product_variants AS v
+			SET sku = $3, attributes = $4, updated_at = NOW()
+			WHERE v.id = $1 AND v.product_id = $2
+			RETURNING `+variantColumns,
+			variant.ID, variant.ProductID, variant.SKU, variant.Attributes)
+	})
+	return saved, variantError(err)
+}
+
+// Delete removes a variant nobody offers. The product's last variant stays,
+// since offers could not be made for it otherwise.
+func (r *VariantRepository) Delete(ctx context.Context, productID, variantID int64) error {
+	return inTx(ctx, r.db, func(tx DBTX) error {
+		var ids []int64
+		err := tx.SelectContext(ctx, &ids, `
+			SELECT id FROM product_variants WHERE product_id = $1 FOR UPDATE
+		`, productID)
+		if err != nil {
+			return err
+		}
+
+		found := false
+		for _, id := range ids {
+			found = found || id == variantID
+		}
+		switch {
+		case !found:
+			return ErrVariantNotFound
+		case len(ids) == 1:
+			return ErrLastVariant
+		}
+
+		_, err = tx.ExecContext(ctx, `DELETE FROM product_variants WHERE id = $1`, variantID)
+		if isForeignKeyViolation(err) {
+			return ErrVariantInUse
+		}
+		return err
+	})
+}
+
+// AttributesForProduct returns the attributes of the product's categories and
+// of their ancestors. When two of them define the same code, the one closest
+// to the product's category wins.
+func (r *VariantRepository) AttributesForProduct(ctx context.Context, productID int64) ([]domain.CategoryAttribute, error) {
+	var attributes []domain.CategoryAttribute
+	err := r.db.SelectContext(ctx, &attributes, `
+		WITH RECURSIVE up AS (
+			SELECT c.id, c.parent_id, 0 AS depth
+			FROM product_categories pc
+			JOIN categories c ON c.id = pc.category_id
+			WHERE pc.product_id = $1
+			UNION ALL
+			SELECT c.id, c.parent_id, up.depth + 1
+			FROM up
+			JOIN categories c ON c.id = up.parent_id
+		), closest AS (
+			SELECT DISTINCT ON (a.code) `+attributeColumns+`
+			FROM up
+			JOIN category_attributes a ON a.category_id = up.id
+			ORDER BY a.code, up.depth, a.id
+		)
+		SELECT * FROM closest
+		ORDER BY position, name, id
+	`, productID)
+	return attributes, err
+}
+
+// variantError tells the two unique constraints of a variant apart
+func variantError(err error) error {
+	var pqErr *pq.Error
+	if !isUniqueViolation(err) || !errors.As(err, &pqErr) {
+		return err
+	}
+	if pqErr.Constraint == "product_variants_sku_key" {
+		return ErrVariantSKUExists
+	}
+	return ErrVariantExists
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
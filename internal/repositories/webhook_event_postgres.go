@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"go-app-marketplace/pkg/domain"
+	"time"
+)
+
+var ErrWebhookEventNotFound = errors.New("webhook event not found")
+
+// An event stuck in processing this long is assumed abandoned by a crashed worker
+const webhookProcessingTimeout = 5 * time.Minute
+
+type WebhookEventRepository struct {
+	db DBTX
+}
+
+func NewWebhookEventRepository(db *sqlx.DB) *WebhookEventRepository {
+	return &WebhookEventRepository{db: db}
+}
+
+const webhookEventColumns = `id, provider, event_type, status, attempts, last_error, payload,
+	received_at, updated_at, processed_at`
+
+// Claim stores a new event, or takes back a failed or abandoned one, and marks
+// it as processing. It returns false when the event is already processed or
+// another delivery is working on it.
+func (r *WebhookEventRepository) Claim(ctx context.Context, provider, id, eventType string, payload []byte) (bool, error) {
+	var claimedID string
+	err := r.db.GetContext(ctx, &claimedID, `
+		INSERT INTO webhook_events (id, provider, event_type, payload)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (provider, id) DO UPDATE
+		SET status = 'processing',
+		    attempts = webhook_events.attempts + 1,
+		    updated_at = NOW()
+		WHERE webhook_events.status = 'failed'
+		   OR (webhook_events.status = 'processing' AND webhook_events.updated_at < $5)
+		RETURNING id
+	`, id, provider, eventType, string(payload), time.Now().Add(-webhookProcessingTimeout))
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *WebhookEventRepository) MarkProcessed(ctx context.Context, provider, id string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_events
+		SET status = 'processed', last_error = NULL, processed_at = NOW(), updated_at = NOW()
+		WHERE provider = $1 AND id = $2
+	`, provider, id)
+	return err
+}
+
+func (r *WebhookEventRepository) MarkFailed(ctx context.Context, provider, id, reason string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_events
+		SET status = 'failed', last_error = $3, updated_at = NOW()
+		WHERE provider = $1 AND id = $2
+	`, provider, id, reason)
+	return err
+}
+
+func (r *WebhookEventRepository) Get(ctx context.Context, provider, id string) (*domain.WebhookEvent, error) {
+	var ev domain.WebhookEvent
+	err := r.db.GetContext(ctx, &ev, `
+		SELECT `+webhookEventColumns+`
+		FROM webhook_events
+		WHERE provider = $1 AND id = $2
+	`, provider, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookEventNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ev, nil
+}
+
+// List returns events newest first, optionally filtered by status
+func (r *WebhookEventRepository) List(ctx context.Context, status string, page, pageSize int) ([]domain.WebhookEvent, int64, error) {
+	var total int64
+	err := r.db.GetContext(ctx, &total, `
+		SELECT COUNT(*) FROM webhook_events WHERE ($1 = '' OR status = $1)
+	`, status)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var rows []domain.WebhookEvent
+	err = r.db.SelectContext(ctx, &rows, `
+		SELECT `+webhookEventColumns+`
+		FROM webhook_events
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY received_at DESC
+		LIMIT $2 OFFSET $3
+	`, status, pageSize, (page-1)*pageSize)
+	return rows, total, err
+}
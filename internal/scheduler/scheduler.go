@@ -0,0 +1,83 @@
+// Package scheduler runs periodic background jobs inside the app process.
+// Every run is logged and counted in expvar under "scheduler", served at /debug/vars.
+package scheduler
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"time"
+)
+
+var metrics = expvar.NewMap("scheduler")
+
+// JobFunc does one run of a job and returns how many records it handled
+type JobFunc func(ctx context.Context) (int64, error)
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      JobFunc
+	stats    *expvar.Map
+}
+
+type Scheduler struct {
+	jobs []*job
+}
+
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Add registers a job; call it before Start
+func (s *Scheduler) Add(name string, interval time.Duration, run JobFunc) {
+	stats := new(expvar.Map).Init()
+	metrics.Set(name, stats)
+	s.jobs = append(s.jobs, &job{name: name, interval: interval, run: run, stats: stats})
+}
+
+// Start runs every job on its own ticker until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, j := range s.jobs {
+		go j.loop(ctx)
+	}
+}
+
+func (j *job) loop(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			j.once(ctx)
+		}
+	}
+}
+
+func (j *job) once(ctx context.Context) {
+	started := time.Now()
+	processed, err := j.run(ctx)
+	elapsed := time.Since(started)
+
+	j.stats.Add("runs", 1)
+	j.stats.Add("processed", processed)
+	j.stats.Set("last_duration_ms", intVar(elapsed.Milliseconds()))
+	j.stats.Set("last_processed", intVar(processed))
+	j.stats.Set("last_run_unix", intVar(started.Unix()))
+
+	if err != nil {
+		j.stats.Add("failures", 1)
+		log.Printf("Scheduler: %s failed after %s: %v", j.name, elapsed, err)
+		return
+	}
+	log.Printf("Scheduler: %s processed %d in %s", j.name, processed, elapsed)
+}
+
+func intVar(v int64) *expvar.Int {
+	i := new(expvar.Int)
+	i.Set(v)
+	return i
+}
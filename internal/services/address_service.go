@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"go-app-marketplace/internal/usecases"
+	"go-app-marketplace/pkg/domain"
+	"go-app-marketplace/pkg/reqresp"
+	"strings"
+)
+
+type AddressService struct {
+	usecase *usecases.AddressUsecase
+}
+
+func NewAddressService(uc *usecases.AddressUsecase) *AddressService {
+	return &AddressService{usecase: uc}
+}
+
+func (s *AddressService) List(ctx context.Context, userID int64) ([]reqresp.AddressResponse, error) {
+	addresses, err := s.usecase.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]reqresp.AddressResponse, 0, len(addresses))
+	for i := range addresses {
+		resp = append(resp, toAddressResponse(&addresses[i]))
+	}
+	return resp, nil
+}
+
+func (s *AddressService) Get(ctx context.Context, userID, id int64) (*reqresp.AddressResponse, error) {
+	address, err := s.usecase.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := toAddressResponse(address)
+	return &resp, nil
+}
+
+func (s *AddressService) Create(ctx context.Context, userID int64, req *reqresp.AddressRequest) (*reqresp.AddressResponse, error) {
+	address, err := s.usecase.Create(ctx, fromAddressRequest(userID, 0, req))
+	if err != nil {
+		return nil, err
+	}
+	resp := toAddressResponse(address)
+	return &resp, nil
+}
+
+func (s *AddressService) Update(ctx context.Context, userID, id int64, req *reqresp.AddressRequest) (*reqresp.AddressResponse, error) {
+	address, err := s.usecase.Update(ctx, fromAddressRequest(userID, id, req))
+	if err != nil {
+		return nil, err
+	}
+	resp := toAddressResponse(address)
+	return &resp, nil
+}
+
+func (s *AddressService) SetDefault(ctx context.Context, userID, id int64) error {
+	return s.usecase.SetDefault(ctx, userID, id)
+}
+
+func (s *AddressService) Delete(ctx context.Context, userID, id int64) error {
+	return s.usecase.Delete(ctx, userID, id)
+}
+
+func fromAddressRequest(userID, id int64, req *reqresp.AddressRequest) *domain.UserAddress {
+	return &domain.UserAddress{
+		ID:         id,
+		UserID:     userID,
+		FullName:   strings.TrimSpace(req.FullName),
+		Phone:      strings.TrimSpace(req.Phone),
+		Line1:      strings.TrimSpace(req.Line1),
+		Line2:      strings.TrimSpace(req.Line2),
+		City:       strings.TrimSpace(req.City),
+		Region:     strings.TrimSpace(req.Region),
+		PostalCode: strings.TrimSpace(req.PostalCode),
+		Country:    strings.ToUpper(strings.TrimSpace(req.Country)),
+		IsDefault:  req.IsDefault,
+	}
+}
+
+func toAddressResponse(a *domain.UserAddress) reqresp.AddressResponse {
+	return reqresp.AddressResponse{
+		ID:         a.ID,
+		FullName:   a.FullName,
+		Phone:      a.Phone,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+		IsDefault:  a.IsDefault,
+		CreatedAt:  a.CreatedAt,
+		UpdatedAt:  a.UpdatedAt,
+	}
+}
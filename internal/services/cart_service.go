@@ -7,6 +7,7 @@ import (
 	"go-app-marketplace/internal/redisdb"
 	"go-app-marketplace/internal/usecases"
 	"go-app-marketplace/pkg/domain"
+	"strings"
 	"time"
 )
 
@@ -39,7 +40,9 @@ func (s *CartService) AddItem(ctx context.Context, userID, offerID int64, quanti
 	return err
 }
 
-func (s *CartService) GetCart(ctx context.Context, userID int64) ([]domain.CartItem, error) {
+// GetCart returns the cart with its prices also in the display currency.
+// Only the items are cached, the prices follow the current exchange rates.
+func (s *CartService) GetCart(ctx context.Context, userID int64, currency string) ([]domain.CartItem, error) {
 	key := fmt.Sprintf("cart:%d", userID)
 	
 	cart, err := redisdb.CacheGetOrSet(ctx,key, 2*time.Minute, func() ([]domain.CartItem, error) {
@@ -48,6 +51,13 @@ func (s *CartService) GetCart(ctx context.Context, userID int64) ([]domain.CartI
 	if err != nil{
 		return cart, err
 	}
+
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+	if err := s.usecase.PriceIn(ctx, cart, strings.ToUpper(currency)); err != nil {
+		return nil, err
+	}
 	return cart, nil
 }
 
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"go-app-marketplace/internal/redisdb"
+	"go-app-marketplace/internal/usecases"
+	"go-app-marketplace/pkg/domain"
+	"go-app-marketplace/pkg/reqresp"
+	"strings"
+	"time"
+)
+
+const categoryTreeCacheKey = "categories:tree"
+
+type CategoryService struct {
+	usecase *usecases.CategoryUsecase
+}
+
+func NewCategoryService(uc *usecases.CategoryUsecase) *CategoryService {
+	return &CategoryService{usecase: uc}
+}
+
+func (s *CategoryService) List(ctx context.Context) ([]domain.Category, error) {
+	return s.usecase.List(ctx)
+}
+
+// Tree is cached for a few minutes, so product counts may lag behind new and
+// archived products for that long
+func (s *CategoryService) Tree(ctx context.Context) ([]*domain.CategoryNode, error) {
+	return redisdb.CacheGetOrSet(ctx, categoryTreeCacheKey, 5*time.Minute, func() ([]*domain.CategoryNode, error) {
+		return s.usecase.Tree(ctx)
+	})
+}
+
+func (s *CategoryService) GetBySlug(ctx context.Context, slug string) (domain.Category, error) {
+	return s.usecase.GetBySlug(ctx, strings.ToLower(slug))
+}
+
+func (s *CategoryService) Create(ctx context.Context, req reqresp.CategoryRequest) (domain.Category, error) {
+	category, err := s.usecase.Create(ctx, fromCategoryRequest(0, req))
+	if err != nil {
+		return domain.Category{}, err
+	}
+	s.invalidate(ctx)
+	return category, nil
+}
+
+func (s *CategoryService) Update(ctx context.Context, id int64, req reqresp.CategoryRequest) (domain.Category, error) {
+	category, err := s.usecase.Update(ctx, fromCategoryRequest(id, req))
+	if err != nil {
+		return domain.Category{}, err
+	}
+	s.invalidate(ctx)
+	return category, nil
+}
+
+func (s *CategoryService) Delete(ctx context.Context, id int64) error {
+	if err := s.usecase.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.invalidate(ctx)
+	return nil
+}
+
+func (s *CategoryService) ListByProduct(ctx context.Context, productID int64) ([]domain.Category, error) {
+	return s.usecase.ListByProduct(ctx, productID)
+}
+
+func (s *CategoryService) Breadcrumb(ctx context.Context, productID int64) ([]domain.Category, error) {
+	return s.usecase.Breadcrumb(ctx, productID)
+}
+
+func (s *CategoryService) SetProductCategories(ctx context.Context, productID int64, categoryIDs []int64) error {
+	if err := s.usecase.SetProductCategories(ctx, productID, categoryIDs); err != nil {
+		return err
+	}
+	s.invalidate(ctx)
+	return nil
+}
+
+// invalidate drops the cached tree, whose shape or counts just changed
+func (s *CategoryService) invalidate(ctx context.Context) {
+	_ = redisdb.Rdb.Del(ctx, categoryTreeCacheKey)
+}
+
+// fromCategoryRequest trims the request and derives the slug from the name
+// when none is given
+func fromCategoryRequest(id int64, req reqresp.CategoryRequest) domain.Category {
+	name := strings.TrimSpace(req.Name)
+	slug := strings.ToLower(strings.TrimSpace(req.Slug))
+	if slug == "" {
+		slug = domain.Slugify(name)
+	}
+	return domain.Category{
+		ID:       id,
+		ParentID: req.ParentID,
+		Name:     name,
+		Slug:     slug,
+		Position: req.Position,
+	}
+}
+
+func (s *CategoryService) ListAttributes(ctx context.Context, categoryID int64) ([]domain.CategoryAttribute, error) {
+	return s.usecase.ListAttributes(ctx, categoryID)
+}
+
+func (s *CategoryService) CreateAttribute(ctx context.Context, categoryID int64, req reqresp.AttributeRequest) (domain.CategoryAttribute, error) {
+	return s.usecase.CreateAttribute(ctx, fromAttributeRequest(categoryID, 0, req))
+}
+
+func (s *CategoryService) UpdateAttribute(ctx context.Context, categoryID, id int64, req reqresp.AttributeRequest) (domain.CategoryAttribute, error) {
+	return s.usecase.UpdateAttribute(ctx, fromAttributeRequest(categoryID, id, req))
+}
+
+func (s *CategoryService) DeleteAttribute(ctx context.Context, categoryID, id int64) error {
+	return s.usecase.DeleteAttribute(ctx, categoryID, id)
+}
+
+// fromAttributeRequest trims the request and lower-cases the code
+func fromAttributeRequest(categoryID, id int64, req reqresp.AttributeRequest) domain.CategoryAttribute {
+	options := make(domain.AttributeOptions, 0, len(req.Options))
+	for _, o := range req.Options {
+		options = append(options, strings.TrimSpace(o))
+	}
+	return domain.CategoryAttribute{
+		ID:         id,
+		CategoryID: categoryID,
+		Code:       strings.ToLower(strings.TrimSpace(req.Code)),
+		Name:       strings.TrimSpace(req.Name),
+		Type:       domain.AttributeType(req.Type),
+		Options:    options,
+		Position:   req.Position,
+	}
+}
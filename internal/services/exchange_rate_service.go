@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"go-app-marketplace/internal/redisdb"
+	"go-app-marketplace/internal/usecases"
+	"go-app-marketplace/pkg/domain"
+	"strings"
+	"time"
+)
+
+const exchangeRatesCacheKey = "exchange_rates"
+
+type ExchangeRateService struct {
+	usecase *usecases.ExchangeRateUsecase
+}
+
+func NewExchangeRateService(uc *usecases.ExchangeRateUsecase) *ExchangeRateService {
+	return &ExchangeRateService{usecase: uc}
+}
+
+func (s *ExchangeRateService) List(ctx context.Context) ([]domain.ExchangeRate, error) {
+	return redisdb.CacheGetOrSet(ctx, exchangeRatesCacheKey, 5*time.Minute, func() ([]domain.ExchangeRate, error) {
+		return s.usecase.List(ctx)
+	})
+}
+
+func (s *ExchangeRateService) Set(ctx context.Context, base, quote, rate string) (domain.ExchangeRate, error) {
+	saved, err := s.usecase.Set(ctx, domain.ExchangeRate{
+		Base:  strings.ToUpper(base),
+		Quote: strings.ToUpper(quote),
+		Rate:  strings.TrimSpace(rate),
+	})
+	if err != nil {
+		return domain.ExchangeRate{}, err
+	}
+	_ = redisdb.Rdb.Del(ctx, exchangeRatesCacheKey)
+	return saved, nil
+}
+
+func (s *ExchangeRateService) Delete(ctx context.Context, base, quote string) error {
+	if err := s.usecase.Delete(ctx, strings.ToUpper(base), strings.ToUpper(quote)); err != nil {
+		return err
+	}
+	_ = redisdb.Rdb.Del(ctx, exchangeRatesCacheKey)
+	return nil
+}
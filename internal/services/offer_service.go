@@ -6,6 +6,7 @@ import (
 	"go-app-marketplace/internal/redisdb"
 	"go-app-marketplace/internal/usecases"
 	"go-app-marketplace/pkg/domain"
+	"strings"
 	"time"
 )
 
@@ -17,9 +18,10 @@ func NewOfferService(uc *usecases.OfferUseCase) *OfferService {
 	return &OfferService{usecase: uc}
 }
 
-func (s *OfferService) CreateOffer(ctx context.Context, productID, sellerID int64, price float64, stock int, isAvailable bool) (int64, error) {
+func (s *OfferService) CreateOffer(ctx context.Context, productID, variantID, sellerID int64, price domain.Money, stock int, isAvailable bool) (int64, error) {
 	offer := &domain.Offer{
 		ProductID:   productID,
+		VariantID:   variantID,
 		SellerID:    sellerID,
 		Price:       price,
 		Stock:       stock,
@@ -31,8 +33,7 @@ func (s *OfferService) CreateOffer(ctx context.Context, productID, sellerID int6
 	}
 
 	// Очистка кэша списка офферов по продукту
-	key := fmt.Sprintf("offers:product:%d", productID)
-	_ = redisdb.Rdb.Del(ctx, key)
+	_ = redisdb.Rdb.Del(ctx, offersByProductKeys(offer.ProductID)...)
 
 	return id, nil
 }
@@ -50,11 +51,18 @@ func (s *OfferService) GetOfferByID(ctx context.Context, id int64) (*domain.Offe
 	return offer, nil
 }
 
-func (s *OfferService) ListOffersByProduct(ctx context.Context, productID int64) ([]*domain.Offer, error) {
-	key := fmt.Sprintf("offers:product:%d", productID)
+func (s *OfferService) ListOffersByProduct(ctx context.Context, productID int64, currency string) ([]*domain.Offer, error) {
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+	currency = strings.ToUpper(currency)
+	if !domain.IsSupportedCurrency(currency) {
+		return nil, domain.ErrUnsupportedCurrency
+	}
+	key := offersByProductKey(productID, currency)
 
 	offers, err := redisdb.CacheGetOrSet(ctx, key, 2*time.Minute, func() ([]*domain.Offer, error) {
-		return s.usecase.ListOffersByProduct(ctx, productID)
+		return s.usecase.ListOffersByProduct(ctx, productID, currency)
 	})
 
 	if err != nil {
@@ -64,7 +72,7 @@ func (s *OfferService) ListOffersByProduct(ctx context.Context, productID int64)
 	return offers, nil
 }
 
-func (s *OfferService) UpdateOffer(ctx context.Context, id, sellerID int64, price float64, stock int, isAvailable bool) error {
+func (s *OfferService) UpdateOffer(ctx context.Context, id, sellerID int64, price domain.Money, stock int, isAvailable bool) error {
 	offer := &domain.Offer{
 		ID:          id,
 		SellerID:    sellerID,
@@ -98,3 +106,18 @@ func (s *OfferService) DeleteOffer(ctx context.Context, id, sellerID int64) erro
 func (s *OfferService) ListOffersBySeller(ctx context.Context, sellerID int64) ([]*domain.Offer, error) {
 	return s.usecase.ListOffersBySeller(ctx, sellerID)
 }
+
+// offersByProductKey caches the product's offers priced in one display currency
+func offersByProductKey(productID int64, currency string) string {
+	return fmt.Sprintf("offers:product:%d:%s", productID, currency)
+}
+
+// offersByProductKeys lists the cache keys of the product's offers in every currency
+func offersByProductKeys(productID int64) []string {
+	currencies := domain.SupportedCurrencies()
+	keys := make([]string, 0, len(currencies))
+	for _, c := range currencies {
+		keys = append(keys, offersByProductKey(productID, c))
+	}
+	return keys
+}
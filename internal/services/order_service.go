@@ -2,23 +2,37 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"go-app-marketplace/internal/redisdb"
+	"go-app-marketplace/internal/repositories"
 	"go-app-marketplace/internal/usecases"
+	"go-app-marketplace/pkg/auth"
 	"go-app-marketplace/pkg/domain"
 	"go-app-marketplace/pkg/reqresp"
+	"log"
+	"strconv"
 	"time"
 )
 
+// ErrOrderPaymentInFlight means the buyer paid while the order was being
+// cancelled; it can be cancelled once the payment is confirmed
+var ErrOrderPaymentInFlight = errors.New("order was just paid, cancel it again once the payment is confirmed")
+
 type OrderService struct {
 	orderUsecase   *usecases.OrderUsecase
 	paymentService *PaymentService
+	refundService  *RefundService
+	quoteSecret    []byte
+	quoteTTL       time.Duration
 }
 
-func NewOrderService(orderUsecase *usecases.OrderUsecase) *OrderService {
+func NewOrderService(orderUsecase *usecases.OrderUsecase, quoteSecret string, quoteTTL time.Duration) *OrderService {
 	return &OrderService{
 		orderUsecase: orderUsecase,
+		quoteSecret:  []byte(quoteSecret),
+		quoteTTL:     quoteTTL,
 	}
 }
 
@@ -27,17 +41,45 @@ func (s *OrderService) SetPaymentService(paymentService *PaymentService) {
 	s.paymentService = paymentService
 }
 
-func (s *OrderService) Checkout(ctx context.Context, userID int64) (*reqresp.CheckoutResponse, error) {
-	orderID, totalAmount, err := s.orderUsecase.Checkout(ctx, userID)
+// SetRefundService sets the refund service that pays back cancelled items
+func (s *OrderService) SetRefundService(refundService *RefundService) {
+	s.refundService = refundService
+}
+
+// Checkout places the order and opens its payment session. With a quote token
+// from Quote the order is held to the quoted prices.
+func (s *OrderService) Checkout(ctx context.Context, userID, addressID int64, currency string, shipping []reqresp.ShippingChoiceRequest, quoteToken string) (*reqresp.CheckoutResponse, error) {
+	var quote *usecases.Quote
+	if quoteToken != "" {
+		var err error
+		if quote, err = s.parseQuote(quoteToken, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	order, err := s.orderUsecase.Checkout(ctx, userID, addressID, currency, toShippingChoices(shipping), quote)
+	if err != nil {
+		return nil, err
+	}
+	orderID := order.ID
+	// The cart was emptied inside the checkout transaction, not through
+	// CartService, so its cached copy has to be dropped here
+	_ = redisdb.Rdb.Del(ctx, fmt.Sprintf("cart:%d", userID))
+
+	lines, err := s.orderUsecase.ListOrderLines(ctx, orderID)
 	if err != nil {
 		return nil, err
 	}
 
 	session, err := s.paymentService.CreateCheckoutSession(
+		ctx,
 		orderID,
-		totalAmount,
+		order.TotalAmount,
+		order.TaxAmount,
+		lines,
 		"https://localhost/payment-success",
 		"https://localhost/payment-cancel",
+		s.orderUsecase.ReservationDeadline(),
 	)
 	if err != nil {
 		return nil, err
@@ -45,55 +87,222 @@ func (s *OrderService) Checkout(ctx context.Context, userID int64) (*reqresp.Che
 
 	return &reqresp.CheckoutResponse{
 		OrderID:     orderID,
-		TotalAmount: totalAmount,
+		TotalAmount: order.TotalAmount,
 		PaymentURL:  session.URL,
 	}, nil
 }
 
-func (s *OrderService) GetOrderByID(ctx context.Context, userID, orderID int64) (*reqresp.OrderResponse, error) {
-	key := fmt.Sprintf("order:%d", orderID)
+// PreviewCheckout shows what checking out the cart would charge, per seller
+func (s *OrderService) PreviewCheckout(ctx context.Context, userID, addressID int64, currency string, shipping []reqresp.ShippingChoiceRequest) (*reqresp.CheckoutPreviewResponse, error) {
+	plan, err := s.orderUsecase.PreviewCheckout(ctx, userID, addressID, currency, toShippingChoices(shipping))
+	if err != nil {
+		return nil, err
+	}
 
-	// Кэшируем структуру OrderResponse
-	cachedOrder, err := redisdb.CacheGetOrSet(ctx, key, 5*time.Minute, func() (*reqresp.OrderResponse, error) {
-		order, items, err := s.orderUsecase.GetOrderByID(ctx, orderID)
-		if err != nil {
-			return nil, err
+	return toCheckoutPreviewResponse(plan)
+}
+
+// Quote revalidates the cart against the current offers and prices what can
+// be bought. Unless something keeps the cart from checking out, it comes with
+// a token that holds checkout to these prices until it expires.
+func (s *OrderService) Quote(ctx context.Context, userID, addressID int64, currency string, shipping []reqresp.ShippingChoiceRequest) (*reqresp.CheckoutQuoteResponse, error) {
+	plan, err := s.orderUsecase.QuoteCheckout(ctx, userID, addressID, currency, toShippingChoices(shipping))
+	if err != nil {
+		return nil, err
+	}
+
+	preview, err := toCheckoutPreviewResponse(plan)
+	if err != nil {
+		return nil, err
+	}
+	resp := &reqresp.CheckoutQuoteResponse{
+		CheckoutPreviewResponse: *preview,
+		DiscountAmount:          domain.NewMoney(0, plan.Order.Currency),
+		Issues:                  make([]reqresp.CartIssueResponse, 0, len(plan.Issues)),
+	}
+
+	blocked := len(plan.Items) == 0
+	for _, issue := range plan.Issues {
+		blocked = blocked || issue.Blocking()
+		ir := reqresp.CartIssueResponse{OfferID: issue.OfferID, Reason: string(issue.Reason)}
+		switch issue.Reason {
+		case usecases.CartIssueInsufficientStock:
+			ir.Available = &issue.Stock
+		case usecases.CartIssuePriceChanged:
+			previous, current := issue.Previous, issue.Current
+			ir.PreviousPrice, ir.CurrentPrice = &previous, &current
 		}
+		resp.Issues = append(resp.Issues, ir)
+	}
+	if blocked {
+		return resp, nil
+	}
 
-		if order.UserID != userID {
-			return nil, errors.New("order not found or access denied")
+	claims := auth.QuoteClaims{
+		UserID:    userID,
+		AddressID: addressID,
+		Currency:  plan.Order.Currency,
+		Total:     plan.Order.TotalAmount.Amount,
+	}
+	for _, item := range plan.Items {
+		claims.Items = append(claims.Items, auth.QuoteItem{
+			OfferID: item.OfferID, Quantity: item.Quantity, UnitPrice: item.UnitPrice.Amount,
+		})
+	}
+	for _, line := range plan.Shipping {
+		if line.ProfileID != nil {
+			claims.Shipping = append(claims.Shipping, auth.QuoteShipping{SellerID: line.SellerID, ProfileID: *line.ProfileID})
 		}
+	}
+	expiresAt := time.Now().Add(s.quoteTTL)
+	if resp.QuoteToken, err = auth.GenerateQuoteToken(claims, expiresAt, s.quoteSecret); err != nil {
+		return nil, err
+	}
+	resp.ExpiresAt = &expiresAt
 
-		var itemResponses []reqresp.OrderItemResponse
-		for _, item := range items {
-			itemResponses = append(itemResponses, reqresp.OrderItemResponse{
-				ID:        item.ID,
+	return resp, nil
+}
+
+// parseQuote verifies a quote token issued to the user
+func (s *OrderService) parseQuote(token string, userID int64) (*usecases.Quote, error) {
+	claims, err := auth.ParseQuoteToken(token, s.quoteSecret)
+	if err != nil {
+		return nil, err
+	}
+	if claims.UserID != userID {
+		return nil, auth.ErrInvalidQuoteToken
+	}
+
+	quote := &usecases.Quote{
+		ID:         claims.ID,
+		AddressID:  claims.AddressID,
+		Currency:   claims.Currency,
+		Total:      domain.NewMoney(claims.Total, claims.Currency),
+		Quantities: make(map[int64]int, len(claims.Items)),
+		Prices:     make(map[int64]domain.Money, len(claims.Items)),
+		Shipping:   make(usecases.ShippingChoices, len(claims.Shipping)),
+	}
+	for _, item := range claims.Items {
+		quote.Quantities[item.OfferID] = item.Quantity
+		quote.Prices[item.OfferID] = domain.NewMoney(item.UnitPrice, claims.Currency)
+	}
+	for _, s := range claims.Shipping {
+		quote.Shipping[s.SellerID] = s.ProfileID
+	}
+	return quote, nil
+}
+
+// toShippingChoices keys the buyer's shipping choices by seller
+func toShippingChoices(req []reqresp.ShippingChoiceRequest) usecases.ShippingChoices {
+	choices := make(usecases.ShippingChoices, len(req))
+	for _, c := range req {
+		choices[c.SellerID] = c.ProfileID
+	}
+	return choices
+}
+
+// toCheckoutPreviewResponse breaks the plan down by seller
+func toCheckoutPreviewResponse(plan *usecases.CheckoutPlan) (*reqresp.CheckoutPreviewResponse, error) {
+	var err error
+	resp := &reqresp.CheckoutPreviewResponse{
+		Currency:        plan.Order.Currency,
+		Subtotal:        plan.Subtotal,
+		ShippingAmount:  plan.Order.ShippingAmount,
+		TaxAmount:       plan.Order.TaxAmount,
+		TotalAmount:     plan.Order.TotalAmount,
+		ExchangeRates:   plan.Order.ExchangeRates,
+		ShippingAddress: plan.Order.ShippingAddress,
+	}
+
+	// Shipping lines come one per seller, in the order the sellers appear in the cart
+	for _, line := range plan.Shipping {
+		seller := reqresp.CheckoutPreviewSeller{
+			SellerID:        line.SellerID,
+			Subtotal:        domain.NewMoney(0, plan.Order.Currency),
+			TaxAmount:       domain.NewMoney(0, plan.Order.Currency),
+			Shipping:        toShippingLineResponse(line),
+			ShippingOptions: toShippingLineResponses(plan.ShippingOptions[line.SellerID]),
+		}
+		for _, item := range plan.Items {
+			if item.SellerID != line.SellerID {
+				continue
+			}
+			seller.Items = append(seller.Items, reqresp.CheckoutPreviewItem{
 				OfferID:   item.OfferID,
 				ProductID: item.ProductID,
-				SellerID:  item.SellerID,
 				Quantity:  item.Quantity,
 				UnitPrice: item.UnitPrice,
-				Status:    string(item.Status),
+				Tax:       toItemTaxResponse(item),
 			})
+			if seller.Subtotal, err = seller.Subtotal.Add(item.UnitPrice.Mul(int64(item.Quantity))); err != nil {
+				return nil, err
+			}
+			if seller.TaxAmount, err = seller.TaxAmount.Add(item.TaxAmount); err != nil {
+				return nil, err
+			}
 		}
+		resp.Sellers = append(resp.Sellers, seller)
+	}
 
-		resp := &reqresp.OrderResponse{
-			ID:            order.ID,
-			UserID:        order.UserID,
-			TotalAmount:   order.TotalAmount,
-			Status:        string(order.Status),
-			PaymentStatus: string(order.PaymentStatus),
-			Items:         itemResponses,
-		}
+	return resp, nil
+}
 
-		return resp, nil
-	})
+// GetOrderByID reads the order fresh on every call: shipments, cancellations
+// and payment events change it, and a cached copy would show the buyer an
+// outdated status or total until it expires.
+func (s *OrderService) GetOrderByID(ctx context.Context, userID, orderID int64) (*reqresp.OrderResponse, error) {
+	order, items, err := s.orderUsecase.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.UserID != userID {
+		return nil, usecases.ErrOrderAccessDenied
+	}
 
+	shipping, err := s.orderUsecase.ListShippingLines(ctx, orderID)
 	if err != nil {
 		return nil, err
 	}
 
-	return cachedOrder, nil
+	shipments, shipmentItems, err := s.orderUsecase.ListShipments(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	var itemResponses []reqresp.OrderItemResponse
+	for _, item := range items {
+		itemResponses = append(itemResponses, reqresp.OrderItemResponse{
+			ID:                 item.ID,
+			OfferID:            item.OfferID,
+			ProductID:          item.ProductID,
+			SellerID:           item.SellerID,
+			Quantity:           item.Quantity,
+			UnitPrice:          item.UnitPrice,
+			Status:             string(item.Status),
+			Tax:                toItemTaxResponse(item),
+			ShipmentID:         item.ShipmentID,
+			CancellationReason: item.CancellationReason,
+			VariantID:          item.VariantID,
+			SKU:                item.SKU,
+			Attributes:         item.Attributes,
+		})
+	}
+
+	return &reqresp.OrderResponse{
+		ID:              order.ID,
+		UserID:          order.UserID,
+		TotalAmount:     order.TotalAmount,
+		Status:          string(order.Status),
+		PaymentStatus:   string(order.PaymentStatus),
+		Items:           itemResponses,
+		ExchangeRates:   order.ExchangeRates,
+		ShippingAddress: order.ShippingAddress,
+		ShippingAmount:  order.ShippingAmount,
+		Shipping:        toShippingLineResponses(shipping),
+		TaxAmount:       order.TaxAmount,
+		Shipments:       toShipmentResponses(shipments, shipmentItems),
+	}, nil
 }
 
 func (s *OrderService) CheckoutExistingOrder(ctx context.Context, userID, orderID int64) (*reqresp.CheckoutResponse, error) {
@@ -107,17 +316,32 @@ func (s *OrderService) CheckoutExistingOrder(ctx context.Context, userID, orderI
 		return nil, errors.New("order not found or access denied")
 	}
 
-	// Check if payment is pending
-	if order.PaymentStatus != domain.PaymentStatusPending {
+	// Only unpaid orders, including ones whose last attempt was declined, can be paid again
+	if order.Status != domain.OrderStatusPending || !order.PaymentStatus.CanTransitionTo(domain.PaymentStatusSuccessful) {
 		return nil, errors.New("order payment already processed")
 	}
 
+	// Keep the stock on hold for as long as the new session lives
+	deadline := s.orderUsecase.ReservationDeadline()
+	if err := s.orderUsecase.ExtendReservation(ctx, orderID, deadline); err != nil {
+		return nil, err
+	}
+
+	lines, err := s.orderUsecase.ListOrderLines(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create a new checkout session for the existing order
 	session, err := s.paymentService.CreateCheckoutSession(
+		ctx,
 		orderID,
 		order.TotalAmount,
+		order.TaxAmount,
+		lines,
 		"https://localhost/payment-success",
 		"https://localhost/payment-cancel",
+		deadline,
 	)
 
 	if err != nil {
@@ -131,8 +355,177 @@ func (s *OrderService) CheckoutExistingOrder(ctx context.Context, userID, orderI
 	}, nil
 }
 
-func (s *OrderService) CancelOrderItem(ctx context.Context, userID, itemID int64) error {
-	return s.orderUsecase.CancelOrderItem(ctx, userID, itemID)
+// Timeline is the order's status history as the user may see it
+func (s *OrderService) Timeline(ctx context.Context, userID int64, role domain.UserRole, orderID int64) ([]reqresp.OrderEventResponse, error) {
+	events, err := s.orderUsecase.Timeline(ctx, userID, role, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]reqresp.OrderEventResponse, 0, len(events))
+	for _, e := range events {
+		resp = append(resp, reqresp.OrderEventResponse{
+			Entity:     string(e.Entity),
+			EntityID:   e.EntityID,
+			SellerID:   e.SellerID,
+			FromStatus: e.FromStatus,
+			ToStatus:   e.ToStatus,
+			ActorRole:  e.ActorRole,
+			ActorID:    e.ActorID,
+			CreatedAt:  e.CreatedAt,
+		})
+	}
+	return resp, nil
+}
+
+// CancelOrderItem cancels a pending item of the buyer's order and refunds it when the order is paid
+func (s *OrderService) CancelOrderItem(ctx context.Context, userID, itemID int64) (*reqresp.CancelOrderItemResponse, error) {
+	owns := func(item *domain.OrderItem) bool { return item.OrderUserID == userID }
+	if err := s.expireCheckoutsOfItem(ctx, itemID, owns); err != nil {
+		return nil, err
+	}
+
+	cancellation, err := s.orderUsecase.CancelOrderItem(ctx, userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return s.settleCancellation(ctx, cancellation)
+}
+
+// SellerCancelOrderItem cancels one of the seller's unshipped items and refunds it when the order is paid
+func (s *OrderService) SellerCancelOrderItem(ctx context.Context, sellerID, itemID int64, reason string) (*reqresp.CancelOrderItemResponse, error) {
+	owns := func(item *domain.OrderItem) bool { return item.SellerID == sellerID }
+	if err := s.expireCheckoutsOfItem(ctx, itemID, owns); err != nil {
+		return nil, err
+	}
+
+	cancellation, err := s.orderUsecase.SellerCancelOrderItem(ctx, sellerID, itemID, reason)
+	if err != nil {
+		return nil, err
+	}
+	return s.settleCancellation(ctx, cancellation)
+}
+
+// expireCheckoutsOfItem expires the open checkout sessions of the item's
+// order while it is unpaid, as CancelOrder does, so the buyer cannot pay the
+// total from before the item was cancelled. The buyer checks out again for
+// the new total. An item owns rejects is reported as not found.
+func (s *OrderService) expireCheckoutsOfItem(ctx context.Context, itemID int64, owns func(item *domain.OrderItem) bool) error {
+	item, err := s.orderUsecase.GetOrderItem(ctx, itemID)
+	if err != nil {
+		return err
+	}
+	if !owns(item) {
+		return repositories.ErrOrderItemNotFound
+	}
+
+	order, _, err := s.orderUsecase.GetOrderByID(ctx, item.OrderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repositories.ErrOrderNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if order.Status != domain.OrderStatusPending {
+		return nil
+	}
+
+	paid, err := s.paymentService.ExpireOpenCheckouts(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+	if paid {
+		return ErrOrderPaymentInFlight
+	}
+	return nil
+}
+
+// settleCancellation sends the refund of a cancelled item to the payment
+// provider. A refund the provider rejects stays failed for the seller to retry;
+// the response is returned together with ErrRefundPaymentFailed then.
+func (s *OrderService) settleCancellation(ctx context.Context, c *usecases.ItemCancellation) (*reqresp.CancelOrderItemResponse, error) {
+	resp := toCancelOrderItemResponse(c)
+	if c.RefundID == 0 {
+		return resp, nil
+	}
+	return resp, s.refundService.Execute(ctx, c.RefundID)
+}
+
+// CancelOrder cancels what the buyer may still cancel of the order. Open
+// checkout sessions of an unpaid order are expired at the provider first so it
+// can no longer be paid; the refunds of a paid order are sent to the provider.
+// A refund the provider rejects is flagged on its item and left failed for the
+// seller to retry.
+func (s *OrderService) CancelOrder(ctx context.Context, userID, orderID int64) (*reqresp.CancelOrderResponse, error) {
+	order, _, err := s.orderUsecase.GetOrderByID(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && order.UserID != userID) {
+		return nil, repositories.ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status == domain.OrderStatusPending {
+		paid, err := s.paymentService.ExpireOpenCheckouts(ctx, orderID)
+		if err != nil {
+			return nil, err
+		}
+		if paid {
+			return nil, ErrOrderPaymentInFlight
+		}
+	}
+
+	cancellation, err := s.orderUsecase.CancelOrder(ctx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &reqresp.CancelOrderResponse{
+		OrderID:       orderID,
+		Status:        string(cancellation.Order.Status),
+		PaymentStatus: string(cancellation.Order.PaymentStatus),
+		TotalAmount:   cancellation.Order.TotalAmount,
+		Cancelled:     []reqresp.CancelOrderItemResponse{},
+		Skipped:       []reqresp.SkippedItemResponse{},
+	}
+
+	for i := range cancellation.Cancelled {
+		c := &cancellation.Cancelled[i]
+		item := toCancelOrderItemResponse(c)
+		if c.RefundID != 0 {
+			if err := s.refundService.Execute(ctx, c.RefundID); err != nil {
+				log.Printf("Refund %d of cancelled order %d failed: %v", c.RefundID, orderID, err)
+				item.RefundFailed = true
+			}
+		}
+		resp.Cancelled = append(resp.Cancelled, *item)
+	}
+	for _, skipped := range cancellation.Skipped {
+		resp.Skipped = append(resp.Skipped, reqresp.SkippedItemResponse{
+			ItemID: skipped.ItemID,
+			Status: string(skipped.Status),
+			Reason: skipped.Reason,
+		})
+	}
+	return resp, nil
+}
+
+func toCancelOrderItemResponse(c *usecases.ItemCancellation) *reqresp.CancelOrderItemResponse {
+	resp := &reqresp.CancelOrderItemResponse{
+		ItemID:      c.ItemID,
+		OrderID:     c.Order.ID,
+		TotalAmount: c.Order.TotalAmount,
+	}
+	if c.RefundID != 0 {
+		resp.RefundID = &c.RefundID
+		resp.RefundAmount = &c.Refund
+	}
+	return resp
+}
+
+// ReleaseExpiredReservations returns stock held by unpaid orders past their deadline
+func (s *OrderService) ReleaseExpiredReservations(ctx context.Context) (int64, error) {
+	return s.orderUsecase.ReleaseExpiredReservations(ctx)
 }
 
 func (s *OrderService) ListOrders(ctx context.Context, userID int64) ([]reqresp.OrderResponse, error) {
@@ -149,46 +542,108 @@ func (s *OrderService) ListOrders(ctx context.Context, userID int64) ([]reqresp.
 			return nil, err
 		}
 
+		shipping, err := s.orderUsecase.ListShippingLines(ctx, order.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		shipments, shipmentItems, err := s.orderUsecase.ListShipments(ctx, order.ID)
+		if err != nil {
+			return nil, err
+		}
+
 		var itemResponses []reqresp.OrderItemResponse
 		for _, item := range items {
 			itemResponses = append(itemResponses, reqresp.OrderItemResponse{
-				ID:        item.ID,
-				OfferID:   item.OfferID,
-				ProductID: item.ProductID,
-				SellerID:  item.SellerID,
-				Quantity:  item.Quantity,
-				UnitPrice: item.UnitPrice,
-				Status:    string(item.Status),
+				ID:                 item.ID,
+				OfferID:            item.OfferID,
+				ProductID:          item.ProductID,
+				SellerID:           item.SellerID,
+				Quantity:           item.Quantity,
+				UnitPrice:          item.UnitPrice,
+				Status:             string(item.Status),
+				Tax:                toItemTaxResponse(item),
+				ShipmentID:         item.ShipmentID,
+				CancellationReason: item.CancellationReason,
+				VariantID:          item.VariantID,
+				SKU:                item.SKU,
+				Attributes:         item.Attributes,
 			})
 		}
 
 		resp = append(resp, reqresp.OrderResponse{
-			ID:            order.ID,
-			UserID:        order.UserID,
-			TotalAmount:   order.TotalAmount,
-			Status:        string(order.Status),
-			PaymentStatus: string(order.PaymentStatus),
-			Items:         itemResponses,
+			ID:              order.ID,
+			UserID:          order.UserID,
+			TotalAmount:     order.TotalAmount,
+			Status:          string(order.Status),
+			PaymentStatus:   string(order.PaymentStatus),
+			Items:           itemResponses,
+			ExchangeRates:   order.ExchangeRates,
+			ShippingAddress: order.ShippingAddress,
+			ShippingAmount:  order.ShippingAmount,
+			Shipping:        toShippingLineResponses(shipping),
+			TaxAmount:       order.TaxAmount,
+			Shipments:       toShipmentResponses(shipments, shipmentItems),
 		})
 	}
 
 	return resp, nil
 }
 
-func (s *OrderService) UpdatePaymentStatusByOrderID(ctx context.Context, orderIDStr string, status domain.PaymentStatus) error {
-	err := s.orderUsecase.UpdatePaymentStatusByOrderID(ctx, orderIDStr, status)
-	if err == nil {
-		_ = redisdb.Rdb.Del(ctx, fmt.Sprintf("order:%s", orderIDStr))
+func (s *OrderService) TransitionPayment(ctx context.Context, orderIDStr string, status domain.PaymentStatus) error {
+	orderID, err := strconv.ParseInt(orderIDStr, 10, 64)
+	if err != nil {
+		return err
 	}
+
+	return s.transitionPayment(ctx, orderID, status)
+}
+
+func (s *OrderService) transitionPayment(ctx context.Context, orderID int64, status domain.PaymentStatus) error {
+	return s.orderUsecase.TransitionPayment(ctx, orderID, status)
+}
+
+func (s *OrderService) TransitionPaymentByIntent(ctx context.Context, paymentIntentID string, status domain.PaymentStatus) error {
+	_, err := s.orderUsecase.TransitionPaymentByIntent(ctx, paymentIntentID, status)
 	return err
 }
 
-func (s *OrderService) SellerUpdateOrderItemStatus(
-	ctx context.Context,
-	sellerID, itemID int64,
-	status domain.OrderItemStatus,
-) error {
-	return s.orderUsecase.SellerUpdateOrderItemStatus(ctx, sellerID, itemID, status)
+// ExpireStalePendingOrders cancels orders left unpaid for longer than ttl: the
+// provider sessions are expired first so the buyer cannot pay afterwards, then
+// the order is cancelled and its stock released.
+func (s *OrderService) ExpireStalePendingOrders(ctx context.Context, ttl time.Duration, batchSize int) (int64, error) {
+	orderIDs, err := s.orderUsecase.ListStalePendingOrders(ctx, ttl, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var expired int64
+	for _, orderID := range orderIDs {
+		paid, err := s.paymentService.ExpireOpenCheckouts(ctx, orderID)
+		if err != nil {
+			log.Printf("Failed to expire checkout of order %d: %v", orderID, err)
+			continue
+		}
+		if paid {
+			// The payment webhook is on its way and will mark the order paid
+			continue
+		}
+
+		err = s.transitionPayment(ctx, orderID, domain.PaymentStatusExpired)
+		if errors.Is(err, domain.ErrInvalidTransition) {
+			continue
+		}
+		if err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// AttachPaymentIntent remembers the provider payment so it can be refunded later
+func (s *OrderService) AttachPaymentIntent(ctx context.Context, orderIDStr, paymentIntentID string) error {
+	return s.orderUsecase.AttachPaymentIntent(ctx, orderIDStr, paymentIntentID)
 }
 
 func (s *OrderService) ListSellerOrderItems(
@@ -197,3 +652,16 @@ func (s *OrderService) ListSellerOrderItems(
 ) ([]reqresp.SellerOrderItem, error) {
 	return s.orderUsecase.ListSellerOrderItems(ctx, sellerID)
 }
+
+// toItemTaxResponse describes the tax on an item, nil when no rate applied
+func toItemTaxResponse(item domain.OrderItem) *reqresp.ItemTaxResponse {
+	if item.TaxName == "" {
+		return nil
+	}
+	return &reqresp.ItemTaxResponse{
+		Name:      item.TaxName,
+		Rate:      item.TaxRate,
+		Inclusive: item.TaxInclusive,
+		Amount:    item.TaxAmount,
+	}
+}
@@ -1,53 +1,227 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"github.com/stripe/stripe-go/v82"
-	"github.com/stripe/stripe-go/v82/checkout/session"
-
+	"go-app-marketplace/internal/payments"
+	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/internal/usecases"
+	"go-app-marketplace/pkg/domain"
+	"log"
+	"net/http"
 	"strconv"
+	"strings"
+	"time"
 )
 
+// ErrCheckoutTotalMismatch means the order items do not add up to the stored order total
+var ErrCheckoutTotalMismatch = errors.New("order items do not match the order total")
+
 type PaymentService struct {
-	secretKey     string
-	webhookSecret string
-}
-
-func NewPaymentService(secretKey, webhookSecret string) *PaymentService {
-	stripe.Key = secretKey
-	return &PaymentService{
-		secretKey:     secretKey,
-		webhookSecret: webhookSecret,
-	}
-}
-
-func (p *PaymentService) GetWebhookSecret() string {
-	return p.webhookSecret
-}
-
-func (p *PaymentService) CreateCheckoutSession(orderID int64, amount float64, successURL, cancelURL string) (*stripe.CheckoutSession, error) {
-	params := &stripe.CheckoutSessionParams{
-		PaymentIntentData: &stripe.CheckoutSessionPaymentIntentDataParams{
-			Metadata: map[string]string{
-				"order_id": strconv.FormatInt(orderID, 10),
-			},
-		},
-		LineItems: []*stripe.CheckoutSessionLineItemParams{
-			{
-				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
-					Currency: stripe.String("usd"),
-					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
-						Name: stripe.String(fmt.Sprintf("Order #%d", orderID)),
-					},
-					UnitAmount: stripe.Int64(int64(amount * 100)),
-				},
-				Quantity: stripe.Int64(1),
-			},
-		},
-		Mode:               stripe.String(string(stripe.CheckoutSessionModePayment)),
-		SuccessURL:         stripe.String(successURL),
-		CancelURL:          stripe.String(cancelURL),
-		PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
-	}
-	return session.New(params)
+	gateway   payments.Gateway
+	paymentUC *usecases.PaymentUsecase
+}
+
+func NewPaymentService(gateway payments.Gateway, paymentUC *usecases.PaymentUsecase) *PaymentService {
+	return &PaymentService{gateway: gateway, paymentUC: paymentUC}
+}
+
+func (p *PaymentService) Gateway() payments.Gateway {
+	return p.gateway
+}
+
+func (p *PaymentService) ProviderName() string {
+	return p.gateway.Name()
+}
+
+// CreateCheckoutSession opens a provider session with one line item per order
+// line and records it as a new attempt in the payments ledger. The line items
+// must add up to the order total to the cent; tax is the part of it that is tax.
+func (p *PaymentService) CreateCheckoutSession(ctx context.Context, orderID int64, amount, tax domain.Money, lines []domain.OrderLine, successURL, cancelURL string, expiresAt time.Time) (*payments.CheckoutSession, error) {
+	currency := strings.ToLower(amount.Currency)
+
+	items := make([]payments.LineItem, 0, len(lines))
+	var sum domain.Money
+	for _, line := range lines {
+		subtotal := line.UnitPrice.Mul(int64(line.Quantity))
+		var err error
+		if sum, err = sum.Add(subtotal); err != nil {
+			return nil, err
+		}
+		items = append(items, payments.LineItem{
+			Name:       line.ProductName,
+			UnitAmount: line.UnitPrice.Amount,
+			Quantity:   int64(line.Quantity),
+		})
+	}
+	if len(items) == 0 || !sum.Equal(amount) {
+		return nil, fmt.Errorf("%w: items %s, order %s", ErrCheckoutTotalMismatch, sum, amount)
+	}
+
+	session, err := p.gateway.CreateCheckout(ctx, payments.CheckoutParams{
+		OrderID:    orderID,
+		Amount:     amount.Amount,
+		TaxAmount:  tax.Amount,
+		Currency:   currency,
+		LineItems:  items,
+		SuccessURL: successURL,
+		CancelURL:  cancelURL,
+		ExpiresAt:  expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.paymentUC.RecordCheckout(ctx, &domain.Payment{
+		OrderID:           orderID,
+		Provider:          p.gateway.Name(),
+		CheckoutSessionID: session.ID,
+		Amount:            amount,
+		Currency:          amount.Currency,
+		Status:            domain.PaymentAttemptOpen,
+		RawPayload:        session.Raw,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// RecordEvent stores a verified provider event on the matching ledger entry.
+// Events that do not change a payment attempt are ignored.
+func (p *PaymentService) RecordEvent(ctx context.Context, event *payments.Event) error {
+	var status domain.PaymentAttemptStatus
+	switch event.Type {
+	case payments.EventPaymentSucceeded, payments.EventCheckoutCompleted:
+		status = domain.PaymentAttemptSucceeded
+	case payments.EventPaymentFailed:
+		status = domain.PaymentAttemptFailed
+	case payments.EventCheckoutExpired:
+		status = domain.PaymentAttemptExpired
+	case payments.EventChargeRefunded:
+		// A partial refund keeps the attempt succeeded, only the payload is updated
+		status = domain.PaymentAttemptSucceeded
+		if event.AmountRefunded >= event.Amount {
+			status = domain.PaymentAttemptRefunded
+		}
+	default:
+		return nil
+	}
+
+	// charge.refunded carries no order metadata, the payment intent is enough
+	var orderID int64
+	if event.OrderID != "" {
+		id, err := strconv.ParseInt(event.OrderID, 10, 64)
+		if err != nil {
+			return err
+		}
+		orderID = id
+	}
+
+	return p.paymentUC.RecordEvent(ctx, orderID, event.SessionID, event.PaymentIntentID,
+		string(event.Type), status, event.Payload)
+}
+
+// RefundOrphanedPayment gives back in full a payment made after its order was
+// cancelled: the checkout was paid although the order and its stock are gone.
+// The attempt is flagged orphaned in the ledger and the refund is keyed on the
+// payment intent, so the two success events of one payment, or a replay,
+// refund it only once.
+func (p *PaymentService) RefundOrphanedPayment(ctx context.Context, event *payments.Event) error {
+	if event.PaymentIntentID == "" {
+		return fmt.Errorf("orphaned payment of order %s has no payment intent", event.OrderID)
+	}
+	orderID, err := strconv.ParseInt(event.OrderID, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	// Sessions opened before the ledger existed have nothing to flag
+	_, err = p.paymentUC.MarkOrphaned(ctx, orderID, event.SessionID, event.PaymentIntentID, string(event.Type), event.Payload)
+	if err != nil && !errors.Is(err, repositories.ErrPaymentNotFound) {
+		return err
+	}
+
+	refund, err := p.gateway.Refund(ctx, payments.RefundParams{
+		PaymentIntentID: event.PaymentIntentID,
+		Amount:          event.Amount,
+		Metadata:        map[string]string{"order_id": event.OrderID, "reason": "order_cancelled"},
+		IdempotencyKey:  "orphaned-payment-" + event.PaymentIntentID,
+	})
+	if err != nil {
+		return fmt.Errorf("refund orphaned payment %s of order %d: %w", event.PaymentIntentID, orderID, err)
+	}
+	log.Printf("Refunded orphaned payment %s of cancelled order %d: refund %s", event.PaymentIntentID, orderID, refund.ID)
+	return nil
+}
+
+// ExpireOpenCheckouts closes every open session of the order at the provider.
+// It returns paid=true when one of them turns out to be paid already, in which
+// case the order must not be expired.
+func (p *PaymentService) ExpireOpenCheckouts(ctx context.Context, orderID int64) (paid bool, err error) {
+	open, err := p.paymentUC.ListOpenAttempts(ctx, orderID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, attempt := range open {
+		if err := p.gateway.ExpireCheckout(ctx, attempt.CheckoutSessionID); err != nil {
+			status, statusErr := p.gateway.GetPaymentStatus(ctx, attempt.CheckoutSessionID)
+			if statusErr != nil {
+				return false, err
+			}
+			if status.Status == payments.SessionStatusPaid {
+				return true, nil
+			}
+			if status.Status == payments.SessionStatusOpen {
+				return false, err
+			}
+		}
+
+		if err := p.paymentUC.MarkExpired(ctx, attempt.ID); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// HasOpenCheckout reports whether the order still has a checkout session that can be paid
+func (p *PaymentService) HasOpenCheckout(ctx context.Context, orderID int64) (bool, error) {
+	return p.paymentUC.HasOpenAttempt(ctx, orderID)
+}
+
+func (p *PaymentService) ListOrderPayments(ctx context.Context, userID, orderID int64) ([]domain.Payment, error) {
+	return p.paymentUC.ListOrderPayments(ctx, userID, orderID)
+}
+
+func (p *PaymentService) ListPayments(ctx context.Context, status string, orderID int64, page, pageSize int) ([]domain.Payment, int64, error) {
+	return p.paymentUC.ListPayments(ctx, status, orderID, page, pageSize)
+}
+
+func (p *PaymentService) GetPayment(ctx context.Context, id int64) (*domain.Payment, []domain.PaymentTransition, error) {
+	return p.paymentUC.GetPayment(ctx, id)
+}
+
+// Refund returns money for a captured payment; amount is in minor units.
+// Calls with the same idempotency key issue one refund.
+func (p *PaymentService) Refund(ctx context.Context, paymentIntentID string, amount int64, metadata map[string]string, idempotencyKey string) (*payments.Refund, error) {
+	return p.gateway.Refund(ctx, payments.RefundParams{
+		PaymentIntentID: paymentIntentID,
+		Amount:          amount,
+		Metadata:        metadata,
+		IdempotencyKey:  idempotencyKey,
+	})
+}
+
+func (p *PaymentService) GetRefund(ctx context.Context, refundID string) (*payments.Refund, error) {
+	return p.gateway.GetRefund(ctx, refundID)
+}
+
+func (p *PaymentService) GetPaymentStatus(ctx context.Context, sessionID string) (*payments.PaymentStatus, error) {
+	return p.gateway.GetPaymentStatus(ctx, sessionID)
+}
+
+func (p *PaymentService) VerifyWebhook(payload []byte, header http.Header) (*payments.Event, error) {
+	return p.gateway.VerifyWebhook(payload, header)
 }
@@ -6,6 +6,8 @@ import (
 	"go-app-marketplace/internal/redisdb"
 	"go-app-marketplace/internal/usecases"
 	"go-app-marketplace/pkg/domain"
+	"go-app-marketplace/pkg/reqresp"
+	"strings"
 	"time"
 )
 
@@ -17,10 +19,14 @@ func NewProductService(uc *usecases.ProductUseCase) *ProductService {
 	return &ProductService{usecase: uc}
 }
 
-func (s *ProductService) CreateProduct(ctx context.Context, name, description string) (int64, error) {
+func (s *ProductService) CreateProduct(ctx context.Context, name, description, taxClass string) (int64, error) {
+	if taxClass == "" {
+		taxClass = domain.TaxClassStandard
+	}
 	product := &domain.Product{
 		Name:        name,
 		Description: description,
+		TaxClass:    taxClass,
 	}
 	return s.usecase.CreateProduct(ctx, product)
 }
@@ -39,16 +45,94 @@ func (s *ProductService) GetProductByID(ctx context.Context, id int64) (*domain.
 	return  p, nil
 }
 
-func (s *ProductService) ListProducts(ctx context.Context, page, pageSize int) ([]*domain.Product, int64, error) {
-	products, err := s.usecase.ListProducts(ctx, page, pageSize)
+func (s *ProductService) ListProducts(ctx context.Context, req reqresp.ProductFilterRequest, page, pageSize int) ([]*domain.ProductListing, int64, *domain.ProductFacets, error) {
+	filter := domain.ProductFilter{
+		Currency:     strings.ToUpper(req.Currency),
+		InStock:      req.InStock,
+		CategorySlug: req.Category,
+		Sort:         domain.ProductSort(req.Sort),
+	}
+	if filter.Currency == "" {
+		filter.Currency = domain.DefaultCurrency
+	}
+	if req.SellerID != 0 {
+		filter.SellerID = &req.SellerID
+	}
+	var err error
+	if filter.MinPrice, err = parsePriceBound(req.MinPrice, filter.Currency); err != nil {
+		return nil, 0, nil, err
+	}
+	if filter.MaxPrice, err = parsePriceBound(req.MaxPrice, filter.Currency); err != nil {
+		return nil, 0, nil, err
+	}
+
+	return s.usecase.ListProducts(ctx, filter, page, pageSize)
+}
+
+// parsePriceBound reads an optional price filter; an empty one is no bound
+func parsePriceBound(text, currency string) (*domain.Money, error) {
+	if text == "" {
+		return nil, nil
+	}
+	price, err := domain.ParseMoney(text, currency)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
+	return &price, nil
+}
 
-	total, err := s.usecase.GetTotalProducts(ctx)
+// UpdateProduct replaces the product's name, description and tax class
+func (s *ProductService) UpdateProduct(ctx context.Context, id int64, req reqresp.ProductUpdateRequest) (*domain.Product, error) {
+	return s.updateProduct(ctx, id, func(p *domain.Product) {
+		p.Name = req.Name
+		p.Description = req.Description
+		p.TaxClass = req.TaxClass
+	})
+}
+
+// PatchProduct changes the fields present in the request
+func (s *ProductService) PatchProduct(ctx context.Context, id int64, req reqresp.ProductPatchRequest) (*domain.Product, error) {
+	return s.updateProduct(ctx, id, func(p *domain.Product) {
+		if req.Name != nil {
+			p.Name = *req.Name
+		}
+		if req.Description != nil {
+			p.Description = *req.Description
+		}
+		if req.TaxClass != nil {
+			p.TaxClass = *req.TaxClass
+		}
+	})
+}
+
+func (s *ProductService) updateProduct(ctx context.Context, id int64, apply func(p *domain.Product)) (*domain.Product, error) {
+	product, err := s.usecase.GetProductByID(ctx, id)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
+	}
+
+	apply(product)
+	if err := s.usecase.UpdateProduct(ctx, product); err != nil {
+		return nil, err
 	}
+	s.invalidate(ctx, id)
+	return product, nil
+}
+
+// ArchiveProduct deletes the product from the catalogue and takes its offers off sale
+func (s *ProductService) ArchiveProduct(ctx context.Context, id int64) error {
+	if err := s.usecase.ArchiveProduct(ctx, id); err != nil {
+		return err
+	}
+	s.invalidate(ctx, id)
+	return nil
+}
+
+// invalidate drops the cached product together with its cached offers
+func (s *ProductService) invalidate(ctx context.Context, id int64) {
+	_ = redisdb.Rdb.Del(ctx, append(offersByProductKeys(id), fmt.Sprintf("product:%d", id))...)
+}
 
-	return products, total, nil
+func (s *ProductService) SearchProducts(ctx context.Context, query string, page, pageSize int) ([]*domain.ProductSearchResult, int64, error) {
+	return s.usecase.SearchProducts(ctx, query, page, pageSize)
 }
@@ -2,17 +2,140 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"go-app-marketplace/internal/payments"
+	"go-app-marketplace/internal/repositories"
 	"go-app-marketplace/internal/usecases"
+	"log"
+	"strconv"
+	"time"
 )
 
-type RefundService struct{ uc *usecases.RefundUsecase }
+// ErrRefundPaymentFailed means the refund was approved but the provider refused it.
+// The refund is left in the failed state and can be retried.
+var ErrRefundPaymentFailed = errors.New("payment provider rejected the refund")
 
-func NewRefundService(uc *usecases.RefundUsecase) *RefundService { return &RefundService{uc} }
+type RefundService struct {
+	uc             *usecases.RefundUsecase
+	paymentService *PaymentService
+}
+
+func NewRefundService(uc *usecases.RefundUsecase, paymentService *PaymentService) *RefundService {
+	return &RefundService{uc, paymentService}
+}
 
 func (s *RefundService) Request(ctx context.Context, customerID, orderItemID int64, reason string) (int64, error) {
 	return s.uc.RequestRefund(ctx, customerID, orderItemID, reason)
 }
 
 func (s *RefundService) Approve(ctx context.Context, sellerID, refundID int64, approve bool) error {
-	return s.uc.ApproveRefund(ctx, sellerID, refundID, approve)
+	if err := s.uc.ApproveRefund(ctx, sellerID, refundID, approve); err != nil {
+		return err
+	}
+	if !approve {
+		return nil
+	}
+	return s.Execute(ctx, refundID)
+}
+
+// Retry sends a failed refund to the payment provider again
+func (s *RefundService) Retry(ctx context.Context, sellerID, refundID int64) error {
+	if err := s.uc.RetryRefund(ctx, sellerID, refundID); err != nil {
+		return err
+	}
+	return s.Execute(ctx, refundID)
+}
+
+// Execute issues the approved refund against the order's payment. The provider confirms
+// it later through the charge.refunded webhook.
+func (s *RefundService) Execute(ctx context.Context, refundID int64) error {
+	_, err := s.submit(ctx, refundID)
+	return err
+}
+
+// submit sends the refund to the provider under a key of its own, so a retry
+// after a timeout or a lost result returns the refund already issued instead
+// of paying the buyer twice
+func (s *RefundService) submit(ctx context.Context, refundID int64) (*payments.Refund, error) {
+	target, err := s.uc.GetRefundTarget(ctx, refundID)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.PaymentIntentID == nil || *target.PaymentIntentID == "" {
+		_ = s.uc.MarkFailed(ctx, refundID, repositories.ErrRefundNoPayment.Error())
+		return nil, repositories.ErrRefundNoPayment
+	}
+
+	refund, err := s.paymentService.Refund(ctx, *target.PaymentIntentID,
+		target.Amount.Amount,
+		map[string]string{"refund_id": strconv.FormatInt(refundID, 10)},
+		"refund-"+strconv.FormatInt(refundID, 10))
+	if err != nil {
+		if markErr := s.uc.MarkFailed(ctx, refundID, err.Error()); markErr != nil {
+			log.Printf("Failed to mark refund %d as failed: %v", refundID, markErr)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrRefundPaymentFailed, err)
+	}
+
+	return refund, s.uc.MarkSubmitted(ctx, refundID, refund.ID)
+}
+
+// ResubmitStale sends again the approved refunds that never got a provider
+// refund recorded, after waiting longer than after for a running Execute to
+// finish. The provider hands back the refund it already issued under the same
+// key. The charge.refunded webhook may have come and gone meanwhile, so what the
+// provider reports now settles the refund.
+func (s *RefundService) ResubmitStale(ctx context.Context, after time.Duration, batchSize int) (int64, error) {
+	ids, err := s.uc.ListUnsubmitted(ctx, after, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var submitted int64
+	for _, id := range ids {
+		refund, err := s.submit(ctx, id)
+		if err != nil {
+			log.Printf("Failed to resubmit refund %d: %v", id, err)
+			continue
+		}
+		submitted++
+
+		if err := s.settle(ctx, id, refund); err != nil {
+			log.Printf("Failed to settle resubmitted refund %d: %v", id, err)
+		}
+	}
+	return submitted, nil
+}
+
+// HandleChargeRefunded settles the refunds of a payment once the provider reports them
+func (s *RefundService) HandleChargeRefunded(ctx context.Context, paymentIntentID string) error {
+	pending, err := s.uc.ListAwaitingCompletion(ctx, paymentIntentID)
+	if err != nil {
+		return err
+	}
+
+	for _, rf := range pending {
+		refund, err := s.paymentService.GetRefund(ctx, *rf.ProviderRefundID)
+		if err != nil {
+			return err
+		}
+		if err := s.settle(ctx, rf.ID, refund); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settle completes or fails the refund once the provider has decided on it;
+// a pending one is left for the charge.refunded webhook
+func (s *RefundService) settle(ctx context.Context, refundID int64, refund *payments.Refund) error {
+	switch refund.Status {
+	case payments.RefundStatusSucceeded:
+		return s.uc.MarkCompleted(ctx, refundID)
+	case payments.RefundStatusFailed, payments.RefundStatusCanceled:
+		return s.uc.MarkFailed(ctx, refundID, "refund "+refund.Status+" by payment provider")
+	}
+	return nil
 }
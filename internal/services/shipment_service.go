@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"go-app-marketplace/internal/usecases"
+	"go-app-marketplace/pkg/domain"
+	"go-app-marketplace/pkg/reqresp"
+	"strings"
+)
+
+type ShipmentService struct {
+	usecase *usecases.ShipmentUsecase
+}
+
+func NewShipmentService(uc *usecases.ShipmentUsecase) *ShipmentService {
+	return &ShipmentService{usecase: uc}
+}
+
+func (s *ShipmentService) ListBySeller(ctx context.Context, sellerID int64, status string) ([]reqresp.ShipmentResponse, error) {
+	shipments, err := s.usecase.ListBySeller(ctx, sellerID, domain.ShipmentStatus(status))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(shipments))
+	for _, sh := range shipments {
+		ids = append(ids, sh.ID)
+	}
+	items, err := s.usecase.ListItemIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return toShipmentResponses(shipments, items), nil
+}
+
+func (s *ShipmentService) Update(ctx context.Context, sellerID, shipmentID int64, req reqresp.ShipmentUpdateRequest) (*reqresp.ShipmentResponse, error) {
+	shipment, err := s.usecase.Update(ctx, sellerID, shipmentID, domain.ShipmentStatus(req.Status),
+		strings.TrimSpace(req.Carrier), strings.TrimSpace(req.TrackingNumber))
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.usecase.ListItemIDs(ctx, []int64{shipment.ID})
+	if err != nil {
+		return nil, err
+	}
+	resp := toShipmentResponse(*shipment, items[shipment.ID])
+	return &resp, nil
+}
+
+func toShipmentResponse(s domain.Shipment, itemIDs []int64) reqresp.ShipmentResponse {
+	if itemIDs == nil {
+		itemIDs = []int64{}
+	}
+	return reqresp.ShipmentResponse{
+		ID:             s.ID,
+		OrderID:        s.OrderID,
+		SellerID:       s.SellerID,
+		Status:         string(s.Status),
+		Carrier:        s.Carrier,
+		TrackingNumber: s.TrackingNumber,
+		ItemIDs:        itemIDs,
+		ShippedAt:      s.ShippedAt,
+		DeliveredAt:    s.DeliveredAt,
+		CreatedAt:      s.CreatedAt,
+		UpdatedAt:      s.UpdatedAt,
+	}
+}
+
+func toShipmentResponses(shipments []domain.Shipment, items map[int64][]int64) []reqresp.ShipmentResponse {
+	resp := make([]reqresp.ShipmentResponse, 0, len(shipments))
+	for _, s := range shipments {
+		resp = append(resp, toShipmentResponse(s, items[s.ID]))
+	}
+	return resp
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-app-marketplace/internal/usecases"
+	"go-app-marketplace/pkg/domain"
+	"go-app-marketplace/pkg/reqresp"
+	"strings"
+)
+
+type ShippingService struct {
+	usecase *usecases.ShippingUsecase
+}
+
+func NewShippingService(uc *usecases.ShippingUsecase) *ShippingService {
+	return &ShippingService{usecase: uc}
+}
+
+func (s *ShippingService) ListProfiles(ctx context.Context, sellerID int64) ([]reqresp.ShippingProfileResponse, error) {
+	profiles, err := s.usecase.ListProfiles(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]reqresp.ShippingProfileResponse, 0, len(profiles))
+	for i := range profiles {
+		resp = append(resp, toShippingProfileResponse(&profiles[i]))
+	}
+	return resp, nil
+}
+
+func (s *ShippingService) GetProfile(ctx context.Context, sellerID, id int64) (*reqresp.ShippingProfileResponse, error) {
+	profile, err := s.usecase.GetProfile(ctx, sellerID, id)
+	if err != nil {
+		return nil, err
+	}
+	resp := toShippingProfileResponse(profile)
+	return &resp, nil
+}
+
+func (s *ShippingService) CreateProfile(ctx context.Context, sellerID int64, req *reqresp.ShippingProfileRequest) (*reqresp.ShippingProfileResponse, error) {
+	profile, err := fromShippingProfileRequest(sellerID, 0, req)
+	if err != nil {
+		return nil, err
+	}
+	if profile, err = s.usecase.CreateProfile(ctx, profile); err != nil {
+		return nil, err
+	}
+	resp := toShippingProfileResponse(profile)
+	return &resp, nil
+}
+
+func (s *ShippingService) UpdateProfile(ctx context.Context, sellerID, id int64, req *reqresp.ShippingProfileRequest) (*reqresp.ShippingProfileResponse, error) {
+	profile, err := fromShippingProfileRequest(sellerID, id, req)
+	if err != nil {
+		return nil, err
+	}
+	if profile, err = s.usecase.UpdateProfile(ctx, profile); err != nil {
+		return nil, err
+	}
+	resp := toShippingProfileResponse(profile)
+	return &resp, nil
+}
+
+func (s *ShippingService) SetDefaultProfile(ctx context.Context, sellerID, id int64) error {
+	return s.usecase.SetDefaultProfile(ctx, sellerID, id)
+}
+
+func (s *ShippingService) DeleteProfile(ctx context.Context, sellerID, id int64) error {
+	return s.usecase.DeleteProfile(ctx, sellerID, id)
+}
+
+// fromShippingProfileRequest reads every amount in the profile currency
+func fromShippingProfileRequest(sellerID, id int64, req *reqresp.ShippingProfileRequest) (*domain.ShippingProfile, error) {
+	currency := strings.ToUpper(strings.TrimSpace(req.Currency))
+
+	rate, err := domain.ParseMoney(req.Rate, currency)
+	if err != nil {
+		return nil, fmt.Errorf("%w: rate: %w", domain.ErrInvalidShippingProfile, err)
+	}
+
+	profile := &domain.ShippingProfile{
+		ID:        id,
+		SellerID:  sellerID,
+		Name:      strings.TrimSpace(req.Name),
+		Method:    domain.ShippingMethod(req.Method),
+		Rate:      rate,
+		Currency:  currency,
+		IsDefault: req.IsDefault,
+	}
+
+	if req.FreeAbove != nil {
+		freeAbove, err := domain.ParseMoney(*req.FreeAbove, currency)
+		if err != nil {
+			return nil, fmt.Errorf("%w: free_above: %w", domain.ErrInvalidShippingProfile, err)
+		}
+		profile.FreeAbove = &freeAbove
+	}
+
+	for _, z := range req.Zones {
+		zoneRate, err := domain.ParseMoney(z.Rate, currency)
+		if err != nil {
+			return nil, fmt.Errorf("%w: zone rate: %w", domain.ErrInvalidShippingProfile, err)
+		}
+		profile.Zones = append(profile.Zones, domain.ShippingZoneRate{
+			Country: strings.ToUpper(strings.TrimSpace(z.Country)),
+			Region:  strings.TrimSpace(z.Region),
+			Rate:    zoneRate,
+		})
+	}
+	return profile, nil
+}
+
+func toShippingProfileResponse(p *domain.ShippingProfile) reqresp.ShippingProfileResponse {
+	zones := make([]reqresp.ShippingZoneRateResponse, 0, len(p.Zones))
+	for _, z := range p.Zones {
+		zones = append(zones, reqresp.ShippingZoneRateResponse{
+			Country: z.Country,
+			Region:  z.Region,
+			Rate:    z.Rate,
+		})
+	}
+
+	return reqresp.ShippingProfileResponse{
+		ID:        p.ID,
+		Name:      p.Name,
+		Method:    string(p.Method),
+		Rate:      p.Rate,
+		FreeAbove: p.FreeAbove,
+		Currency:  p.Currency,
+		IsDefault: p.IsDefault,
+		Zones:     zones,
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+	}
+}
+
+func toShippingLineResponses(lines []domain.ShippingLine) []reqresp.ShippingLineResponse {
+	resp := make([]reqresp.ShippingLineResponse, 0, len(lines))
+	for _, l := range lines {
+		resp = append(resp, toShippingLineResponse(l))
+	}
+	return resp
+}
+
+func toShippingLineResponse(l domain.ShippingLine) reqresp.ShippingLineResponse {
+	return reqresp.ShippingLineResponse{
+		SellerID:  l.SellerID,
+		ProfileID: l.ProfileID,
+		Name:      l.Name,
+		Method:    string(l.Method),
+		Quantity:  l.Quantity,
+		Amount:    l.Amount,
+	}
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"go-app-marketplace/internal/usecases"
+	"go-app-marketplace/pkg/domain"
+	"go-app-marketplace/pkg/reqresp"
+	"strings"
+)
+
+type TaxService struct {
+	usecase *usecases.TaxUsecase
+}
+
+func NewTaxService(uc *usecases.TaxUsecase) *TaxService {
+	return &TaxService{usecase: uc}
+}
+
+func (s *TaxService) List(ctx context.Context) ([]domain.TaxRate, error) {
+	return s.usecase.List(ctx)
+}
+
+func (s *TaxService) Create(ctx context.Context, req reqresp.TaxRateRequest) (domain.TaxRate, error) {
+	return s.usecase.Create(ctx, fromTaxRateRequest(0, req))
+}
+
+func (s *TaxService) Update(ctx context.Context, id int64, req reqresp.TaxRateRequest) (domain.TaxRate, error) {
+	return s.usecase.Update(ctx, fromTaxRateRequest(id, req))
+}
+
+func (s *TaxService) Delete(ctx context.Context, id int64) error {
+	return s.usecase.Delete(ctx, id)
+}
+
+// fromTaxRateRequest normalizes the request the way rates are matched at
+// checkout: upper-case countries, trimmed regions and the standard class by default
+func fromTaxRateRequest(id int64, req reqresp.TaxRateRequest) domain.TaxRate {
+	class := strings.TrimSpace(req.TaxClass)
+	if class == "" {
+		class = domain.TaxClassStandard
+	}
+	return domain.TaxRate{
+		ID:        id,
+		Country:   strings.ToUpper(strings.TrimSpace(req.Country)),
+		Region:    strings.TrimSpace(req.Region),
+		TaxClass:  class,
+		Name:      strings.TrimSpace(req.Name),
+		Rate:      strings.TrimSpace(req.Rate),
+		Inclusive: req.Inclusive,
+	}
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"go-app-marketplace/internal/usecases"
+	"go-app-marketplace/pkg/domain"
+	"go-app-marketplace/pkg/reqresp"
+	"strings"
+)
+
+type VariantService struct {
+	usecase *usecases.VariantUsecase
+}
+
+func NewVariantService(uc *usecases.VariantUsecase) *VariantService {
+	return &VariantService{usecase: uc}
+}
+
+func (s *VariantService) Matrix(ctx context.Context, productID int64, currency string) ([]*domain.VariantWithOffer, []domain.VariantAxis, error) {
+	if currency == "" {
+		currency = domain.DefaultCurrency
+	}
+	return s.usecase.Matrix(ctx, productID, strings.ToUpper(currency))
+}
+
+func (s *VariantService) Create(ctx context.Context, productID int64, req reqresp.VariantRequest) (domain.ProductVariant, error) {
+	return s.usecase.Create(ctx, fromVariantRequest(productID, 0, req))
+}
+
+func (s *VariantService) Update(ctx context.Context, productID, id int64, req reqresp.VariantRequest) (domain.ProductVariant, error) {
+	return s.usecase.Update(ctx, fromVariantRequest(productID, id, req))
+}
+
+func (s *VariantService) Delete(ctx context.Context, productID, id int64) error {
+	return s.usecase.Delete(ctx, productID, id)
+}
+
+func fromVariantRequest(productID, id int64, req reqresp.VariantRequest) domain.ProductVariant {
+	attributes := domain.AttributeValues(req.Attributes)
+	if attributes == nil {
+		attributes = domain.AttributeValues{}
+	}
+	return domain.ProductVariant{
+		ID:         id,
+		ProductID:  productID,
+		SKU:        strings.TrimSpace(req.SKU),
+		Attributes: attributes,
+	}
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-app-marketplace/internal/payments"
+	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/internal/usecases"
+	"go-app-marketplace/pkg/domain"
+	"log"
+	"strconv"
+)
+
+// ErrWebhookEventNotReplayable is returned when replaying an event that did not fail
+var ErrWebhookEventNotReplayable = errors.New("only failed webhook events can be replayed")
+
+// WebhookService applies provider events exactly once. Every delivery is stored
+// in webhook_events first so duplicates are skipped and failures can be replayed.
+type WebhookService struct {
+	uc             *usecases.WebhookEventUsecase
+	orderService   *OrderService
+	paymentService *PaymentService
+	refundService  *RefundService
+}
+
+func NewWebhookService(
+	uc *usecases.WebhookEventUsecase,
+	orderService *OrderService,
+	paymentService *PaymentService,
+	refundService *RefundService,
+) *WebhookService {
+	return &WebhookService{
+		uc:             uc,
+		orderService:   orderService,
+		paymentService: paymentService,
+		refundService:  refundService,
+	}
+}
+
+// Process handles a verified event. It returns duplicate=true without doing
+// anything when the event was already processed or is being processed.
+func (s *WebhookService) Process(ctx context.Context, event *payments.Event) (duplicate bool, err error) {
+	provider := s.paymentService.ProviderName()
+
+	claimed, err := s.uc.Claim(ctx, provider, event.ID, string(event.Type), event.Payload)
+	if err != nil {
+		return false, err
+	}
+	if !claimed {
+		return true, nil
+	}
+	return false, s.run(ctx, provider, event)
+}
+
+// Replay runs a stored failed event again
+func (s *WebhookService) Replay(ctx context.Context, id string) error {
+	provider := s.paymentService.ProviderName()
+
+	stored, err := s.uc.Get(ctx, provider, id)
+	if err != nil {
+		return err
+	}
+	if stored.Status != domain.WebhookEventFailed {
+		return ErrWebhookEventNotReplayable
+	}
+
+	event, err := s.paymentService.Gateway().ParseEvent(stored.Payload)
+	if err != nil {
+		return err
+	}
+
+	claimed, err := s.uc.Claim(ctx, provider, event.ID, string(event.Type), event.Payload)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrWebhookEventNotReplayable
+	}
+	return s.run(ctx, provider, event)
+}
+
+func (s *WebhookService) List(ctx context.Context, status string, page, pageSize int) ([]domain.WebhookEvent, int64, error) {
+	return s.uc.List(ctx, status, page, pageSize)
+}
+
+func (s *WebhookService) run(ctx context.Context, provider string, event *payments.Event) error {
+	if err := s.apply(ctx, event); err != nil {
+		if markErr := s.uc.MarkFailed(ctx, provider, event.ID, err.Error()); markErr != nil {
+			log.Printf("Failed to mark webhook event %s as failed: %v", event.ID, markErr)
+		}
+		return fmt.Errorf("webhook event %s: %w", event.ID, err)
+	}
+	return s.uc.MarkProcessed(ctx, provider, event.ID)
+}
+
+func (s *WebhookService) apply(ctx context.Context, event *payments.Event) error {
+	// Sessions opened before the ledger existed have no payments row
+	if err := s.paymentService.RecordEvent(ctx, event); err != nil {
+		if !errors.Is(err, repositories.ErrPaymentNotFound) {
+			return err
+		}
+		log.Printf("No payment attempt for event %s", event.ID)
+	}
+
+	var err error
+	switch event.Type {
+	case payments.EventCheckoutCompleted, payments.EventPaymentSucceeded:
+		if event.OrderID == "" {
+			log.Println("Missing order_id in metadata")
+			return nil
+		}
+
+		log.Printf("Payment succeeded for Order ID: %s", event.OrderID)
+
+		if event.PaymentIntentID != "" {
+			if err := s.orderService.AttachPaymentIntent(ctx, event.OrderID, event.PaymentIntentID); err != nil {
+				return err
+			}
+		}
+		err = s.orderService.TransitionPayment(ctx, event.OrderID, domain.PaymentStatusSuccessful)
+		if errors.Is(err, domain.ErrOrphanedPayment) {
+			// The buyer paid after the order was cancelled or expired, there is nothing left to sell them
+			log.Printf("Order %s: %v, refunding", event.OrderID, err)
+			return s.paymentService.RefundOrphanedPayment(ctx, event)
+		}
+
+	case payments.EventPaymentFailed:
+		if event.OrderID == "" {
+			log.Println("Missing order_id in metadata")
+			return nil
+		}
+
+		log.Printf("Payment failed for Order ID: %s", event.OrderID)
+
+		err = s.orderService.TransitionPayment(ctx, event.OrderID, domain.PaymentStatusFailed)
+
+	case payments.EventCheckoutExpired:
+		if event.OrderID == "" {
+			log.Println("Missing order_id in metadata")
+			return nil
+		}
+
+		orderID, err := strconv.ParseInt(event.OrderID, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		// The buyer may have started a newer session for the same order
+		open, err := s.paymentService.HasOpenCheckout(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if open {
+			log.Printf("Session %s expired, order %s still has an open checkout", event.SessionID, event.OrderID)
+			return nil
+		}
+
+		log.Printf("Checkout expired for Order ID: %s", event.OrderID)
+
+		return skipInvalidTransition(s.orderService.TransitionPayment(ctx, event.OrderID, domain.PaymentStatusExpired))
+
+	case payments.EventChargeRefunded:
+		log.Printf("Charge refunded for payment intent: %s", event.PaymentIntentID)
+
+		if err := s.refundService.HandleChargeRefunded(ctx, event.PaymentIntentID); err != nil {
+			return err
+		}
+
+		status := domain.PaymentStatusPartiallyRefunded
+		if event.AmountRefunded >= event.Amount {
+			status = domain.PaymentStatusRefunded
+		}
+		err = s.orderService.TransitionPaymentByIntent(ctx, event.PaymentIntentID, status)
+	}
+	return skipInvalidTransition(err)
+}
+
+// skipInvalidTransition acknowledges events that arrive too late to change the
+// order, e.g. a decline after the payment already succeeded. Retrying them
+// would never succeed.
+func skipInvalidTransition(err error) error {
+	if errors.Is(err, domain.ErrInvalidTransition) {
+		log.Printf("Ignoring out-of-order event: %v", err)
+		return nil
+	}
+	return err
+}
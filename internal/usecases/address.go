@@ -0,0 +1,46 @@
+package usecases
+
+import (
+	"context"
+	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/pkg/domain"
+)
+
+type AddressUsecase struct {
+	repo *repositories.AddressRepository
+}
+
+func NewAddressUsecase(repo *repositories.AddressRepository) *AddressUsecase {
+	return &AddressUsecase{repo: repo}
+}
+
+func (u *AddressUsecase) List(ctx context.Context, userID int64) ([]domain.UserAddress, error) {
+	return u.repo.ListByUser(ctx, userID)
+}
+
+func (u *AddressUsecase) Get(ctx context.Context, userID, id int64) (*domain.UserAddress, error) {
+	return u.repo.Get(ctx, userID, id)
+}
+
+func (u *AddressUsecase) Create(ctx context.Context, address *domain.UserAddress) (*domain.UserAddress, error) {
+	id, err := u.repo.Create(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	return u.repo.Get(ctx, address.UserID, id)
+}
+
+func (u *AddressUsecase) Update(ctx context.Context, address *domain.UserAddress) (*domain.UserAddress, error) {
+	if err := u.repo.Update(ctx, address); err != nil {
+		return nil, err
+	}
+	return u.repo.Get(ctx, address.UserID, address.ID)
+}
+
+func (u *AddressUsecase) SetDefault(ctx context.Context, userID, id int64) error {
+	return u.repo.SetDefault(ctx, userID, id)
+}
+
+func (u *AddressUsecase) Delete(ctx context.Context, userID, id int64) error {
+	return u.repo.Delete(ctx, userID, id)
+}
@@ -18,15 +18,39 @@ var (
 type CartUseCase struct {
 	repo      *repositories.CartRepository
 	offerRepo *repositories.OfferRepository
+	rates     *repositories.ExchangeRateRepository
 }
 
-func NewCartUseCase(cartRepo *repositories.CartRepository, offerRepo *repositories.OfferRepository) *CartUseCase {
+func NewCartUseCase(cartRepo *repositories.CartRepository, offerRepo *repositories.OfferRepository,
+	rates *repositories.ExchangeRateRepository) *CartUseCase {
 	return &CartUseCase{
 		repo:      cartRepo,
 		offerRepo: offerRepo,
+		rates:     rates,
 	}
 }
 
+// PriceIn gives the items their prices in the display currency. An item
+// whose currency has no exchange rate into it is left without one.
+func (u *CartUseCase) PriceIn(ctx context.Context, items []domain.CartItem, currency string) error {
+	if !domain.IsSupportedCurrency(currency) {
+		return domain.ErrUnsupportedCurrency
+	}
+
+	conv := &converter{rates: u.rates}
+	for i := range items {
+		price, err := conv.convert(ctx, items[i].UnitPrice, currency)
+		if errors.Is(err, repositories.ErrExchangeRateNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		items[i].DisplayPrice = &price
+	}
+	return nil
+}
+
 func (u *CartUseCase) AddItem(ctx context.Context, userID, offerID int64, quantity int) error {
 	offer, err := u.offerRepo.GetOfferByID(ctx, offerID)
 	if err != nil {
@@ -0,0 +1,93 @@
+package usecases
+
+import (
+	"context"
+	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/pkg/domain"
+)
+
+type CategoryUsecase struct {
+	repo *repositories.CategoryRepository
+}
+
+func NewCategoryUsecase(repo *repositories.CategoryRepository) *CategoryUsecase {
+	return &CategoryUsecase{repo: repo}
+}
+
+func (u *CategoryUsecase) List(ctx context.Context) ([]domain.Category, error) {
+	return u.repo.List(ctx)
+}
+
+// Tree returns the root categories with their subcategories and product counts
+func (u *CategoryUsecase) Tree(ctx context.Context) ([]*domain.CategoryNode, error) {
+	nodes, err := u.repo.ListWithProductCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return domain.BuildCategoryTree(nodes), nil
+}
+
+func (u *CategoryUsecase) GetBySlug(ctx context.Context, slug string) (domain.Category, error) {
+	return u.repo.GetBySlug(ctx, slug)
+}
+
+func (u *CategoryUsecase) Create(ctx context.Context, category domain.Category) (domain.Category, error) {
+	if err := category.Validate(); err != nil {
+		return domain.Category{}, err
+	}
+	return u.repo.Create(ctx, category)
+}
+
+func (u *CategoryUsecase) Update(ctx context.Context, category domain.Category) (domain.Category, error) {
+	if err := category.Validate(); err != nil {
+		return domain.Category{}, err
+	}
+	return u.repo.Update(ctx, category)
+}
+
+func (u *CategoryUsecase) Delete(ctx context.Context, id int64) error {
+	return u.repo.Delete(ctx, id)
+}
+
+func (u *CategoryUsecase) ListByProduct(ctx context.Context, productID int64) ([]domain.Category, error) {
+	return u.repo.ListByProduct(ctx, productID)
+}
+
+func (u *CategoryUsecase) Breadcrumb(ctx context.Context, productID int64) ([]domain.Category, error) {
+	return u.repo.Breadcrumb(ctx, productID)
+}
+
+// SetProductCategories puts the product in exactly the given categories
+func (u *CategoryUsecase) SetProductCategories(ctx context.Context, productID int64, categoryIDs []int64) error {
+	seen := make(map[int64]bool, len(categoryIDs))
+	unique := make([]int64, 0, len(categoryIDs))
+	for _, id := range categoryIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return u.repo.SetProductCategories(ctx, productID, unique)
+}
+
+func (u *CategoryUsecase) ListAttributes(ctx context.Context, categoryID int64) ([]domain.CategoryAttribute, error) {
+	return u.repo.ListAttributes(ctx, categoryID)
+}
+
+func (u *CategoryUsecase) CreateAttribute(ctx context.Context, attr domain.CategoryAttribute) (domain.CategoryAttribute, error) {
+	if err := attr.Validate(); err != nil {
+		return domain.CategoryAttribute{}, err
+	}
+	return u.repo.CreateAttribute(ctx, attr)
+}
+
+func (u *CategoryUsecase) UpdateAttribute(ctx context.Context, attr domain.CategoryAttribute) (domain.CategoryAttribute, error) {
+	if err := attr.Validate(); err != nil {
+		return domain.CategoryAttribute{}, err
+	}
+	return u.repo.UpdateAttribute(ctx, attr)
+}
+
+func (u *CategoryUsecase) DeleteAttribute(ctx context.Context, categoryID, id int64) error {
+	return u.repo.DeleteAttribute(ctx, categoryID, id)
+}
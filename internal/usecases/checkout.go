@@ -0,0 +1,434 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/pkg/domain"
+)
+
+// ErrQuoteMismatch means the cart, address or prices no longer match the quote
+var ErrQuoteMismatch = errors.New("checkout no longer matches the quote, request a new one")
+
+// ErrInvalidShippingChoice means a shipping profile was chosen that the seller does not have
+var ErrInvalidShippingChoice = errors.New("the seller has no such shipping profile")
+
+// ShippingChoices maps a seller ID to the shipping profile the buyer picked
+// for that seller's items. Sellers left out ship by their default profile.
+type ShippingChoices map[int64]int64
+
+// CheckoutPlan is the priced cart: the order to create, its items and one
+// shipping line per seller. ShippingOptions lists, per seller, what each of
+// their profiles would charge. All amounts are in Order.Currency.
+// ExclusiveTax is the part of Order.TaxAmount charged on top of the item prices.
+//
+// Cart items that can no longer be bought are left out and reported in Issues,
+// together with price changes since they were added.
+type CheckoutPlan struct {
+	Order           domain.Order
+	Items           []domain.OrderItem
+	Shipping        []domain.ShippingLine
+	ShippingOptions map[int64][]domain.ShippingLine
+	Subtotal        domain.Money
+	ExclusiveTax    domain.Money
+	Issues          []CartIssue
+}
+
+type CartIssueReason string
+
+const (
+	CartIssueUnavailable       CartIssueReason = "unavailable"
+	CartIssueInsufficientStock CartIssueReason = "insufficient_stock"
+	CartIssuePriceChanged      CartIssueReason = "price_changed"
+)
+
+// CartIssue is a cart item that changed since the buyer added it. Stock is what
+// is left of the offer; Previous and Current are the prices of a price change.
+type CartIssue struct {
+	OfferID  int64
+	Reason   CartIssueReason
+	Stock    int
+	Previous domain.Money
+	Current  domain.Money
+}
+
+// Blocking issues keep the cart from being checked out as it is
+func (i CartIssue) Blocking() bool {
+	return i.Reason != CartIssuePriceChanged
+}
+
+// err is why the plan cannot become an order, or nil
+func (p *CheckoutPlan) err() error {
+	for _, issue := range p.Issues {
+		switch issue.Reason {
+		case CartIssueUnavailable:
+			return fmt.Errorf("%w: offer %d", ErrOfferNotFound, issue.OfferID)
+		case CartIssueInsufficientStock:
+			return fmt.Errorf("%w: offer %d has %d left", repositories.ErrInsufficientStock, issue.OfferID, issue.Stock)
+		}
+	}
+	return nil
+}
+
+// Quote holds checkout to what the buyer was quoted: the same cart, delivered
+// to the same address, at the quoted unit prices, shipping and total. ID
+// identifies the quote, which places a single order.
+type Quote struct {
+	ID         string
+	AddressID  int64
+	Currency   string
+	Total      domain.Money
+	Quantities map[int64]int
+	Prices     map[int64]domain.Money
+	Shipping   ShippingChoices
+}
+
+// planCheckout prices the user's cart for delivery to one of their addresses.
+//
+// Offers listed in another currency are converted into the settlement currency
+// at the current rates, which are kept on the order. An empty currency settles
+// in the cart's own currency when all offers share one. Items are grouped by
+// seller and each group pays shipping by the profile chosen for the seller,
+// or the seller's default one, and ships free when the seller has none. Every
+// item is taxed by the rate for its product's tax class at the address;
+// shipping is not taxed.
+//
+// With a quote the cart must be the quoted one and its items keep the quoted
+// unit prices and shipping; the total must still come out as quoted.
+func planCheckout(ctx context.Context, tx *repositories.TxRepositories, userID, addressID int64, currency string, shipping ShippingChoices, quote *Quote) (*CheckoutPlan, error) {
+	if quote != nil {
+		if addressID != quote.AddressID || (currency != "" && currency != quote.Currency) {
+			return nil, ErrQuoteMismatch
+		}
+		if !quote.Shipping.contains(shipping) {
+			return nil, ErrQuoteMismatch
+		}
+		currency = quote.Currency
+		shipping = quote.Shipping
+	}
+	if currency != "" && !domain.IsSupportedCurrency(currency) {
+		return nil, domain.ErrUnsupportedCurrency
+	}
+
+	cartItems, err := tx.Cart.GetItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cartItems) == 0 {
+		return nil, errors.New("cart is empty")
+	}
+
+	address, err := tx.Addresses.Get(ctx, userID, addressID)
+	if err != nil {
+		return nil, err
+	}
+
+	if quote != nil && !quote.covers(cartItems) {
+		return nil, ErrQuoteMismatch
+	}
+
+	// Check every item against its offer as it is now
+	var issues []CartIssue
+	offers := make([]*domain.Offer, len(cartItems))
+	for i, item := range cartItems {
+		offer, err := tx.Offers.GetOfferByID(ctx, item.OfferID)
+		if err != nil {
+			return nil, err
+		}
+		offers[i] = offer
+
+		switch {
+		case !offer.IsAvailable:
+			issues = append(issues, CartIssue{OfferID: offer.ID, Reason: CartIssueUnavailable})
+		case offer.Stock < item.Quantity:
+			issues = append(issues, CartIssue{OfferID: offer.ID, Reason: CartIssueInsufficientStock, Stock: offer.Stock})
+		case !offer.Price.Equal(item.UnitPrice):
+			issues = append(issues, CartIssue{
+				OfferID: offer.ID, Reason: CartIssuePriceChanged, Previous: item.UnitPrice, Current: offer.Price,
+			})
+		}
+	}
+
+	settlement := currency
+	if settlement == "" {
+		settlement = cartCurrency(offers)
+	}
+
+	conv := &converter{rates: tx.ExchangeRates}
+	plan := &CheckoutPlan{
+		ShippingOptions: make(map[int64][]domain.ShippingLine),
+		Subtotal:        domain.NewMoney(0, settlement),
+		ExclusiveTax:    domain.NewMoney(0, settlement),
+		Issues:          issues,
+	}
+	taxAmount := domain.NewMoney(0, settlement)
+
+	// Seller groups in the order their first item appears in the cart
+	var groups []*sellerGroup
+	bySeller := make(map[int64]*sellerGroup)
+
+	for i, item := range cartItems {
+		offer := offers[i]
+		if !offer.IsAvailable || offer.Stock < item.Quantity {
+			continue
+		}
+
+		// Converted per unit so the provider's line items add up to the total
+		unitPrice, quoted := quote.price(item.OfferID)
+		if !quoted {
+			if unitPrice, err = conv.convert(ctx, offer.Price, settlement); err != nil {
+				return nil, err
+			}
+		}
+
+		orderItem := domain.OrderItem{
+			OfferID:   item.OfferID,
+			ProductID: offer.ProductID,
+			SellerID:  offer.SellerID,
+			Quantity:  item.Quantity,
+			UnitPrice: unitPrice,
+			TaxAmount: domain.NewMoney(0, settlement),
+			TaxRate:   "0",
+		}
+		lineAmount := unitPrice.Mul(int64(item.Quantity))
+
+		rate, err := tx.Taxes.ResolveForProduct(ctx, offer.ProductID, address.Country, address.Region)
+		if err != nil {
+			return nil, err
+		}
+		if rate != nil {
+			if orderItem.TaxAmount, err = rate.Compute(lineAmount); err != nil {
+				return nil, err
+			}
+			orderItem.TaxRate = rate.Rate
+			orderItem.TaxName = rate.Name
+			orderItem.TaxInclusive = rate.Inclusive
+		}
+		plan.Items = append(plan.Items, orderItem)
+
+		if plan.Subtotal, err = plan.Subtotal.Add(lineAmount); err != nil {
+			return nil, err
+		}
+		if taxAmount, err = taxAmount.Add(orderItem.TaxAmount); err != nil {
+			return nil, err
+		}
+		if !orderItem.TaxInclusive {
+			if plan.ExclusiveTax, err = plan.ExclusiveTax.Add(orderItem.TaxAmount); err != nil {
+				return nil, err
+			}
+		}
+
+		g, ok := bySeller[offer.SellerID]
+		if !ok {
+			g = &sellerGroup{sellerID: offer.SellerID}
+			bySeller[offer.SellerID] = g
+			groups = append(groups, g)
+		}
+		g.quantity += item.Quantity
+		// The free shipping threshold counts what the buyer pays for the items:
+		// the quoted price when there is one, otherwise the offer price
+		if quoted {
+			g.lines = append(g.lines, lineAmount)
+		} else {
+			g.lines = append(g.lines, offer.Price.Mul(int64(item.Quantity)))
+		}
+	}
+
+	if quote != nil {
+		if err := plan.err(); err != nil {
+			return nil, err
+		}
+	}
+
+	shippingAmount := domain.NewMoney(0, settlement)
+	for _, g := range groups {
+		options, err := g.options(ctx, tx.Shipping, conv, address, settlement)
+		if err != nil {
+			return nil, err
+		}
+		profileID, chosen := shipping[g.sellerID]
+		line, err := pickShipping(options, profileID, chosen)
+		if err != nil {
+			return nil, err
+		}
+		plan.Shipping = append(plan.Shipping, line)
+		plan.ShippingOptions[g.sellerID] = options
+		if shippingAmount, err = shippingAmount.Add(line.Amount); err != nil {
+			return nil, err
+		}
+	}
+
+	total, err := plan.Subtotal.Add(shippingAmount)
+	if err != nil {
+		return nil, err
+	}
+	if total, err = total.Add(plan.ExclusiveTax); err != nil {
+		return nil, err
+	}
+
+	plan.Order = domain.Order{
+		UserID:          userID,
+		TotalAmount:     total,
+		Currency:        settlement,
+		ExchangeRates:   conv.snapshot,
+		ShippingAddress: address.Snapshot(),
+		ShippingAmount:  shippingAmount,
+		TaxAmount:       taxAmount,
+	}
+	if quote != nil {
+		if !total.Equal(quote.Total) {
+			return nil, ErrQuoteMismatch
+		}
+		plan.Order.QuoteID = &quote.ID
+	}
+	return plan, nil
+}
+
+// price is the quoted unit price of the offer; a nil quote quotes nothing
+func (q *Quote) price(offerID int64) (domain.Money, bool) {
+	if q == nil {
+		return domain.Money{}, false
+	}
+	price, ok := q.Prices[offerID]
+	return price, ok
+}
+
+// covers reports whether the cart holds exactly the quoted offers and quantities
+func (q *Quote) covers(items []domain.CartItem) bool {
+	if len(items) != len(q.Quantities) {
+		return false
+	}
+	for _, item := range items {
+		if q.Quantities[item.OfferID] != item.Quantity {
+			return false
+		}
+		if price, ok := q.Prices[item.OfferID]; !ok || price.Currency != q.Currency {
+			return false
+		}
+	}
+	return true
+}
+
+// contains reports whether every choice in other was made here as well. A
+// quote keeps the profile of every seller, chosen or default, so checkout may
+// repeat all or some of the choices but not change them.
+func (c ShippingChoices) contains(other ShippingChoices) bool {
+	for sellerID, profileID := range other {
+		if id, ok := c[sellerID]; !ok || id != profileID {
+			return false
+		}
+	}
+	return true
+}
+
+// pickShipping is the option of the chosen profile, or without a choice the
+// first option, which is the seller's default profile or free shipping
+func pickShipping(options []domain.ShippingLine, profileID int64, chosen bool) (domain.ShippingLine, error) {
+	if !chosen {
+		return options[0], nil
+	}
+	for _, option := range options {
+		if option.ProfileID != nil && *option.ProfileID == profileID {
+			return option, nil
+		}
+	}
+	return domain.ShippingLine{}, fmt.Errorf("%w: profile %d", ErrInvalidShippingChoice, profileID)
+}
+
+// sellerGroup collects the cart items of one seller. Line totals stay in the
+// offer currencies until the seller's shipping profile says which one to use.
+type sellerGroup struct {
+	sellerID int64
+	quantity int
+	lines    []domain.Money
+}
+
+// options prices the group's delivery by each of the seller's profiles, the
+// default one first. A seller without profiles ships free.
+func (g *sellerGroup) options(ctx context.Context, shipping *repositories.ShippingRepository, conv *converter,
+	address *domain.UserAddress, settlement string) ([]domain.ShippingLine, error) {
+
+	profiles, err := shipping.ListProfiles(ctx, g.sellerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(profiles) == 0 {
+		return []domain.ShippingLine{{
+			SellerID: g.sellerID,
+			Quantity: g.quantity,
+			Name:     "Free shipping",
+			Method:   domain.ShippingFree,
+			Amount:   domain.NewMoney(0, settlement),
+		}}, nil
+	}
+
+	options := make([]domain.ShippingLine, 0, len(profiles))
+	for i := range profiles {
+		profile := &profiles[i]
+
+		// The free shipping threshold is in the profile currency
+		subtotal := domain.NewMoney(0, profile.Currency)
+		for _, m := range g.lines {
+			converted, err := conv.convert(ctx, m, profile.Currency)
+			if err != nil {
+				return nil, err
+			}
+			if subtotal, err = subtotal.Add(converted); err != nil {
+				return nil, err
+			}
+		}
+
+		cost, err := profile.Quote(g.quantity, subtotal, address.Country, address.Region)
+		if err != nil {
+			return nil, err
+		}
+		amount, err := conv.convert(ctx, cost, settlement)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, domain.ShippingLine{
+			SellerID:  g.sellerID,
+			ProfileID: &profile.ID,
+			Name:      profile.Name,
+			Method:    profile.Method,
+			Quantity:  g.quantity,
+			Amount:    amount,
+		})
+	}
+	return options, nil
+}
+
+// converter converts money at the stored rates and remembers every rate it used
+type converter struct {
+	rates    *repositories.ExchangeRateRepository
+	snapshot domain.RateSnapshot
+}
+
+func (c *converter) convert(ctx context.Context, m domain.Money, to string) (domain.Money, error) {
+	if m.Currency == to {
+		return m, nil
+	}
+	rate, err := c.rates.Get(ctx, m.Currency, to)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	converted, err := rate.Convert(m)
+	if err != nil {
+		return domain.Money{}, err
+	}
+	c.snapshot.Add(rate)
+	return converted, nil
+}
+
+// cartCurrency is the currency shared by all offers, or DefaultCurrency for a mixed cart
+func cartCurrency(offers []*domain.Offer) string {
+	currency := offers[0].Price.Currency
+	for _, offer := range offers[1:] {
+		if offer.Price.Currency != currency {
+			return domain.DefaultCurrency
+		}
+	}
+	return currency
+}
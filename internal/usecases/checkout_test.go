@@ -0,0 +1,130 @@
+package usecases
+
+import (
+	"errors"
+	"testing"
+
+	"go-app-marketplace/pkg/domain"
+)
+
+func TestPickShipping(t *testing.T) {
+	standard, express := int64(3), int64(4)
+	options := []domain.ShippingLine{
+		{SellerID: 7, ProfileID: &standard, Name: "Standard"},
+		{SellerID: 7, ProfileID: &express, Name: "Express"},
+	}
+	free := []domain.ShippingLine{{SellerID: 7, Name: "Free shipping", Method: domain.ShippingFree}}
+
+	tests := []struct {
+		name      string
+		options   []domain.ShippingLine
+		profileID int64
+		chosen    bool
+		want      string
+		wantErr   error
+	}{
+		{name: "no choice takes the default profile", options: options, want: "Standard"},
+		{name: "chosen default profile", options: options, profileID: 3, chosen: true, want: "Standard"},
+		{name: "chosen other profile", options: options, profileID: 4, chosen: true, want: "Express"},
+		{name: "profile of another seller", options: options, profileID: 9, chosen: true, wantErr: ErrInvalidShippingChoice},
+		{name: "seller without profiles ships free", options: free, want: "Free shipping"},
+		{name: "seller without profiles has nothing to choose", options: free, profileID: 3, chosen: true, wantErr: ErrInvalidShippingChoice},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pickShipping(tt.options, tt.profileID, tt.chosen)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("pickShipping() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("pickShipping() error = %v, want nil", err)
+			}
+			if got.Name != tt.want {
+				t.Fatalf("pickShipping() = %q, want %q", got.Name, tt.want)
+			}
+		})
+	}
+}
+
+func TestShippingChoicesContains(t *testing.T) {
+	quoted := ShippingChoices{7: 3, 8: 5}
+
+	tests := []struct {
+		name    string
+		choices ShippingChoices
+		want    bool
+	}{
+		{name: "no choices", want: true},
+		{name: "all quoted choices", choices: ShippingChoices{7: 3, 8: 5}, want: true},
+		{name: "some quoted choices", choices: ShippingChoices{8: 5}, want: true},
+		{name: "other profile", choices: ShippingChoices{7: 4}, want: false},
+		{name: "seller not in the quote", choices: ShippingChoices{9: 3}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quoted.contains(tt.choices); got != tt.want {
+				t.Fatalf("contains(%v) = %v, want %v", tt.choices, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteCovers(t *testing.T) {
+	quote := &Quote{
+		Currency:   domain.CurrencyEUR,
+		Quantities: map[int64]int{1: 2, 2: 1},
+		Prices: map[int64]domain.Money{
+			1: domain.NewMoney(1000, domain.CurrencyEUR),
+			2: domain.NewMoney(500, domain.CurrencyEUR),
+		},
+	}
+
+	tests := []struct {
+		name  string
+		items []domain.CartItem
+		want  bool
+	}{
+		{name: "quoted cart", items: []domain.CartItem{{OfferID: 1, Quantity: 2}, {OfferID: 2, Quantity: 1}}, want: true},
+		{name: "quantity changed", items: []domain.CartItem{{OfferID: 1, Quantity: 3}, {OfferID: 2, Quantity: 1}}, want: false},
+		{name: "item removed", items: []domain.CartItem{{OfferID: 1, Quantity: 2}}, want: false},
+		{name: "item swapped", items: []domain.CartItem{{OfferID: 1, Quantity: 2}, {OfferID: 3, Quantity: 1}}, want: false},
+		{name: "empty cart", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quote.covers(tt.items); got != tt.want {
+				t.Fatalf("covers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCartCurrency(t *testing.T) {
+	offer := func(currency string) *domain.Offer {
+		return &domain.Offer{Price: domain.NewMoney(100, currency)}
+	}
+
+	tests := []struct {
+		name   string
+		offers []*domain.Offer
+		want   string
+	}{
+		{name: "single offer", offers: []*domain.Offer{offer(domain.CurrencyEUR)}, want: domain.CurrencyEUR},
+		{name: "shared currency", offers: []*domain.Offer{offer(domain.CurrencyKZT), offer(domain.CurrencyKZT)}, want: domain.CurrencyKZT},
+		{name: "mixed currencies settle in the default", offers: []*domain.Offer{offer(domain.CurrencyEUR), offer(domain.CurrencyKZT)}, want: domain.DefaultCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cartCurrency(tt.offers); got != tt.want {
+				t.Fatalf("cartCurrency() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
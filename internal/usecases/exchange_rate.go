@@ -0,0 +1,31 @@
+package usecases
+
+import (
+	"context"
+	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/pkg/domain"
+)
+
+type ExchangeRateUsecase struct {
+	repo *repositories.ExchangeRateRepository
+}
+
+func NewExchangeRateUsecase(repo *repositories.ExchangeRateRepository) *ExchangeRateUsecase {
+	return &ExchangeRateUsecase{repo: repo}
+}
+
+func (u *ExchangeRateUsecase) List(ctx context.Context) ([]domain.ExchangeRate, error) {
+	return u.repo.List(ctx)
+}
+
+// Set creates or replaces the rate of a currency pair
+func (u *ExchangeRateUsecase) Set(ctx context.Context, rate domain.ExchangeRate) (domain.ExchangeRate, error) {
+	if err := rate.Validate(); err != nil {
+		return domain.ExchangeRate{}, err
+	}
+	return u.repo.Upsert(ctx, rate)
+}
+
+func (u *ExchangeRateUsecase) Delete(ctx context.Context, base, quote string) error {
+	return u.repo.Delete(ctx, base, quote)
+}
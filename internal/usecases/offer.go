@@ -7,8 +7,9 @@ import (
 
 type OfferRepository interface {
 	CreateOffer(ctx context.Context, offer *domain.Offer) (int64, error)
+	SoleVariantID(ctx context.Context, productID int64) (int64, error)
 	GetOfferByID(ctx context.Context, id int64) (*domain.Offer, error)
-	ListOffersByProduct(ctx context.Context, productID int64) ([]*domain.Offer, error)
+	ListOffersByProduct(ctx context.Context, productID int64, currency string) ([]*domain.Offer, error)
 	UpdateOffer(ctx context.Context, offer *domain.Offer) error
 	DeleteOffer(ctx context.Context, id int64, sellerID int64) error
 	ListOffersBySeller(ctx context.Context, sellerID int64) ([]*domain.Offer, error)
@@ -22,7 +23,16 @@ func NewOfferUseCase(repo OfferRepository) *OfferUseCase {
 	return &OfferUseCase{repo: repo}
 }
 
+// CreateOffer offers a variant. Without one, the product must have a single
+// variant, which is then the one offered.
 func (uc *OfferUseCase) CreateOffer(ctx context.Context, offer *domain.Offer) (int64, error) {
+	if offer.VariantID == 0 {
+		variantID, err := uc.repo.SoleVariantID(ctx, offer.ProductID)
+		if err != nil {
+			return 0, err
+		}
+		offer.VariantID = variantID
+	}
 	return uc.repo.CreateOffer(ctx, offer)
 }
 
@@ -30,8 +40,13 @@ func (uc *OfferUseCase) GetOfferByID(ctx context.Context, id int64) (*domain.Off
 	return uc.repo.GetOfferByID(ctx, id)
 }
 
-func (uc *OfferUseCase) ListOffersByProduct(ctx context.Context, productID int64) ([]*domain.Offer, error) {
-	return uc.repo.ListOffersByProduct(ctx, productID)
+// ListOffersByProduct returns the product's offers priced also in the display
+// currency, cheapest first
+func (uc *OfferUseCase) ListOffersByProduct(ctx context.Context, productID int64, currency string) ([]*domain.Offer, error) {
+	if !domain.IsSupportedCurrency(currency) {
+		return nil, domain.ErrUnsupportedCurrency
+	}
+	return uc.repo.ListOffersByProduct(ctx, productID, currency)
 }
 
 func (uc *OfferUseCase) UpdateOffer(ctx context.Context, offer *domain.Offer) error {
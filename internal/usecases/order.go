@@ -2,81 +2,288 @@ package usecases
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
 	"go-app-marketplace/internal/repositories"
 	"go-app-marketplace/pkg/domain"
 	"go-app-marketplace/pkg/reqresp"
+	"strconv"
+	"time"
 )
 
 type OrderUsecase struct {
-	orderRepo *repositories.OrderRepository
-	cartRepo  *repositories.CartRepository
-	offerRepo *repositories.OfferRepository
+	uow             *repositories.UnitOfWork
+	orderRepo       *repositories.OrderRepository
+	cartRepo        *repositories.CartRepository
+	offerRepo       *repositories.OfferRepository
+	reservationRepo *repositories.ReservationRepository
+	shipmentRepo    *repositories.ShipmentRepository
+	eventRepo       *repositories.OrderEventRepository
+	reservationTTL  time.Duration
 }
 
 func NewOrderUsecase(
+	uow *repositories.UnitOfWork,
 	orderRepo *repositories.OrderRepository,
 	cartRepo *repositories.CartRepository,
 	offerRepo *repositories.OfferRepository,
+	reservationRepo *repositories.ReservationRepository,
+	shipmentRepo *repositories.ShipmentRepository,
+	eventRepo *repositories.OrderEventRepository,
+	reservationTTL time.Duration,
 ) *OrderUsecase {
 	return &OrderUsecase{
-		orderRepo: orderRepo,
-		cartRepo:  cartRepo,
-		offerRepo: offerRepo,
+		uow:             uow,
+		orderRepo:       orderRepo,
+		cartRepo:        cartRepo,
+		offerRepo:       offerRepo,
+		reservationRepo: reservationRepo,
+		shipmentRepo:    shipmentRepo,
+		eventRepo:       eventRepo,
+		reservationTTL:  reservationTTL,
 	}
 }
 
-func (u *OrderUsecase) Checkout(ctx context.Context, userID int64) (int64, float64, error) {
-	// Get cart items
-	cartItems, err := u.cartRepo.GetItems(ctx, userID)
+// ReservationDeadline is the moment stock reserved right now will be released
+// if the order is still unpaid.
+func (u *OrderUsecase) ReservationDeadline() time.Time {
+	return time.Now().Add(u.reservationTTL)
+}
+
+// Checkout turns the cart into an order, reserves the stock and empties the
+// cart in a single transaction. See planCheckout for how the total is made up;
+// a non-nil quote holds it to the quoted prices.
+func (u *OrderUsecase) Checkout(ctx context.Context, userID, addressID int64, currency string, shipping ShippingChoices, quote *Quote) (*domain.Order, error) {
+	var order *domain.Order
+
+	err := u.uow.Do(ctx, func(ctx context.Context, tx *repositories.TxRepositories) error {
+		plan, err := planCheckout(ctx, tx, userID, addressID, currency, shipping, quote)
+		if err != nil {
+			return err
+		}
+		if err := plan.err(); err != nil {
+			return err
+		}
+
+		// Create order and reserve stock; the final stock check happens in the DB
+		if plan.Order.ID, err = tx.Orders.CreateOrder(ctx, &plan.Order, plan.Items, plan.Shipping, u.ReservationDeadline()); err != nil {
+			return err
+		}
+		order = &plan.Order
+
+		return tx.Cart.ClearCart(ctx, userID)
+	})
 	if err != nil {
-		return 0, 0, err
+		return nil, err
 	}
 
-	if len(cartItems) == 0 {
-		return 0, 0, errors.New("cart is empty")
+	return order, nil
+}
+
+// PreviewCheckout prices the cart exactly like Checkout without placing the order
+func (u *OrderUsecase) PreviewCheckout(ctx context.Context, userID, addressID int64, currency string, shipping ShippingChoices) (*CheckoutPlan, error) {
+	plan, err := u.QuoteCheckout(ctx, userID, addressID, currency, shipping)
+	if err != nil {
+		return nil, err
+	}
+	if err := plan.err(); err != nil {
+		return nil, err
 	}
+	return plan, nil
+}
 
-	var totalAmount float64
-	var orderItems []domain.OrderItem
+// QuoteCheckout prices what can be bought of the cart right now and reports
+// the rest, and any price changes, in the plan's Issues
+func (u *OrderUsecase) QuoteCheckout(ctx context.Context, userID, addressID int64, currency string, shipping ShippingChoices) (*CheckoutPlan, error) {
+	var plan *CheckoutPlan
+	err := u.uow.Do(ctx, func(ctx context.Context, tx *repositories.TxRepositories) error {
+		var err error
+		plan, err = planCheckout(ctx, tx, userID, addressID, currency, shipping, nil)
+		return err
+	})
+	return plan, err
+}
+
+// ItemCancellation is the outcome of cancelling an item: the order with its
+// recomputed totals and, on a paid order, the approved refund of the difference
+type ItemCancellation struct {
+	ItemID   int64
+	Order    *domain.Order
+	RefundID int64
+	Refund   domain.Money
+}
+
+// CancelOrderItem cancels a pending item of the buyer's order
+func (u *OrderUsecase) CancelOrderItem(ctx context.Context, userID, itemID int64) (*ItemCancellation, error) {
+	owns := func(item *domain.OrderItem) bool { return item.OrderUserID == userID }
+	return u.cancelItem(ctx, itemID, domain.UserRoleCustomer, owns, "")
+}
+
+// SellerCancelOrderItem cancels one of the seller's items whose shipment has not left yet
+func (u *OrderUsecase) SellerCancelOrderItem(ctx context.Context, sellerID, itemID int64, reason string) (*ItemCancellation, error) {
+	owns := func(item *domain.OrderItem) bool { return item.SellerID == sellerID }
+	return u.cancelItem(ctx, itemID, domain.UserRoleSeller, owns, reason)
+}
+
+// cancelItem cancels the item if owns accepts it and the cancellation policy
+// allows it for role. The item's stock goes back to the offer and the order
+// totals are recomputed; on a paid order the amount they dropped by is refunded.
+func (u *OrderUsecase) cancelItem(ctx context.Context, itemID int64, role domain.UserRole,
+	owns func(item *domain.OrderItem) bool, reason string) (*ItemCancellation, error) {
 
-	for _, item := range cartItems {
-		offer, err := u.offerRepo.GetOfferByID(ctx, item.OfferID)
+	var result *ItemCancellation
+	err := u.uow.Do(ctx, func(ctx context.Context, tx *repositories.TxRepositories) error {
+		item, err := tx.Orders.GetOrderItemByID(ctx, itemID)
 		if err != nil {
-			return 0, 0, err
+			return err
+		}
+		if !owns(item) {
+			return repositories.ErrOrderItemNotFound
 		}
 
-		if offer.Stock < item.Quantity {
-			return 0, 0, errors.New("insufficient stock for offer")
+		order, err := tx.Orders.GetOrderForUpdate(ctx, item.OrderID)
+		if err != nil {
+			return err
 		}
+		result, err = cancelItemInTx(ctx, tx, order, item, role, reason)
+		return err
+	})
+	return result, err
+}
 
-		orderItems = append(orderItems, domain.OrderItem{
-			OfferID:   item.OfferID,
-			ProductID: offer.ProductID,
-			SellerID:  offer.SellerID,
-			Quantity:  item.Quantity,
-			UnitPrice: offer.Price,
-		})
+// cancelItemInTx does the work of cancelItem for an item of the locked order
+func cancelItemInTx(ctx context.Context, tx *repositories.TxRepositories, before *domain.Order, item *domain.OrderItem,
+	role domain.UserRole, reason string) (*ItemCancellation, error) {
 
-		totalAmount += offer.Price * float64(item.Quantity)
+	shipment, err := tx.Shipments.GetForUpdate(ctx, item.ShipmentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := item.CanCancel(role, shipment.Status); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Orders.CancelOrderItem(ctx, item.ID, reason); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Reservations.ReleaseByOrderItem(ctx, item.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Orders.RecomputeTotals(ctx, item.OrderID); err != nil {
+		return nil, err
+	}
+	after, err := tx.Orders.GetOrderForUpdate(ctx, item.OrderID)
+	if err != nil {
+		return nil, err
 	}
+	result := &ItemCancellation{ItemID: item.ID, Order: after}
 
-	// Create order
-	orderID, err := u.orderRepo.CreateOrder(ctx, userID, totalAmount, orderItems)
+	if before.Status != domain.OrderStatusPaid {
+		return result, nil
+	}
+	refund, err := before.TotalAmount.Sub(after.TotalAmount)
+	if err != nil || !refund.IsPositive() {
+		return result, err
+	}
+	tax, err := before.TaxAmount.Sub(after.TaxAmount)
 	if err != nil {
-		return 0, 0, err
+		return nil, err
 	}
 
-	// Clear cart
-	if err := u.cartRepo.ClearCart(ctx, userID); err != nil {
-		return 0, 0, err
+	refundReason := "Cancelled by the buyer"
+	if reason != "" {
+		refundReason = "Cancelled by the seller: " + reason
+	}
+	result.Refund = refund
+	if result.RefundID, err = tx.Refunds.CreateApproved(ctx, *item, refund, tax, refundReason); err != nil {
+		return nil, err
 	}
+	return result, nil
+}
+
+// OrderCancellation is the outcome of cancelling a whole order: the order as
+// it is now, the items cancelled with their refunds and the items that could
+// no longer be cancelled
+type OrderCancellation struct {
+	Order     *domain.Order
+	Cancelled []ItemCancellation
+	Skipped   []SkippedItem
+}
+
+// SkippedItem is an item the cancellation policy kept in the order
+type SkippedItem struct {
+	ItemID int64
+	Status domain.OrderItemStatus
+	Reason string
+}
+
+// CancelOrder cancels every item of the buyer's order that the policy allows
+// in one transaction, refunding them when the order is paid. Once no item is
+// left the order itself is cancelled. An order that is already cancelled
+// returns domain.ErrInvalidTransition.
+func (u *OrderUsecase) CancelOrder(ctx context.Context, userID, orderID int64) (*OrderCancellation, error) {
+	var result *OrderCancellation
+	err := u.uow.Do(ctx, func(ctx context.Context, tx *repositories.TxRepositories) error {
+		order, err := tx.Orders.GetOrderForUpdate(ctx, orderID)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && order.UserID != userID) {
+			return repositories.ErrOrderNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if order.Status == domain.OrderStatusCancelled {
+			return fmt.Errorf("%w: order is already cancelled", domain.ErrInvalidTransition)
+		}
+
+		items, err := tx.Orders.ListOrderItems(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		result = &OrderCancellation{}
+		for i := range items {
+			item := &items[i]
+			if item.Status == domain.OrderItemStatusCancelled {
+				continue
+			}
+			item.OrderUserID = order.UserID
+
+			cancelled, err := cancelItemInTx(ctx, tx, order, item, domain.UserRoleCustomer, "")
+			if errors.Is(err, domain.ErrInvalidTransition) {
+				result.Skipped = append(result.Skipped, SkippedItem{
+					ItemID: item.ID, Status: item.Status, Reason: "item is already " + string(item.Status),
+				})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			result.Cancelled = append(result.Cancelled, *cancelled)
+			order = cancelled.Order
+		}
 
-	return orderID, totalAmount, nil
+		if len(result.Skipped) == 0 {
+			if err := order.Cancel(); err != nil {
+				return err
+			}
+			if err := tx.Orders.UpdateStatus(ctx, orderID, order.Status, order.PaymentStatus); err != nil {
+				return err
+			}
+		}
+		result.Order = order
+		return nil
+	})
+	return result, err
+}
+
+// ExtendReservation keeps the order's stock on hold for a new payment attempt
+func (u *OrderUsecase) ExtendReservation(ctx context.Context, orderID int64, until time.Time) error {
+	return u.reservationRepo.ExtendByOrder(ctx, orderID, until)
 }
 
-func (u *OrderUsecase) CancelOrderItem(ctx context.Context, userID, itemID int64) error {
-	return u.orderRepo.CancelOrderItem(ctx, userID, itemID)
+func (u *OrderUsecase) ReleaseExpiredReservations(ctx context.Context) (int64, error) {
+	return u.reservationRepo.ReleaseExpired(ctx)
 }
 
 func (u *OrderUsecase) ListOrders(ctx context.Context, userID int64) ([]*domain.Order, error) {
@@ -87,34 +294,153 @@ func (u *OrderUsecase) ListOrderItems(ctx context.Context, orderID int64) ([]dom
 	return u.orderRepo.ListOrderItems(ctx, orderID)
 }
 
-func (u *OrderUsecase) GetOrderByID(ctx context.Context, orderID int64) (*domain.Order, []domain.OrderItem, error) {
-	return u.orderRepo.GetOrderByID(ctx, orderID)
+// ListOrderLines is what the buyer pays for: the items not cancelled followed
+// by the paid shipping lines of their sellers and the tax charged on top of the
+// prices. The lines add up to the total RecomputeTotals leaves on the order.
+func (u *OrderUsecase) ListOrderLines(ctx context.Context, orderID int64) ([]domain.OrderLine, error) {
+	lines, err := u.orderRepo.ListOrderLines(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	shipping, err := u.orderRepo.ListPayableShippingLines(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range shipping {
+		if !s.Amount.IsPositive() {
+			continue
+		}
+		lines = append(lines, domain.OrderLine{
+			ProductName: "Shipping: " + s.Name,
+			UnitPrice:   s.Amount,
+			Currency:    s.Currency,
+			Quantity:    1,
+		})
+	}
+
+	taxes, err := u.orderRepo.ListTaxLines(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range taxes {
+		t.ProductName = "Tax: " + t.ProductName
+		lines = append(lines, t)
+	}
+	return lines, nil
 }
-func (u *OrderUsecase) UpdatePaymentStatusByOrderID(ctx context.Context, orderIDStr string, status domain.PaymentStatus) error {
-	return u.orderRepo.UpdatePaymentStatusByOrderID(ctx, orderIDStr, status)
+
+func (u *OrderUsecase) ListShippingLines(ctx context.Context, orderID int64) ([]domain.ShippingLine, error) {
+	return u.orderRepo.ListShippingLines(ctx, orderID)
 }
 
-func (u *OrderUsecase) SellerUpdateOrderItemStatus(
-	ctx context.Context,
-	sellerID int64,
-	itemID int64,
-	newStatus domain.OrderItemStatus,
-) error {
+// ListShipments returns the order's shipments, one per seller, with the IDs of their items
+func (u *OrderUsecase) ListShipments(ctx context.Context, orderID int64) ([]domain.Shipment, map[int64][]int64, error) {
+	shipments, err := u.shipmentRepo.ListByOrder(ctx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := make([]int64, 0, len(shipments))
+	for _, s := range shipments {
+		ids = append(ids, s.ID)
+	}
+	items, err := u.shipmentRepo.ListItemIDs(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	return shipments, items, nil
+}
 
-	item, err := u.orderRepo.GetOrderItemByID(ctx, itemID)
+// Timeline is the status history of the order. The buyer and admins see all of
+// it, a seller only the events of their own items, shipment and refunds; anyone
+// else gets repositories.ErrOrderNotFound.
+func (u *OrderUsecase) Timeline(ctx context.Context, userID int64, role domain.UserRole, orderID int64) ([]domain.OrderEvent, error) {
+	order, items, err := u.orderRepo.GetOrderByID(ctx, orderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repositories.ErrOrderNotFound
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if item.SellerID != sellerID {
-		return errors.New("access denied: not your order item")
+
+	if role == domain.UserRoleAdmin || order.UserID == userID {
+		return u.eventRepo.ListByOrder(ctx, orderID, nil)
+	}
+	if role == domain.UserRoleSeller {
+		for _, item := range items {
+			if item.SellerID == userID {
+				return u.eventRepo.ListByOrder(ctx, orderID, &userID)
+			}
+		}
 	}
+	return nil, repositories.ErrOrderNotFound
+}
+
+func (u *OrderUsecase) GetOrderByID(ctx context.Context, orderID int64) (*domain.Order, []domain.OrderItem, error) {
+	return u.orderRepo.GetOrderByID(ctx, orderID)
+}
+
+// GetOrderItem returns the item together with the buyer of its order
+func (u *OrderUsecase) GetOrderItem(ctx context.Context, itemID int64) (*domain.OrderItem, error) {
+	return u.orderRepo.GetOrderItemByID(ctx, itemID)
+}
+
+// TransitionPayment moves the order through the payment state machine and
+// settles its stock: a successful payment commits the reservations, an expired
+// one cancels the items and releases them. Repeating the current status is a no-op, so redelivered
+// events are harmless; a move the state machine forbids returns
+// domain.ErrInvalidTransition.
+func (u *OrderUsecase) TransitionPayment(ctx context.Context, orderID int64, next domain.PaymentStatus) error {
+	return u.uow.Do(ctx, func(ctx context.Context, tx *repositories.TxRepositories) error {
+		order, err := tx.Orders.GetOrderForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if order.PaymentStatus == next && next != domain.PaymentStatusPartiallyRefunded {
+			return nil
+		}
 
-	if !(item.Status == domain.OrderItemStatusPending && newStatus == domain.OrderItemStatusProcessing) &&
-		!(item.Status == domain.OrderItemStatusProcessing && newStatus == domain.OrderItemStatusDelivered) {
-		return errors.New("invalid status transition")
+		if err := order.TransitionPayment(next); err != nil {
+			return err
+		}
+		if err := tx.Orders.UpdateStatus(ctx, orderID, order.Status, order.PaymentStatus); err != nil {
+			return err
+		}
+
+		switch next.ReservationStatus() {
+		case domain.ReservationCommitted:
+			return tx.Reservations.CommitByOrder(ctx, orderID)
+		case domain.ReservationReleased:
+			if err := tx.Orders.CancelItemsByOrder(ctx, orderID); err != nil {
+				return err
+			}
+			_, err = tx.Reservations.ReleaseByOrder(ctx, orderID)
+			return err
+		}
+		return nil
+	})
+}
+
+// ListStalePendingOrders returns unpaid orders with no checkout attempt in the last ttl
+func (u *OrderUsecase) ListStalePendingOrders(ctx context.Context, ttl time.Duration, limit int) ([]int64, error) {
+	return u.orderRepo.ListStalePendingOrderIDs(ctx, time.Now().Add(-ttl), limit)
+}
+
+// TransitionPaymentByIntent is TransitionPayment for events that only carry the payment intent
+func (u *OrderUsecase) TransitionPaymentByIntent(ctx context.Context, paymentIntentID string, next domain.PaymentStatus) (int64, error) {
+	orderID, err := u.orderRepo.GetOrderIDByPaymentIntent(ctx, paymentIntentID)
+	if err != nil {
+		return 0, err
 	}
+	return orderID, u.TransitionPayment(ctx, orderID, next)
+}
 
-	return u.orderRepo.UpdateOrderItemStatus(ctx, itemID, newStatus)
+func (u *OrderUsecase) AttachPaymentIntent(ctx context.Context, orderIDStr, paymentIntentID string) error {
+	orderID, err := strconv.ParseInt(orderIDStr, 10, 64)
+	if err != nil {
+		return err
+	}
+	return u.orderRepo.SetPaymentIntentID(ctx, orderID, paymentIntentID)
 }
 
 func (u *OrderUsecase) ListSellerOrderItems(
@@ -0,0 +1,90 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/pkg/domain"
+)
+
+var ErrOrderAccessDenied = errors.New("order not found or access denied")
+
+type PaymentUsecase struct {
+	paymentRepo *repositories.PaymentRepository
+	orderRepo   *repositories.OrderRepository
+}
+
+func NewPaymentUsecase(paymentRepo *repositories.PaymentRepository, orderRepo *repositories.OrderRepository) *PaymentUsecase {
+	return &PaymentUsecase{paymentRepo: paymentRepo, orderRepo: orderRepo}
+}
+
+func (u *PaymentUsecase) RecordCheckout(ctx context.Context, p *domain.Payment) (int64, error) {
+	return u.paymentRepo.Create(ctx, p, "checkout.session.created")
+}
+
+// RecordEvent moves the matching ledger entry to the given status
+func (u *PaymentUsecase) RecordEvent(ctx context.Context, orderID int64, sessionID, paymentIntentID, eventType string,
+	status domain.PaymentAttemptStatus, payload []byte) error {
+
+	payment, err := u.paymentRepo.FindForEvent(ctx, sessionID, paymentIntentID, orderID)
+	if err != nil {
+		return err
+	}
+	return u.paymentRepo.Transition(ctx, payment.ID, status, paymentIntentID, eventType, payload)
+}
+
+func (u *PaymentUsecase) ListOpenAttempts(ctx context.Context, orderID int64) ([]domain.Payment, error) {
+	return u.paymentRepo.ListOpenByOrder(ctx, orderID)
+}
+
+// MarkOrphaned flags the attempt an event belongs to as paid after its order
+// was cancelled and returns it
+func (u *PaymentUsecase) MarkOrphaned(ctx context.Context, orderID int64, sessionID, paymentIntentID, eventType string,
+	payload []byte) (*domain.Payment, error) {
+
+	payment, err := u.paymentRepo.FindForEvent(ctx, sessionID, paymentIntentID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.paymentRepo.Transition(ctx, payment.ID, domain.PaymentAttemptOrphaned, paymentIntentID, eventType, payload); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// MarkExpired records that we closed the attempt ourselves
+func (u *PaymentUsecase) MarkExpired(ctx context.Context, paymentID int64) error {
+	return u.paymentRepo.Transition(ctx, paymentID, domain.PaymentAttemptExpired, "", "checkout.session.expired", nil)
+}
+
+func (u *PaymentUsecase) HasOpenAttempt(ctx context.Context, orderID int64) (bool, error) {
+	return u.paymentRepo.HasOpen(ctx, orderID)
+}
+
+// ListOrderPayments returns the attempts of an order owned by the user
+func (u *PaymentUsecase) ListOrderPayments(ctx context.Context, userID, orderID int64) ([]domain.Payment, error) {
+	order, _, err := u.orderRepo.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, ErrOrderAccessDenied
+	}
+	return u.paymentRepo.ListByOrder(ctx, orderID)
+}
+
+func (u *PaymentUsecase) ListPayments(ctx context.Context, status string, orderID int64, page, pageSize int) ([]domain.Payment, int64, error) {
+	return u.paymentRepo.List(ctx, status, orderID, page, pageSize)
+}
+
+func (u *PaymentUsecase) GetPayment(ctx context.Context, id int64) (*domain.Payment, []domain.PaymentTransition, error) {
+	payment, err := u.paymentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	transitions, err := u.paymentRepo.ListTransitions(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return payment, transitions, nil
+}
@@ -2,14 +2,25 @@ package usecases
 
 import (
 	"context"
+	"errors"
 	"go-app-marketplace/pkg/domain"
+	"strings"
+	"unicode"
 )
 
+// ErrEmptySearchQuery means the search text has no words to look for
+var ErrEmptySearchQuery = errors.New("search query has no words")
+
 type ProductRepository interface {
 	CreateProduct(ctx context.Context, product *domain.Product) (int64, error)
 	GetProductByID(ctx context.Context, id int64) (*domain.Product, error)
-	ListProducts(ctx context.Context, page, pageSize int) ([]*domain.Product, error)
-	GetTotalProducts(ctx context.Context) (int64, error)
+	ListProducts(ctx context.Context, filter domain.ProductFilter, page, pageSize int) ([]*domain.ProductListing, error)
+	CountProducts(ctx context.Context, filter domain.ProductFilter) (int64, error)
+	ProductFacets(ctx context.Context, filter domain.ProductFilter) (*domain.ProductFacets, error)
+	UpdateProduct(ctx context.Context, product *domain.Product) error
+	ArchiveProduct(ctx context.Context, id int64) error
+	SearchProducts(ctx context.Context, tsquery string, page, pageSize int) ([]*domain.ProductSearchResult, error)
+	CountSearchResults(ctx context.Context, tsquery string) (int64, error)
 }
 
 type ProductUseCase struct {
@@ -28,10 +39,66 @@ func (uc *ProductUseCase) GetProductByID(ctx context.Context, id int64) (*domain
 	return uc.repo.GetProductByID(ctx, id)
 }
 
-func (uc *ProductUseCase) ListProducts(ctx context.Context, page, pageSize int) ([]*domain.Product, error) {
-	return uc.repo.ListProducts(ctx, page, pageSize)
+// ListProducts returns a page of the products matching the filter, their
+// total and the facet counts for the filter sidebar
+func (uc *ProductUseCase) ListProducts(ctx context.Context, filter domain.ProductFilter, page, pageSize int) ([]*domain.ProductListing, int64, *domain.ProductFacets, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, 0, nil, err
+	}
+
+	products, err := uc.repo.ListProducts(ctx, filter, page, pageSize)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	total, err := uc.repo.CountProducts(ctx, filter)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	facets, err := uc.repo.ProductFacets(ctx, filter)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return products, total, facets, nil
+}
+
+func (uc *ProductUseCase) UpdateProduct(ctx context.Context, product *domain.Product) error {
+	return uc.repo.UpdateProduct(ctx, product)
+}
+
+// ArchiveProduct is how products are deleted: order history still needs them
+func (uc *ProductUseCase) ArchiveProduct(ctx context.Context, id int64) error {
+	return uc.repo.ArchiveProduct(ctx, id)
+}
+
+// SearchProducts finds live products whose name or description contain every
+// word of the query as a word prefix, so results show up while the user is
+// still typing
+func (uc *ProductUseCase) SearchProducts(ctx context.Context, query string, page, pageSize int) ([]*domain.ProductSearchResult, int64, error) {
+	tsquery := prefixTSQuery(query)
+	if tsquery == "" {
+		return nil, 0, ErrEmptySearchQuery
+	}
+
+	results, err := uc.repo.SearchProducts(ctx, tsquery, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := uc.repo.CountSearchResults(ctx, tsquery)
+	if err != nil {
+		return nil, 0, err
+	}
+	return results, total, nil
 }
 
-func (uc *ProductUseCase) GetTotalProducts(ctx context.Context) (int64, error) {
-	return uc.repo.GetTotalProducts(ctx)
+// prefixTSQuery turns free text into a tsquery that matches all of its words
+// as prefixes. Anything but letters and digits separates words, so the user
+// cannot inject tsquery operators.
+func prefixTSQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
 }
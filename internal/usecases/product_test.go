@@ -0,0 +1,31 @@
+package usecases
+
+import "testing"
+
+func TestPrefixTSQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "single word", in: "phone", want: "phone:*"},
+		{name: "words are lowercased and joined", in: "Red  Phone", want: "red:* & phone:*"},
+		{name: "digits are kept", in: "iphone 15", want: "iphone:* & 15:*"},
+		{name: "non-latin letters are kept", in: "Телефон", want: "телефон:*"},
+		{name: "operators separate words", in: "red&phone|case!cover", want: "red:* & phone:* & case:* & cover:*"},
+		{name: "prefix and weight syntax is dropped", in: "phone:*A", want: "phone:* & a:*"},
+		{name: "parentheses and quotes are dropped", in: `("red" <-> 'phone')`, want: "red:* & phone:*"},
+		{name: "backslash is dropped", in: `red\phone`, want: "red:* & phone:*"},
+		{name: "empty", in: "", want: ""},
+		{name: "only whitespace", in: " \t\n", want: ""},
+		{name: "only metacharacters", in: "&|!():*<->'\"", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := prefixTSQuery(tt.in); got != tt.want {
+				t.Fatalf("prefixTSQuery(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
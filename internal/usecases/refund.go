@@ -4,15 +4,17 @@ import (
 	"context"
 	"go-app-marketplace/internal/repositories"
 	"go-app-marketplace/pkg/domain"
+	"time"
 )
 
 type RefundUsecase struct {
+	uow        *repositories.UnitOfWork
 	refundRepo *repositories.RefundRepository
 	orderRepo  *repositories.OrderRepository
 }
 
-func NewRefundUsecase(r *repositories.RefundRepository, o *repositories.OrderRepository) *RefundUsecase {
-	return &RefundUsecase{r, o}
+func NewRefundUsecase(uow *repositories.UnitOfWork, r *repositories.RefundRepository, o *repositories.OrderRepository) *RefundUsecase {
+	return &RefundUsecase{uow, r, o}
 }
 
 func (u *RefundUsecase) RequestRefund(
@@ -28,20 +30,68 @@ func (u *RefundUsecase) RequestRefund(
 	if item.OrderUserID != customerID { // ensure owner
 		return 0, repositories.ErrRefundStatusForbidden
 	}
-	return u.refundRepo.Create(ctx, *item, float64(item.Quantity)*item.UnitPrice, reason)
-}
 
-func (u *RefundUsecase) ApproveRefund(ctx context.Context, sellerID, refundID int64, approve bool) error {
-	refund, err := u.refundRepo.GetByID(ctx, refundID)
+	// The buyer gets back what they paid for the whole line, its tax included
+	amount, err := item.LineTotal()
 	if err != nil {
-		return err
-	}
-	if refund.SellerID != sellerID {
-		return repositories.ErrRefundStatusForbidden
-	}
-	next := domain.RefundRejected
-	if approve {
-		next = domain.RefundApproved
+		return 0, err
 	}
-	return u.refundRepo.UpdateStatus(ctx, refundID, next)
+	return u.refundRepo.Create(ctx, *item, amount, item.TaxAmount, reason)
+}
+
+// RetryRefund puts a failed refund back to approved so it can be executed again
+func (u *RefundUsecase) RetryRefund(ctx context.Context, sellerID, refundID int64) error {
+	return u.uow.Do(ctx, func(ctx context.Context, tx *repositories.TxRepositories) error {
+		refund, err := tx.Refunds.GetByIDForUpdate(ctx, refundID)
+		if err != nil {
+			return err
+		}
+		if refund.SellerID != sellerID {
+			return repositories.ErrRefundStatusForbidden
+		}
+		return tx.Refunds.MarkRetrying(ctx, refundID)
+	})
+}
+
+func (u *RefundUsecase) GetRefundTarget(ctx context.Context, refundID int64) (*repositories.RefundTarget, error) {
+	return u.refundRepo.GetTarget(ctx, refundID)
+}
+
+func (u *RefundUsecase) MarkSubmitted(ctx context.Context, refundID int64, providerRefundID string) error {
+	return u.refundRepo.MarkSubmitted(ctx, refundID, providerRefundID)
+}
+
+func (u *RefundUsecase) MarkFailed(ctx context.Context, refundID int64, reason string) error {
+	return u.refundRepo.MarkFailed(ctx, refundID, reason)
+}
+
+func (u *RefundUsecase) MarkCompleted(ctx context.Context, refundID int64) error {
+	return u.refundRepo.MarkCompleted(ctx, refundID)
+}
+
+// ListUnsubmitted returns approved refunds that have waited longer than after
+// without reaching the payment provider
+func (u *RefundUsecase) ListUnsubmitted(ctx context.Context, after time.Duration, limit int) ([]int64, error) {
+	return u.refundRepo.ListUnsubmittedIDs(ctx, time.Now().Add(-after), limit)
+}
+
+func (u *RefundUsecase) ListAwaitingCompletion(ctx context.Context, paymentIntentID string) ([]domain.Refund, error) {
+	return u.refundRepo.ListAwaitingCompletion(ctx, paymentIntentID)
+}
+
+func (u *RefundUsecase) ApproveRefund(ctx context.Context, sellerID, refundID int64, approve bool) error {
+	return u.uow.Do(ctx, func(ctx context.Context, tx *repositories.TxRepositories) error {
+		refund, err := tx.Refunds.GetByIDForUpdate(ctx, refundID)
+		if err != nil {
+			return err
+		}
+		if refund.SellerID != sellerID {
+			return repositories.ErrRefundStatusForbidden
+		}
+		next := domain.RefundRejected
+		if approve {
+			next = domain.RefundApproved
+		}
+		return tx.Refunds.UpdateStatus(ctx, refundID, next)
+	})
 }
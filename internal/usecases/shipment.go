@@ -0,0 +1,77 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/pkg/domain"
+)
+
+// ErrShipmentOrderUnpaid means the seller tried to work on a shipment whose order is not paid yet
+var ErrShipmentOrderUnpaid = errors.New("order is not paid yet")
+
+type ShipmentUsecase struct {
+	uow  *repositories.UnitOfWork
+	repo *repositories.ShipmentRepository
+}
+
+func NewShipmentUsecase(uow *repositories.UnitOfWork, repo *repositories.ShipmentRepository) *ShipmentUsecase {
+	return &ShipmentUsecase{uow: uow, repo: repo}
+}
+
+func (u *ShipmentUsecase) ListBySeller(ctx context.Context, sellerID int64, status domain.ShipmentStatus) ([]domain.Shipment, error) {
+	return u.repo.ListBySeller(ctx, sellerID, status)
+}
+
+func (u *ShipmentUsecase) ListItemIDs(ctx context.Context, shipmentIDs []int64) (map[int64][]int64, error) {
+	return u.repo.ListItemIDs(ctx, shipmentIDs)
+}
+
+// Update moves the seller's shipment to next, or with an empty next only
+// changes its carrier and tracking number. The items of the shipment follow
+// its status. Nothing moves before the order is paid.
+func (u *ShipmentUsecase) Update(ctx context.Context, sellerID, shipmentID int64, next domain.ShipmentStatus,
+	carrier, trackingNumber string) (*domain.Shipment, error) {
+
+	var shipment *domain.Shipment
+	err := u.uow.Do(ctx, func(ctx context.Context, tx *repositories.TxRepositories) error {
+		var err error
+		shipment, err = tx.Shipments.GetForUpdate(ctx, shipmentID)
+		if err != nil {
+			return err
+		}
+		if shipment.SellerID != sellerID {
+			return repositories.ErrShipmentNotFound
+		}
+
+		order, err := tx.Orders.GetOrderForUpdate(ctx, shipment.OrderID)
+		if err != nil {
+			return err
+		}
+		if order.Status != domain.OrderStatusPaid {
+			return ErrShipmentOrderUnpaid
+		}
+
+		if next == "" {
+			switch shipment.Status {
+			case domain.ShipmentStatusDelivered, domain.ShipmentStatusCancelled:
+				return fmt.Errorf("%w: shipment is %s", domain.ErrInvalidTransition, shipment.Status)
+			}
+			if carrier != "" {
+				shipment.Carrier = carrier
+			}
+			if trackingNumber != "" {
+				shipment.TrackingNumber = trackingNumber
+			}
+		} else if err := shipment.Transition(next, carrier, trackingNumber); err != nil {
+			return err
+		}
+
+		return tx.Shipments.Update(ctx, shipment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return shipment, nil
+}
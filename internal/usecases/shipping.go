@@ -0,0 +1,52 @@
+package usecases
+
+import (
+	"context"
+	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/pkg/domain"
+)
+
+type ShippingUsecase struct {
+	repo *repositories.ShippingRepository
+}
+
+func NewShippingUsecase(repo *repositories.ShippingRepository) *ShippingUsecase {
+	return &ShippingUsecase{repo: repo}
+}
+
+func (u *ShippingUsecase) ListProfiles(ctx context.Context, sellerID int64) ([]domain.ShippingProfile, error) {
+	return u.repo.ListProfiles(ctx, sellerID)
+}
+
+func (u *ShippingUsecase) GetProfile(ctx context.Context, sellerID, id int64) (*domain.ShippingProfile, error) {
+	return u.repo.GetProfile(ctx, sellerID, id)
+}
+
+func (u *ShippingUsecase) CreateProfile(ctx context.Context, profile *domain.ShippingProfile) (*domain.ShippingProfile, error) {
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	id, err := u.repo.CreateProfile(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	return u.repo.GetProfile(ctx, profile.SellerID, id)
+}
+
+func (u *ShippingUsecase) UpdateProfile(ctx context.Context, profile *domain.ShippingProfile) (*domain.ShippingProfile, error) {
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	if err := u.repo.UpdateProfile(ctx, profile); err != nil {
+		return nil, err
+	}
+	return u.repo.GetProfile(ctx, profile.SellerID, profile.ID)
+}
+
+func (u *ShippingUsecase) SetDefaultProfile(ctx context.Context, sellerID, id int64) error {
+	return u.repo.SetDefaultProfile(ctx, sellerID, id)
+}
+
+func (u *ShippingUsecase) DeleteProfile(ctx context.Context, sellerID, id int64) error {
+	return u.repo.DeleteProfile(ctx, sellerID, id)
+}
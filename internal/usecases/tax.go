@@ -0,0 +1,37 @@
+package usecases
+
+import (
+	"context"
+	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/pkg/domain"
+)
+
+type TaxUsecase struct {
+	repo *repositories.TaxRepository
+}
+
+func NewTaxUsecase(repo *repositories.TaxRepository) *TaxUsecase {
+	return &TaxUsecase{repo: repo}
+}
+
+func (u *TaxUsecase) List(ctx context.Context) ([]domain.TaxRate, error) {
+	return u.repo.List(ctx)
+}
+
+func (u *TaxUsecase) Create(ctx context.Context, rate domain.TaxRate) (domain.TaxRate, error) {
+	if err := rate.Validate(); err != nil {
+		return domain.TaxRate{}, err
+	}
+	return u.repo.Create(ctx, rate)
+}
+
+func (u *TaxUsecase) Update(ctx context.Context, rate domain.TaxRate) (domain.TaxRate, error) {
+	if err := rate.Validate(); err != nil {
+		return domain.TaxRate{}, err
+	}
+	return u.repo.Update(ctx, rate)
+}
+
+func (u *TaxUsecase) Delete(ctx context.Context, id int64) error {
+	return u.repo.Delete(ctx, id)
+}
@@ -0,0 +1,66 @@
+package usecases
+
+import (
+	"context"
+	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/pkg/domain"
+)
+
+type VariantUsecase struct {
+	repo *repositories.VariantRepository
+}
+
+func NewVariantUsecase(repo *repositories.VariantRepository) *VariantUsecase {
+	return &VariantUsecase{repo: repo}
+}
+
+// Matrix returns the product's variants with their best offers in the
+// currency, and the attributes they differ by
+func (u *VariantUsecase) Matrix(ctx context.Context, productID int64, currency string) ([]*domain.VariantWithOffer, []domain.VariantAxis, error) {
+	if !domain.IsSupportedCurrency(currency) {
+		return nil, nil, domain.ErrUnsupportedCurrency
+	}
+
+	variants, err := u.repo.Matrix(ctx, productID, currency)
+	if err != nil {
+		return nil, nil, err
+	}
+	schema, err := u.repo.AttributesForProduct(ctx, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plain := make([]domain.ProductVariant, 0, len(variants))
+	for _, v := range variants {
+		plain = append(plain, v.ProductVariant)
+	}
+	return variants, domain.VariantAxes(schema, plain), nil
+}
+
+// Create adds a variant whose attribute values fit the attributes of the
+// product's categories
+func (u *VariantUsecase) Create(ctx context.Context, variant domain.ProductVariant) (domain.ProductVariant, error) {
+	if err := u.validate(ctx, variant); err != nil {
+		return domain.ProductVariant{}, err
+	}
+	return u.repo.Create(ctx, variant)
+}
+
+func (u *VariantUsecase) Update(ctx context.Context, variant domain.ProductVariant) (domain.ProductVariant, error) {
+	if err := u.validate(ctx, variant); err != nil {
+		return domain.ProductVariant{}, err
+	}
+	return u.repo.Update(ctx, variant)
+}
+
+func (u *VariantUsecase) Delete(ctx context.Context, productID, variantID int64) error {
+	return u.repo.Delete(ctx, productID, variantID)
+}
+
+func (u *VariantUsecase) validate(ctx context.Context, variant domain.ProductVariant) error {
+	schema, err := u.repo.AttributesForProduct(ctx, variant.ProductID)
+	if err != nil {
+		return err
+	}
+	return variant.Validate(schema)
+}
@@ -0,0 +1,36 @@
+package usecases
+
+import (
+	"context"
+	"go-app-marketplace/internal/repositories"
+	"go-app-marketplace/pkg/domain"
+)
+
+type WebhookEventUsecase struct {
+	repo *repositories.WebhookEventRepository
+}
+
+func NewWebhookEventUsecase(repo *repositories.WebhookEventRepository) *WebhookEventUsecase {
+	return &WebhookEventUsecase{repo: repo}
+}
+
+// Claim reports whether the caller should process the event
+func (u *WebhookEventUsecase) Claim(ctx context.Context, provider, id, eventType string, payload []byte) (bool, error) {
+	return u.repo.Claim(ctx, provider, id, eventType, payload)
+}
+
+func (u *WebhookEventUsecase) MarkProcessed(ctx context.Context, provider, id string) error {
+	return u.repo.MarkProcessed(ctx, provider, id)
+}
+
+func (u *WebhookEventUsecase) MarkFailed(ctx context.Context, provider, id, reason string) error {
+	return u.repo.MarkFailed(ctx, provider, id, reason)
+}
+
+func (u *WebhookEventUsecase) Get(ctx context.Context, provider, id string) (*domain.WebhookEvent, error) {
+	return u.repo.Get(ctx, provider, id)
+}
+
+func (u *WebhookEventUsecase) List(ctx context.Context, status string, page, pageSize int) ([]domain.WebhookEvent, int64, error) {
+	return u.repo.List(ctx, status, page, pageSize)
+}
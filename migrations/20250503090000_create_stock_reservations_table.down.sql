@@ -0,0 +1,2 @@
+ALTER TABLE offers DROP CONSTRAINT IF EXISTS offers_stock_non_negative;
+DROP TABLE IF EXISTS stock_reservations;
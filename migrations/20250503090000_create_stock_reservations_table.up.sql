@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id            BIGSERIAL PRIMARY KEY,
+    order_id      BIGINT      NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    order_item_id BIGINT      NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    offer_id      BIGINT      NOT NULL REFERENCES offers(id),
+    quantity      INTEGER     NOT NULL CHECK (quantity > 0),
+    status        VARCHAR(20) NOT NULL DEFAULT 'reserved',
+    expires_at    TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at    TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at    TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT stock_reservation_one_per_item UNIQUE (order_item_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_order_id ON stock_reservations(order_id);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_expires_at ON stock_reservations(expires_at) WHERE status = 'reserved';
+
+ALTER TABLE offers
+    ADD CONSTRAINT offers_stock_non_negative CHECK (stock >= 0);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_orders_payment_intent_id;
+ALTER TABLE refunds
+    DROP COLUMN IF EXISTS failure_reason,
+    DROP COLUMN IF EXISTS provider_refund_id;
@@ -0,0 +1,6 @@
+ALTER TABLE refunds
+    ADD COLUMN provider_refund_id VARCHAR(255),
+    ADD COLUMN failure_reason     TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_refunds_provider_refund_id ON refunds(provider_refund_id);
+CREATE INDEX IF NOT EXISTS idx_orders_payment_intent_id ON orders(payment_intent_id);
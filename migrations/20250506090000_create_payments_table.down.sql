@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS payment_transitions;
+DROP TABLE IF EXISTS payments;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS payments (
+    id                  BIGSERIAL PRIMARY KEY,
+    order_id            BIGINT        NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    provider            VARCHAR(20)   NOT NULL,
+    checkout_session_id VARCHAR(255)  NOT NULL UNIQUE,
+    payment_intent_id   VARCHAR(255),
+    amount              DECIMAL(10,2) NOT NULL,
+    currency            VARCHAR(3)    NOT NULL,
+    status              VARCHAR(20)   NOT NULL DEFAULT 'open',
+    raw_payload         JSONB,
+    created_at          TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at          TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS payment_transitions (
+    id          BIGSERIAL PRIMARY KEY,
+    payment_id  BIGINT       NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    from_status VARCHAR(20),
+    to_status   VARCHAR(20)  NOT NULL,
+    event_type  VARCHAR(100) NOT NULL,
+    raw_payload JSONB,
+    created_at  TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_order_id ON payments(order_id);
+CREATE INDEX IF NOT EXISTS idx_payments_payment_intent_id ON payments(payment_intent_id);
+CREATE INDEX IF NOT EXISTS idx_payment_transitions_payment_id ON payment_transitions(payment_id);
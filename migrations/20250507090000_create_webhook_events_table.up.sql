@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS webhook_events (
+    id           VARCHAR(255) NOT NULL,
+    provider     VARCHAR(20)  NOT NULL,
+    event_type   VARCHAR(100) NOT NULL,
+    status       VARCHAR(20)  NOT NULL DEFAULT 'processing',
+    attempts     INT          NOT NULL DEFAULT 1,
+    last_error   TEXT,
+    payload      JSONB        NOT NULL,
+    received_at  TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at   TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    processed_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (provider, id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_events_status ON webhook_events(status);
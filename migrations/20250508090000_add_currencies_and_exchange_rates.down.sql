@@ -0,0 +1,6 @@
+ALTER TABLE refunds DROP COLUMN IF EXISTS currency;
+ALTER TABLE order_items DROP COLUMN IF EXISTS currency;
+ALTER TABLE orders DROP COLUMN IF EXISTS exchange_rates, DROP COLUMN IF EXISTS currency;
+ALTER TABLE offers DROP COLUMN IF EXISTS currency;
+
+DROP TABLE IF EXISTS exchange_rates;
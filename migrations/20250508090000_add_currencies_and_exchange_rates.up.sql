@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    base_currency  VARCHAR(3)     NOT NULL,
+    quote_currency VARCHAR(3)     NOT NULL,
+    rate           NUMERIC(20,10) NOT NULL CHECK (rate > 0),
+    updated_at     TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (base_currency, quote_currency),
+    CHECK (base_currency <> quote_currency)
+);
+
+ALTER TABLE offers ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+    ADD COLUMN IF NOT EXISTS exchange_rates JSONB;
+
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT 'USD';
@@ -0,0 +1,3 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping_address;
+
+DROP TABLE IF EXISTS user_addresses;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS user_addresses (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT       NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    full_name   VARCHAR(255) NOT NULL,
+    phone       VARCHAR(32)  NOT NULL,
+    line1       VARCHAR(255) NOT NULL,
+    line2       VARCHAR(255) NOT NULL DEFAULT '',
+    city        VARCHAR(100) NOT NULL,
+    region      VARCHAR(100) NOT NULL DEFAULT '',
+    postal_code VARCHAR(20)  NOT NULL,
+    country     VARCHAR(2)   NOT NULL,
+    is_default  BOOLEAN      NOT NULL DEFAULT FALSE,
+    created_at  TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_addresses_user_id ON user_addresses(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_addresses_default ON user_addresses(user_id) WHERE is_default;
+
+-- The order keeps its own copy so later edits to the address book never change where it ships
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_address JSONB;
@@ -0,0 +1,5 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping_amount;
+
+DROP TABLE IF EXISTS order_shipping_lines;
+DROP TABLE IF EXISTS shipping_zone_rates;
+DROP TABLE IF EXISTS shipping_profiles;
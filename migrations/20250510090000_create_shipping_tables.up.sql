@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS shipping_profiles (
+    id          BIGSERIAL PRIMARY KEY,
+    seller_id   BIGINT        NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name        VARCHAR(100)  NOT NULL,
+    method      VARCHAR(20)   NOT NULL CHECK (method IN ('flat', 'per_item')),
+    rate        DECIMAL(10,2) NOT NULL CHECK (rate >= 0),
+    free_above  DECIMAL(10,2) CHECK (free_above >= 0),
+    currency    VARCHAR(3)    NOT NULL DEFAULT 'USD',
+    is_default  BOOLEAN       NOT NULL DEFAULT FALSE,
+    created_at  TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at  TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipping_profiles_seller_id ON shipping_profiles(seller_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shipping_profiles_default ON shipping_profiles(seller_id) WHERE is_default;
+
+CREATE TABLE IF NOT EXISTS shipping_zone_rates (
+    id         BIGSERIAL PRIMARY KEY,
+    profile_id BIGINT        NOT NULL REFERENCES shipping_profiles(id) ON DELETE CASCADE,
+    country    VARCHAR(2)    NOT NULL,
+    region     VARCHAR(100)  NOT NULL DEFAULT '',
+    rate       DECIMAL(10,2) NOT NULL CHECK (rate >= 0),
+    UNIQUE (profile_id, country, region)
+);
+
+CREATE TABLE IF NOT EXISTS order_shipping_lines (
+    id         BIGSERIAL PRIMARY KEY,
+    order_id   BIGINT        NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    seller_id  BIGINT        NOT NULL REFERENCES users(id),
+    profile_id BIGINT        REFERENCES shipping_profiles(id) ON DELETE SET NULL,
+    name       VARCHAR(100)  NOT NULL,
+    method     VARCHAR(20)   NOT NULL,
+    quantity   INT           NOT NULL,
+    amount     DECIMAL(10,2) NOT NULL,
+    currency   VARCHAR(3)    NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_shipping_lines_order_id ON order_shipping_lines(order_id);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
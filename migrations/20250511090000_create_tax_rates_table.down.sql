@@ -0,0 +1,13 @@
+ALTER TABLE refunds DROP COLUMN IF EXISTS tax_amount;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS tax_amount;
+
+ALTER TABLE order_items
+    DROP COLUMN IF EXISTS tax_inclusive,
+    DROP COLUMN IF EXISTS tax_name,
+    DROP COLUMN IF EXISTS tax_rate,
+    DROP COLUMN IF EXISTS tax_amount;
+
+ALTER TABLE products DROP COLUMN IF EXISTS tax_class;
+
+DROP TABLE IF EXISTS tax_rates;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS tax_rates (
+    id         BIGSERIAL PRIMARY KEY,
+    country    VARCHAR(2)    NOT NULL,
+    region     VARCHAR(100)  NOT NULL DEFAULT '',
+    tax_class  VARCHAR(32)   NOT NULL DEFAULT 'standard',
+    name       VARCHAR(100)  NOT NULL,
+    rate       NUMERIC(7,4)  NOT NULL CHECK (rate >= 0 AND rate <= 100),
+    inclusive  BOOLEAN       NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (country, region, tax_class)
+);
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tax_class VARCHAR(32) NOT NULL DEFAULT 'standard';
+
+ALTER TABLE order_items
+    ADD COLUMN IF NOT EXISTS tax_amount    DECIMAL(10,2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_rate      NUMERIC(7,4)  NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_name      VARCHAR(100)  NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS tax_inclusive BOOLEAN       NOT NULL DEFAULT FALSE;
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS tax_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
@@ -0,0 +1,12 @@
+ALTER TABLE cart_items
+    ADD COLUMN IF NOT EXISTS unit_price DECIMAL(10,2),
+    ADD COLUMN IF NOT EXISTS currency   VARCHAR(3);
+
+UPDATE cart_items ci
+SET unit_price = o.price, currency = o.currency
+FROM offers o
+WHERE o.id = ci.offer_id AND ci.unit_price IS NULL;
+
+ALTER TABLE cart_items
+    ALTER COLUMN unit_price SET NOT NULL,
+    ALTER COLUMN currency SET NOT NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_order_items_shipment_id;
+
+ALTER TABLE order_items DROP COLUMN IF EXISTS shipment_id;
+
+DROP TABLE IF EXISTS shipments;
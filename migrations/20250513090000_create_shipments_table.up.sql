@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS shipments (
+    id              BIGSERIAL PRIMARY KEY,
+    order_id        BIGINT       NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    seller_id       BIGINT       NOT NULL REFERENCES users(id),
+    status          VARCHAR(20)  NOT NULL DEFAULT 'pending'
+                    CHECK (status IN ('pending', 'packed', 'shipped', 'delivered', 'cancelled')),
+    carrier         VARCHAR(100) NOT NULL DEFAULT '',
+    tracking_number VARCHAR(100) NOT NULL DEFAULT '',
+    shipped_at      TIMESTAMP WITH TIME ZONE,
+    delivered_at    TIMESTAMP WITH TIME ZONE,
+    created_at      TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (order_id, seller_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipments_seller_id ON shipments(seller_id);
+
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS shipment_id BIGINT REFERENCES shipments(id);
+
+-- One shipment per seller of every existing order, in the status its items reached
+INSERT INTO shipments (order_id, seller_id, status)
+SELECT order_id, seller_id,
+       CASE
+           WHEN BOOL_AND(status = 'cancelled') THEN 'cancelled'
+           WHEN BOOL_AND(status IN ('delivered', 'cancelled')) THEN 'delivered'
+           WHEN BOOL_OR(status IN ('processing', 'delivered')) THEN 'packed'
+           ELSE 'pending'
+       END
+FROM order_items
+GROUP BY order_id, seller_id
+ON CONFLICT (order_id, seller_id) DO NOTHING;
+
+UPDATE order_items oi
+SET shipment_id = s.id
+FROM shipments s
+WHERE s.order_id = oi.order_id AND s.seller_id = oi.seller_id AND oi.shipment_id IS NULL;
+
+ALTER TABLE order_items ALTER COLUMN shipment_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_order_items_shipment_id ON order_items(shipment_id);
@@ -0,0 +1,25 @@
+-- Append-only history of every status change on an order, its items, shipments and refunds
+CREATE TABLE IF NOT EXISTS order_events (
+    id          BIGSERIAL PRIMARY KEY,
+    order_id    BIGINT      NOT NULL REFERENCES orders(id),
+    entity      VARCHAR(20) NOT NULL CHECK (entity IN ('order', 'payment', 'item', 'shipment', 'refund')),
+    entity_id   BIGINT      NOT NULL,
+    seller_id   BIGINT,
+    from_status VARCHAR(30),
+    to_status   VARCHAR(30) NOT NULL,
+    actor_role  VARCHAR(20) NOT NULL,
+    actor_id    BIGINT,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_events_order_id ON order_events(order_id, created_at);
+
+CREATE OR REPLACE FUNCTION order_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'order_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER order_events_append_only
+    BEFORE UPDATE OR DELETE ON order_events
+    FOR EACH ROW EXECUTE FUNCTION order_events_append_only();
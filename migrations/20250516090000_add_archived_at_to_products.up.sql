@@ -0,0 +1,4 @@
+-- Archived products stay for the order history but leave the catalogue
+ALTER TABLE products ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_products_live_created_at ON products(created_at DESC) WHERE archived_at IS NULL;
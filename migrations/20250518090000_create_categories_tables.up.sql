@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS categories (
+    id         BIGSERIAL    PRIMARY KEY,
+    parent_id  BIGINT       REFERENCES categories(id) ON DELETE RESTRICT,
+    name       VARCHAR(100) NOT NULL,
+    slug       VARCHAR(100) NOT NULL UNIQUE,
+    position   INT          NOT NULL DEFAULT 0,
+    created_at TIMESTAMP    NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP    NOT NULL DEFAULT NOW(),
+    CHECK (parent_id <> id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);
+
+-- A product may sit in several categories
+CREATE TABLE IF NOT EXISTS product_categories (
+    product_id  BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    category_id BIGINT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    PRIMARY KEY (product_id, category_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_categories_category_id ON product_categories(category_id);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_offers_product_id;
+
+-- Fails while a seller still offers several variants of one product
+ALTER TABLE offers DROP CONSTRAINT IF EXISTS offers_variant_id_seller_id_key;
+ALTER TABLE offers ADD CONSTRAINT offers_product_id_seller_id_key UNIQUE (product_id, seller_id);
+ALTER TABLE offers DROP COLUMN IF EXISTS variant_id;
+
+DROP TABLE IF EXISTS product_variants;
+DROP TABLE IF EXISTS category_attributes;
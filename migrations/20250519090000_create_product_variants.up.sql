@@ -0,0 +1,43 @@
+-- Typed attributes a category defines for the variants of its products,
+-- inherited by its subcategories
+CREATE TABLE IF NOT EXISTS category_attributes (
+    id          BIGSERIAL    PRIMARY KEY,
+    category_id BIGINT       NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    code        VARCHAR(32)  NOT NULL,
+    name        VARCHAR(100) NOT NULL,
+    type        VARCHAR(10)  NOT NULL CHECK (type IN ('text', 'number', 'boolean', 'enum')),
+    options     JSONB        NOT NULL DEFAULT '[]',
+    position    INT          NOT NULL DEFAULT 0,
+    created_at  TIMESTAMP    NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMP    NOT NULL DEFAULT NOW(),
+    UNIQUE (category_id, code)
+);
+
+CREATE TABLE IF NOT EXISTS product_variants (
+    id         BIGSERIAL    PRIMARY KEY,
+    product_id BIGINT       NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    sku        VARCHAR(64)  NOT NULL UNIQUE,
+    attributes JSONB        NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP    NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP    NOT NULL DEFAULT NOW(),
+    UNIQUE (product_id, attributes)
+);
+
+-- Every existing product gets a plain variant that takes over its offers
+INSERT INTO product_variants (product_id, sku)
+SELECT id, 'product-' || id FROM products;
+
+ALTER TABLE offers ADD COLUMN IF NOT EXISTS variant_id BIGINT REFERENCES product_variants(id) ON DELETE RESTRICT;
+
+UPDATE offers o
+SET variant_id = v.id
+FROM product_variants v
+WHERE v.product_id = o.product_id;
+
+ALTER TABLE offers ALTER COLUMN variant_id SET NOT NULL;
+
+-- A seller offers each variant once, so a product may have several of their offers
+ALTER TABLE offers DROP CONSTRAINT IF EXISTS offers_product_id_seller_id_key;
+ALTER TABLE offers ADD CONSTRAINT offers_variant_id_seller_id_key UNIQUE (variant_id, seller_id);
+
+CREATE INDEX IF NOT EXISTS idx_offers_product_id ON offers(product_id);
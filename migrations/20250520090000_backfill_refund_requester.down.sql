@@ -0,0 +1,2 @@
+-- The wrong requester IDs are not restored
+SELECT 1;
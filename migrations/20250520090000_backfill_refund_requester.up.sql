@@ -0,0 +1,7 @@
+-- Refund requests used to store the order ID as requester_id; every refund is requested on behalf of the buyer
+UPDATE refunds rf
+SET requester_id = o.user_id
+FROM order_items oi
+JOIN orders o ON o.id = oi.order_id
+WHERE oi.id = rf.order_item_id
+  AND rf.requester_id <> o.user_id;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_orders_quote_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS quote_id;
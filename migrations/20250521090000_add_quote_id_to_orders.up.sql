@@ -0,0 +1,3 @@
+-- A checkout quote places at most one order: its token ID is kept on the order it was redeemed for
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS quote_id VARCHAR(64);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_quote_id ON orders(quote_id);
@@ -0,0 +1,8 @@
+ALTER TABLE cart_items DROP COLUMN IF EXISTS attributes;
+ALTER TABLE cart_items DROP COLUMN IF EXISTS sku;
+ALTER TABLE cart_items DROP COLUMN IF EXISTS variant_id;
+
+DROP INDEX IF EXISTS idx_order_items_variant_id;
+ALTER TABLE order_items DROP COLUMN IF EXISTS attributes;
+ALTER TABLE order_items DROP COLUMN IF EXISTS sku;
+ALTER TABLE order_items DROP COLUMN IF EXISTS variant_id;
@@ -0,0 +1,23 @@
+-- Order and cart lines keep the variant they were made for, with its SKU and
+-- attribute values as they were then
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS variant_id BIGINT REFERENCES product_variants(id) ON DELETE RESTRICT;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS sku VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+
+UPDATE order_items oi
+SET variant_id = v.id, sku = v.sku, attributes = v.attributes
+FROM offers o
+JOIN product_variants v ON v.id = o.variant_id
+WHERE o.id = oi.offer_id;
+
+CREATE INDEX IF NOT EXISTS idx_order_items_variant_id ON order_items(variant_id);
+
+ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS variant_id BIGINT REFERENCES product_variants(id) ON DELETE CASCADE;
+ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS sku VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}';
+
+UPDATE cart_items ci
+SET variant_id = v.id, sku = v.sku, attributes = v.attributes
+FROM offers o
+JOIN product_variants v ON v.id = o.variant_id
+WHERE o.id = ci.offer_id;
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// UserAddress is an entry of the buyer's address book
+type UserAddress struct {
+	ID         int64     `db:"id"`
+	UserID     int64     `db:"user_id"`
+	FullName   string    `db:"full_name"`
+	Phone      string    `db:"phone"`
+	Line1      string    `db:"line1"`
+	Line2      string    `db:"line2"`
+	City       string    `db:"city"`
+	Region     string    `db:"region"`
+	PostalCode string    `db:"postal_code"`
+	Country    string    `db:"country"`
+	IsDefault  bool      `db:"is_default"`
+	CreatedAt  time.Time `db:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at"`
+}
+
+// Snapshot copies the address as it is right now, to be kept on an order
+func (a *UserAddress) Snapshot() *AddressSnapshot {
+	return &AddressSnapshot{
+		AddressID:  a.ID,
+		FullName:   a.FullName,
+		Phone:      a.Phone,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+	}
+}
+
+// AddressSnapshot is the immutable shipping address of an order, stored as JSONB
+type AddressSnapshot struct {
+	AddressID  int64  `json:"address_id"`
+	FullName   string `json:"full_name"`
+	Phone      string `json:"phone"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+}
+
+func (s *AddressSnapshot) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (s *AddressSnapshot) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	}
+	return fmt.Errorf("address snapshot: cannot scan %T", src)
+}
@@ -5,4 +5,18 @@ type CartItem struct {
 	UserID   int64 `db:"user_id"`
 	OfferID  int64 `db:"offer_id"`
 	Quantity int   `db:"quantity"`
+
+	// UnitPrice is the offer price when the item was last added, so checkout
+	// can tell the buyer the price has changed since
+	UnitPrice Money  `db:"unit_price"`
+	Currency  string `db:"currency"`
+
+	// The offer's variant as the buyer saw it when adding the item
+	VariantID  *int64          `db:"variant_id"`
+	SKU        string          `db:"sku"`
+	Attributes AttributeValues `db:"attributes"`
+
+	// DisplayPrice is UnitPrice in the currency the buyer views the cart in;
+	// nil when there is no exchange rate for it
+	DisplayPrice *Money `db:"-"`
 }
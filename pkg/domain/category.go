@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidCategory  = errors.New("invalid category")
+	categorySlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+)
+
+// Category is a node of the catalogue tree. Siblings are shown by Position,
+// then by name.
+type Category struct {
+	ID        int64     `db:"id"`
+	ParentID  *int64    `db:"parent_id"`
+	Name      string    `db:"name"`
+	Slug      string    `db:"slug"`
+	Position  int       `db:"position"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+func (c *Category) Validate() error {
+	switch {
+	case strings.TrimSpace(c.Name) == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidCategory)
+	case len(c.Slug) > 100 || !categorySlugPattern.MatchString(c.Slug):
+		return fmt.Errorf("%w: slug must be lowercase letters and digits separated by dashes", ErrInvalidCategory)
+	case c.ParentID != nil && *c.ParentID == c.ID:
+		return fmt.Errorf("%w: a category cannot be its own parent", ErrInvalidCategory)
+	}
+	return nil
+}
+
+// Slugify makes a slug out of a category name: "Men's T-Shirts" becomes
+// "men-s-t-shirts"
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+// CategoryNode is a category in the tree with the number of live products in
+// it or any of its descendants, each product counted once
+type CategoryNode struct {
+	Category
+	ProductCount int64 `db:"product_count"`
+	Children     []*CategoryNode
+}
+
+// BuildCategoryTree links the nodes to their parents and returns the roots.
+// Children keep the order the nodes come in.
+func BuildCategoryTree(nodes []*CategoryNode) []*CategoryNode {
+	byID := make(map[int64]*CategoryNode, len(nodes))
+	for _, n := range nodes {
+		byID[n.ID] = n
+	}
+
+	roots := []*CategoryNode{}
+	for _, n := range nodes {
+		if n.ParentID == nil {
+			roots = append(roots, n)
+			continue
+		}
+		if parent, ok := byID[*n.ParentID]; ok {
+			parent.Children = append(parent.Children, n)
+		}
+	}
+	return roots
+}
@@ -0,0 +1,156 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+const (
+	CurrencyUSD = "USD"
+	CurrencyEUR = "EUR"
+	CurrencyKZT = "KZT"
+)
+
+var (
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrInvalidExchangeRate = errors.New("invalid exchange rate")
+)
+
+var supportedCurrencies = map[string]bool{
+	CurrencyUSD: true,
+	CurrencyEUR: true,
+	CurrencyKZT: true,
+}
+
+func IsSupportedCurrency(c string) bool {
+	return supportedCurrencies[c]
+}
+
+// SupportedCurrencies lists the currencies we sell in, sorted
+func SupportedCurrencies() []string {
+	currencies := make([]string, 0, len(supportedCurrencies))
+	for c := range supportedCurrencies {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// ExchangeRate converts Base into Quote: 1 Base = Rate Quote. Rate is kept as
+// decimal text so conversions stay exact.
+type ExchangeRate struct {
+	Base      string    `db:"base_currency" json:"base"`
+	Quote     string    `db:"quote_currency" json:"quote"`
+	Rate      string    `db:"rate" json:"rate"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (r ExchangeRate) rat() (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(r.Rate)
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("%w %s/%s: %q", ErrInvalidExchangeRate, r.Base, r.Quote, r.Rate)
+	}
+	return rate, nil
+}
+
+// Validate checks the currencies and that the rate is a positive decimal
+func (r ExchangeRate) Validate() error {
+	if !IsSupportedCurrency(r.Base) || !IsSupportedCurrency(r.Quote) {
+		return fmt.Errorf("%w: %s/%s", ErrUnsupportedCurrency, r.Base, r.Quote)
+	}
+	if r.Base == r.Quote {
+		return fmt.Errorf("%w: %s/%s needs two different currencies", ErrInvalidExchangeRate, r.Base, r.Quote)
+	}
+	_, err := r.rat()
+	return err
+}
+
+// Inverse returns the Quote to Base rate
+func (r ExchangeRate) Inverse() (ExchangeRate, error) {
+	rate, err := r.rat()
+	if err != nil {
+		return ExchangeRate{}, err
+	}
+	return ExchangeRate{
+		Base:      r.Quote,
+		Quote:     r.Base,
+		Rate:      new(big.Rat).Inv(rate).FloatString(10),
+		UpdatedAt: r.UpdatedAt,
+	}, nil
+}
+
+// Convert turns m from Base into Quote, rounding half away from zero to the minor unit
+func (r ExchangeRate) Convert(m Money) (Money, error) {
+	if m.Currency != r.Base {
+		return Money{}, fmt.Errorf("%w: converting %s with a %s/%s rate", ErrCurrencyMismatch, m.Currency, r.Base, r.Quote)
+	}
+	rate, err := r.rat()
+	if err != nil {
+		return Money{}, err
+	}
+
+	minor, err := roundMinor(new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), rate))
+	if err != nil {
+		return Money{}, err
+	}
+	return NewMoney(minor, r.Quote), nil
+}
+
+// roundMinor rounds an exact amount of minor units half away from zero
+func roundMinor(v *big.Rat) (int64, error) {
+	num, den := v.Num(), v.Denom()
+
+	// round(num/den) = floor((2*|num| + den) / (2*den)) with the sign restored
+	abs := new(big.Int).Abs(num)
+	q := new(big.Int).Add(new(big.Int).Mul(abs, big.NewInt(2)), den)
+	q.Quo(q, new(big.Int).Mul(den, big.NewInt(2)))
+	if num.Sign() < 0 {
+		q.Neg(q)
+	}
+	if !q.IsInt64() {
+		return 0, fmt.Errorf("%w: amount overflows", ErrInvalidMoney)
+	}
+	return q.Int64(), nil
+}
+
+// RateSnapshot is the set of rates an order was converted with, stored as JSONB
+type RateSnapshot []ExchangeRate
+
+func (s RateSnapshot) Value() (driver.Value, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (s *RateSnapshot) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	}
+	return fmt.Errorf("rate snapshot: cannot scan %T", src)
+}
+
+// Add records the rate once per currency pair
+func (s *RateSnapshot) Add(rate ExchangeRate) {
+	for _, r := range *s {
+		if r.Base == rate.Base && r.Quote == rate.Quote {
+			return
+		}
+	}
+	*s = append(*s, rate)
+}
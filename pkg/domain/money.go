@@ -0,0 +1,272 @@
+package domain
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used for amounts stored without a currency of their own
+const DefaultCurrency = "USD"
+
+// moneyScale is the number of minor units in a major unit. Every currency we
+// sell in (USD, EUR, KZT) has two decimal places, matching DECIMAL(10,2).
+const moneyScale = 100
+
+var (
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrInvalidMoney     = errors.New("invalid money amount")
+)
+
+// Money is an exact amount in integer minor units (cents) of an ISO 4217 currency.
+// In JSON it is {"amount":"12.34","currency":"USD"}; in Postgres it is a DECIMAL
+// next to a currency column.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+func NewMoney(minor int64, currency string) Money {
+	return Money{Amount: minor, Currency: normalizeCurrency(currency)}
+}
+
+// ParseMoney reads a decimal string such as "12.34" or "-0.5": an optional
+// sign, then ASCII digits with at most one decimal point
+func ParseMoney(s, currency string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Money{}, ErrInvalidMoney
+	}
+
+	negative := strings.HasPrefix(s, "-")
+	if negative || strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if !isDigits(whole) || !isDigits(frac) || whole+frac == "" {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	if len(frac) > 2 {
+		// DECIMAL scale is 2, anything further must be zeros
+		if strings.Trim(frac[2:], "0") != "" {
+			return Money{}, fmt.Errorf("%w: %q has more than 2 decimal places", ErrInvalidMoney, s)
+		}
+		frac = frac[:2]
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || w > math.MaxInt64/moneyScale-1 {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+	f, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, s)
+	}
+
+	minor := w*moneyScale + f
+	if negative {
+		minor = -minor
+	}
+	return NewMoney(minor, currency), nil
+}
+
+// MustParseMoney is ParseMoney for constants known to be valid
+func MustParseMoney(s, currency string) Money {
+	m, err := ParseMoney(s, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// String formats the amount as a plain decimal, e.g. "12.34"
+func (m Money) String() string {
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/moneyScale, amount%moneyScale)
+}
+
+func (m Money) IsZero() bool     { return m.Amount == 0 }
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+
+// Add returns m + o. A zero Money without currency takes the other's currency.
+func (m Money) Add(o Money) (Money, error) {
+	cur, err := m.sameCurrency(o)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: cur}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	cur, err := m.sameCurrency(o)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount - o.Amount, Currency: cur}, nil
+}
+
+// Mul multiplies by a quantity
+func (m Money) Mul(qty int64) Money {
+	return Money{Amount: m.Amount * qty, Currency: m.Currency}
+}
+
+// Cmp returns -1, 0 or 1 like strings.Compare
+func (m Money) Cmp(o Money) (int, error) {
+	if _, err := m.sameCurrency(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m Money) Equal(o Money) bool {
+	return m.Amount == o.Amount && m.Currency == o.Currency
+}
+
+func (m Money) sameCurrency(o Money) (string, error) {
+	switch {
+	case m.Currency == o.Currency:
+		return m.Currency, nil
+	case m.Currency == "" && m.Amount == 0:
+		return o.Currency, nil
+	case o.Currency == "" && o.Amount == 0:
+		return m.Currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+}
+
+// Value stores the amount as DECIMAL text; the currency lives in its own
+// column and is read back with the amount by Scan
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan reads an amount selected together with its currency as a row, e.g.
+// (price, currency) AS price, which Postgres sends as "(12.34,EUR)". A bare
+// DECIMAL scans without a currency, so an amount that lost it fails loudly
+// in arithmetic instead of passing for DefaultCurrency.
+func (m *Money) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		*m = Money{Amount: v * moneyScale}
+		return nil
+	case float64:
+		s = strconv.FormatFloat(v, 'f', 2, 64)
+	case nil:
+		*m = Money{}
+		return nil
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+
+	currency := ""
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		var ok bool
+		s, currency, ok = strings.Cut(s[1:len(s)-1], ",")
+		if !ok || s == "" || currency == "" {
+			return fmt.Errorf("%w: %q is not an (amount,currency) row", ErrInvalidMoney, src)
+		}
+	}
+
+	parsed, err := ParseMoney(s, currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.String(), Currency: m.Currency})
+}
+
+// UnmarshalJSON accepts {"amount":"12.34","currency":"EUR"} as well as a bare
+// "12.34" or 12.34, which are taken in DefaultCurrency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	switch data[0] {
+	case '{':
+		var v moneyJSON
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		currency := v.Currency
+		if currency == "" {
+			currency = DefaultCurrency
+		}
+		parsed, err := ParseMoney(v.Amount, currency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	case '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		parsed, err := ParseMoney(s, DefaultCurrency)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	}
+
+	// A JSON number, parsed from its text so 0.1 + 0.2 style errors never appear
+	parsed, err := ParseMoney(string(data), DefaultCurrency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// isDigits reports whether s is made of ASCII digits only; the empty string is
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeCurrency(c string) string {
+	return strings.ToUpper(strings.TrimSpace(c))
+}
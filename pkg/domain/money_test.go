@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{in: "12.34", want: 1234},
+		{in: "12", want: 1200},
+		{in: "12.3", want: 1230},
+		{in: "12.", want: 1200},
+		{in: ".5", want: 50},
+		{in: "0.05", want: 5},
+		{in: "-0.5", want: -50},
+		{in: "+1.25", want: 125},
+		{in: " 7.10 ", want: 710},
+		{in: "1.2300", want: 123},
+		{in: "007.01", want: 701},
+		{in: "92233720368547757.00", want: 9223372036854775700},
+
+		{in: "", wantErr: true},
+		{in: "-", wantErr: true},
+		{in: ".", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "1.-5", wantErr: true},
+		{in: "1.+5", wantErr: true},
+		{in: "1.5a", wantErr: true},
+		{in: "1.2.3", wantErr: true},
+		{in: "--1", wantErr: true},
+		{in: "-+1", wantErr: true},
+		{in: "1 .5", wantErr: true},
+		{in: "1e3", wantErr: true},
+		{in: "0x10", wantErr: true},
+		{in: "1.234", wantErr: true},
+		{in: "92233720368547758.08", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseMoney(tt.in, "eur")
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidMoney) {
+					t.Fatalf("ParseMoney(%q) = %v, %v; want ErrInvalidMoney", tt.in, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMoney(%q) error = %v", tt.in, err)
+			}
+			if got.Amount != tt.want || got.Currency != "EUR" {
+				t.Fatalf("ParseMoney(%q) = %d %s, want %d EUR", tt.in, got.Amount, got.Currency, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyStringRoundTrip(t *testing.T) {
+	for _, minor := range []int64{0, 5, -5, 100, 1234, -1234, 100000001} {
+		m := NewMoney(minor, "USD")
+		got, err := ParseMoney(m.String(), "USD")
+		if err != nil {
+			t.Fatalf("ParseMoney(%q) error = %v", m.String(), err)
+		}
+		if !got.Equal(m) {
+			t.Fatalf("round trip of %d gave %d", minor, got.Amount)
+		}
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	tests := []struct {
+		src     interface{}
+		want    Money
+		wantErr bool
+	}{
+		{src: []byte("(12.34,EUR)"), want: Money{Amount: 1234, Currency: "EUR"}},
+		{src: "(-0.50,USD)", want: Money{Amount: -50, Currency: "USD"}},
+		{src: []byte("12.34"), want: Money{Amount: 1234}},
+		{src: int64(3), want: Money{Amount: 300}},
+		{src: nil, want: Money{}},
+
+		{src: "(,USD)", wantErr: true},
+		{src: "(12.34,)", wantErr: true},
+		{src: "(12.34)", wantErr: true},
+		{src: "(abc,USD)", wantErr: true},
+		{src: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var got Money
+		err := got.Scan(tt.src)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Scan(%v) = %+v, want an error", tt.src, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Scan(%v) error = %v", tt.src, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Scan(%v) = %+v, want %+v", tt.src, got, tt.want)
+		}
+	}
+}
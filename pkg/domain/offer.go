@@ -3,12 +3,17 @@ package domain
 import "time"
 
 type Offer struct {
-	ID          int64     `db:"id"`
-	ProductID   int64     `db:"product_id"`
-	SellerID    int64     `db:"seller_id"`
-	Price       float64   `db:"price"`
-	Stock       int       `db:"stock"`
-	IsAvailable bool      `db:"is_available"`
-	CreatedAt   time.Time `db:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at"`
+	ID        int64  `db:"id"`
+	ProductID int64  `db:"product_id"`
+	VariantID int64  `db:"variant_id"`
+	SellerID  int64  `db:"seller_id"`
+	Price     Money  `db:"price"`
+	Currency  string `db:"currency"`
+	// DisplayPrice is Price converted into the currency the buyer views it in;
+	// nil when there is no exchange rate for it
+	DisplayPrice *Money    `db:"display_price"`
+	Stock        int       `db:"stock"`
+	IsAvailable  bool      `db:"is_available"`
+	CreatedAt    time.Time `db:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at"`
 }
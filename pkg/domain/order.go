@@ -11,9 +11,12 @@ const (
 	OrderStatusPaid      OrderStatus = "paid"
 	OrderStatusCancelled OrderStatus = "cancelled"
 
-	PaymentStatusPending    PaymentStatus = "pending"
-	PaymentStatusSuccessful PaymentStatus = "successful"
-	PaymentStatusFailed     PaymentStatus = "failed"
+	PaymentStatusPending           PaymentStatus = "pending"
+	PaymentStatusSuccessful        PaymentStatus = "successful"
+	PaymentStatusFailed            PaymentStatus = "failed"
+	PaymentStatusExpired           PaymentStatus = "expired"
+	PaymentStatusPartiallyRefunded PaymentStatus = "partially_refunded"
+	PaymentStatusRefunded          PaymentStatus = "refunded"
 
 	OrderItemStatusPending    OrderItemStatus = "pending"
 	OrderItemStatusProcessing OrderItemStatus = "processing"
@@ -24,11 +27,31 @@ const (
 type Order struct {
 	ID            int64         `db:"id"`
 	UserID        int64         `db:"user_id"`
-	TotalAmount   float64       `db:"total_amount"`
+	TotalAmount   Money         `db:"total_amount"`
 	Status        OrderStatus   `db:"status"`
 	PaymentStatus PaymentStatus `db:"payment_status"`
 	CreatedAt     time.Time     `db:"created_at"`
 	UpdatedAt     time.Time     `db:"updated_at"`
+
+	PaymentIntentID *string `db:"payment_intent_id"`
+
+	// Currency is what the buyer settles in; ExchangeRates are the rates used
+	// to convert offers listed in other currencies at checkout.
+	Currency      string       `db:"currency"`
+	ExchangeRates RateSnapshot `db:"exchange_rates"`
+
+	// ShippingAddress is copied from the address book at checkout; nil on
+	// orders placed before addresses existed. ShippingAmount is the part of
+	// TotalAmount charged for delivery.
+	ShippingAddress *AddressSnapshot `db:"shipping_address"`
+	ShippingAmount  Money            `db:"shipping_amount"`
+
+	// TaxAmount is all tax on the order's items, inclusive and exclusive alike
+	TaxAmount Money `db:"tax_amount"`
+
+	// QuoteID is the checkout quote the order was placed with, if any; a quote
+	// places one order only
+	QuoteID *string `db:"quote_id"`
 }
 
 type OrderItem struct {
@@ -38,10 +61,47 @@ type OrderItem struct {
 	ProductID int64           `db:"product_id"`
 	SellerID  int64           `db:"seller_id"`
 	Quantity  int             `db:"quantity"`
-	UnitPrice float64         `db:"unit_price"`
+	UnitPrice Money           `db:"unit_price"`
 	Status    OrderItemStatus `db:"status"`
 	CreatedAt time.Time       `db:"created_at"`
 	UpdatedAt time.Time       `db:"updated_at"`
 
+	// Tax on the whole line. With TaxInclusive it is part of UnitPrice * Quantity,
+	// otherwise it was charged on top of it.
+	TaxAmount    Money  `db:"tax_amount"`
+	TaxRate      string `db:"tax_rate"`
+	TaxName      string `db:"tax_name"`
+	TaxInclusive bool   `db:"tax_inclusive"`
+
 	OrderUserID int64 `db:"order_user_id" json:"-"`
+
+	// ShipmentID is the seller shipment the item travels in; its status follows the shipment's
+	ShipmentID int64 `db:"shipment_id"`
+
+	// CancellationReason is what the seller gave when cancelling the item
+	CancellationReason string `db:"cancellation_reason"`
+
+	// The variant the item was ordered as, with its SKU and attribute values
+	// at the time. VariantID is nil for items ordered before variants existed
+	// whose offer is gone.
+	VariantID  *int64          `db:"variant_id"`
+	SKU        string          `db:"sku"`
+	Attributes AttributeValues `db:"attributes"`
+}
+
+// LineTotal is what the buyer paid for the item including tax
+func (i *OrderItem) LineTotal() (Money, error) {
+	total := i.UnitPrice.Mul(int64(i.Quantity))
+	if i.TaxInclusive {
+		return total, nil
+	}
+	return total.Add(i.TaxAmount)
+}
+
+// OrderLine is what the buyer pays for: a product with its unit price and total quantity
+type OrderLine struct {
+	ProductName string `db:"product_name"`
+	UnitPrice   Money  `db:"unit_price"`
+	Currency    string `db:"currency"`
+	Quantity    int    `db:"quantity"`
 }
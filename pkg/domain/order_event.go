@@ -0,0 +1,34 @@
+package domain
+
+import "time"
+
+// OrderEventEntity is what an order event changed the status of
+type OrderEventEntity string
+
+const (
+	OrderEventOrder    OrderEventEntity = "order"
+	OrderEventPayment  OrderEventEntity = "payment"
+	OrderEventItem     OrderEventEntity = "item"
+	OrderEventShipment OrderEventEntity = "shipment"
+	OrderEventRefund   OrderEventEntity = "refund"
+)
+
+// ActorSystem is the actor of changes no user asked for, such as payment
+// webhooks and background jobs
+const ActorSystem = "system"
+
+// OrderEvent is one status change on an order or on one of its items,
+// shipments or refunds. FromStatus is nil when the entity was created.
+// Events of a seller's items, shipment and refunds carry the seller.
+type OrderEvent struct {
+	ID         int64            `db:"id"`
+	OrderID    int64            `db:"order_id"`
+	Entity     OrderEventEntity `db:"entity"`
+	EntityID   int64            `db:"entity_id"`
+	SellerID   *int64           `db:"seller_id"`
+	FromStatus *string          `db:"from_status"`
+	ToStatus   string           `db:"to_status"`
+	ActorRole  string           `db:"actor_role"`
+	ActorID    *int64           `db:"actor_id"`
+	CreatedAt  time.Time        `db:"created_at"`
+}
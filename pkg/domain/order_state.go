@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// ErrOrphanedPayment means a payment succeeded for an order that was cancelled
+// before it was ever paid. The order stays cancelled and the money must go back.
+var ErrOrphanedPayment = errors.New("payment succeeded for a cancelled order")
+
+// paymentTransitions lists where each payment status may move next. A failed
+// payment can still succeed through a new checkout session, everything after
+// a successful payment only goes towards refunded.
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentStatusPending:           {PaymentStatusSuccessful, PaymentStatusFailed, PaymentStatusExpired},
+	PaymentStatusFailed:            {PaymentStatusSuccessful, PaymentStatusExpired},
+	PaymentStatusSuccessful:        {PaymentStatusPartiallyRefunded, PaymentStatusRefunded},
+	PaymentStatusPartiallyRefunded: {PaymentStatusPartiallyRefunded, PaymentStatusRefunded},
+}
+
+// orderTransitions lists where each order status may move next. A paid order
+// is cancelled once none of its items are left, its payment is refunded apart.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending: {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:    {OrderStatusCancelled},
+}
+
+// OrderStatusFor is the order status implied by a payment status
+func OrderStatusFor(ps PaymentStatus) OrderStatus {
+	switch ps {
+	case PaymentStatusSuccessful, PaymentStatusPartiallyRefunded, PaymentStatusRefunded:
+		return OrderStatusPaid
+	case PaymentStatusExpired:
+		return OrderStatusCancelled
+	default:
+		return OrderStatusPending
+	}
+}
+
+// ReservationStatus is what the order's stock reservation becomes when its
+// payment reaches s. A declined payment keeps the stock reserved so the buyer
+// can retry in the session or a new one; it is released when the reservation
+// lapses or the order expires.
+func (s PaymentStatus) ReservationStatus() ReservationStatus {
+	switch s {
+	case PaymentStatusSuccessful, PaymentStatusPartiallyRefunded, PaymentStatusRefunded:
+		return ReservationCommitted
+	case PaymentStatusExpired:
+		return ReservationReleased
+	default:
+		return ReservationReserved
+	}
+}
+
+func (s PaymentStatus) CanTransitionTo(next PaymentStatus) bool {
+	for _, allowed := range paymentTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	if s == next {
+		return true
+	}
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Cancel cancels the order once none of its items are left. The payment of an
+// unpaid order expires; a paid one keeps its status until the refunds settle.
+func (o *Order) Cancel() error {
+	if o.Status == OrderStatusPending {
+		return o.TransitionPayment(PaymentStatusExpired)
+	}
+	if o.Status == OrderStatusCancelled || !o.Status.CanTransitionTo(OrderStatusCancelled) {
+		return fmt.Errorf("%w: order %s -> %s", ErrInvalidTransition, o.Status, OrderStatusCancelled)
+	}
+	o.Status = OrderStatusCancelled
+	return nil
+}
+
+// CanCancel checks the cancellation policy for the item: buyers may cancel it
+// only while it is pending, sellers until its shipment leaves. It returns
+// ErrInvalidTransition when the item cannot be cancelled by the role.
+func (i *OrderItem) CanCancel(by UserRole, shipment ShipmentStatus) error {
+	allowed := false
+	switch by {
+	case UserRoleSeller:
+		allowed = i.Status != OrderItemStatusCancelled &&
+			(shipment == ShipmentStatusPending || shipment == ShipmentStatusPacked)
+	default:
+		allowed = i.Status == OrderItemStatusPending
+	}
+	if !allowed {
+		return fmt.Errorf("%w: %s cannot cancel a %s item", ErrInvalidTransition, by, i.Status)
+	}
+	return nil
+}
+
+// TransitionPayment moves the order to the payment status and the order status
+// it implies. It returns ErrInvalidTransition when either move is not allowed,
+// and ErrOrphanedPayment for a success on an order cancelled while unpaid.
+func (o *Order) TransitionPayment(next PaymentStatus) error {
+	if next == PaymentStatusSuccessful && o.Status == OrderStatusCancelled && o.PaymentStatus == PaymentStatusExpired {
+		return fmt.Errorf("%w: order was cancelled before it was paid", ErrOrphanedPayment)
+	}
+	if !o.PaymentStatus.CanTransitionTo(next) {
+		return fmt.Errorf("%w: payment %s -> %s", ErrInvalidTransition, o.PaymentStatus, next)
+	}
+
+	status := OrderStatusFor(next)
+	if o.Status == OrderStatusCancelled && next != PaymentStatusSuccessful {
+		// A cancelled order stays cancelled while its payment is refunded
+		status = OrderStatusCancelled
+	}
+	if !o.Status.CanTransitionTo(status) {
+		return fmt.Errorf("%w: order %s -> %s", ErrInvalidTransition, o.Status, status)
+	}
+
+	o.PaymentStatus = next
+	o.Status = status
+	return nil
+}
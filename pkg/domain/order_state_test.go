@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOrderTransitionPaymentLateSuccess(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   Order
+		wantErr error
+	}{
+		{
+			name:  "pending order is paid",
+			order: Order{Status: OrderStatusPending, PaymentStatus: PaymentStatusPending},
+		},
+		{
+			name:  "declined order is paid through a new session",
+			order: Order{Status: OrderStatusPending, PaymentStatus: PaymentStatusFailed},
+		},
+		{
+			name:    "success after the order expired",
+			order:   Order{Status: OrderStatusCancelled, PaymentStatus: PaymentStatusExpired},
+			wantErr: ErrOrphanedPayment,
+		},
+		{
+			name:    "success after the payment was refunded",
+			order:   Order{Status: OrderStatusPaid, PaymentStatus: PaymentStatusRefunded},
+			wantErr: ErrInvalidTransition,
+		},
+		{
+			name:    "success on a paid order cancelled later",
+			order:   Order{Status: OrderStatusCancelled, PaymentStatus: PaymentStatusRefunded},
+			wantErr: ErrInvalidTransition,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := tt.order
+			err := order.TransitionPayment(PaymentStatusSuccessful)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("TransitionPayment() error = %v, want nil", err)
+				}
+				if order.Status != OrderStatusPaid || order.PaymentStatus != PaymentStatusSuccessful {
+					t.Fatalf("order is %s/%s, want paid/successful", order.Status, order.PaymentStatus)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("TransitionPayment() error = %v, want %v", err, tt.wantErr)
+			}
+			if order.Status != tt.order.Status || order.PaymentStatus != tt.order.PaymentStatus {
+				t.Fatalf("order changed to %s/%s on a rejected transition", order.Status, order.PaymentStatus)
+			}
+		})
+	}
+}
+
+func TestOrphanedPaymentIsNotInvalidTransition(t *testing.T) {
+	order := Order{Status: OrderStatusPending, PaymentStatus: PaymentStatusPending}
+	if err := order.Cancel(); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+
+	err := order.TransitionPayment(PaymentStatusSuccessful)
+	if !errors.Is(err, ErrOrphanedPayment) {
+		t.Fatalf("TransitionPayment() error = %v, want ErrOrphanedPayment", err)
+	}
+	// Webhook handling acknowledges invalid transitions, orphaned payments must be refunded instead
+	if errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("orphaned payment must not be reported as an invalid transition")
+	}
+}
+
+func TestOrderCancel(t *testing.T) {
+	tests := []struct {
+		name        string
+		order       Order
+		wantStatus  OrderStatus
+		wantPayment PaymentStatus
+		wantErr     bool
+	}{
+		{
+			name:        "unpaid order expires its payment",
+			order:       Order{Status: OrderStatusPending, PaymentStatus: PaymentStatusPending},
+			wantStatus:  OrderStatusCancelled,
+			wantPayment: PaymentStatusExpired,
+		},
+		{
+			name:        "paid order keeps its payment until the refunds settle",
+			order:       Order{Status: OrderStatusPaid, PaymentStatus: PaymentStatusSuccessful},
+			wantStatus:  OrderStatusCancelled,
+			wantPayment: PaymentStatusSuccessful,
+		},
+		{
+			name:    "cancelled order",
+			order:   Order{Status: OrderStatusCancelled, PaymentStatus: PaymentStatusExpired},
+			wantErr: true,
+		},
+		{
+			name:    "unknown status",
+			order:   Order{Status: OrderStatus("shipped"), PaymentStatus: PaymentStatusSuccessful},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := tt.order
+			err := order.Cancel()
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTransition) {
+					t.Fatalf("Cancel() error = %v, want ErrInvalidTransition", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Cancel() error = %v", err)
+			}
+			if order.Status != tt.wantStatus || order.PaymentStatus != tt.wantPayment {
+				t.Fatalf("order is %s/%s, want %s/%s", order.Status, order.PaymentStatus, tt.wantStatus, tt.wantPayment)
+			}
+		})
+	}
+}
+
+func TestPaymentStatusReservationStatus(t *testing.T) {
+	tests := []struct {
+		status PaymentStatus
+		want   ReservationStatus
+	}{
+		{PaymentStatusPending, ReservationReserved},
+		// The buyer may retry a declined payment, the stock stays theirs meanwhile
+		{PaymentStatusFailed, ReservationReserved},
+		{PaymentStatusSuccessful, ReservationCommitted},
+		{PaymentStatusExpired, ReservationReleased},
+		{PaymentStatusPartiallyRefunded, ReservationCommitted},
+		{PaymentStatusRefunded, ReservationCommitted},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			if got := tt.status.ReservationStatus(); got != tt.want {
+				t.Fatalf("ReservationStatus() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
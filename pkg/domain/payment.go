@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 type PaymentIntentStatus string
 
 const (
@@ -11,8 +13,47 @@ const (
 type PaymentIntent struct {
 	ID           string              `json:"id"`
 	OrderID      int64               `json:"order_id"`
-	Amount       float64             `json:"amount"`
-	Currency     string              `json:"currency"`
+	Amount       Money               `json:"amount"`
 	Status       PaymentIntentStatus `json:"status"`
 	ClientSecret string              `json:"client_secret"`
 }
+
+// PaymentAttemptStatus is the state of a single checkout attempt in the payments ledger
+type PaymentAttemptStatus string
+
+const (
+	PaymentAttemptOpen      PaymentAttemptStatus = "open"
+	PaymentAttemptSucceeded PaymentAttemptStatus = "succeeded"
+	PaymentAttemptFailed    PaymentAttemptStatus = "failed"
+	PaymentAttemptRefunded  PaymentAttemptStatus = "refunded"
+	PaymentAttemptExpired   PaymentAttemptStatus = "expired"
+	// PaymentAttemptOrphaned is a payment made after its order was cancelled;
+	// it is refunded in full automatically
+	PaymentAttemptOrphaned PaymentAttemptStatus = "orphaned"
+)
+
+// Payment is one checkout session created for an order
+type Payment struct {
+	ID                int64                `db:"id"`
+	OrderID           int64                `db:"order_id"`
+	Provider          string               `db:"provider"`
+	CheckoutSessionID string               `db:"checkout_session_id"`
+	PaymentIntentID   *string              `db:"payment_intent_id"`
+	Amount            Money                `db:"amount"`
+	Currency          string               `db:"currency"`
+	Status            PaymentAttemptStatus `db:"status"`
+	RawPayload        []byte               `db:"raw_payload"`
+	CreatedAt         time.Time            `db:"created_at"`
+	UpdatedAt         time.Time            `db:"updated_at"`
+}
+
+// PaymentTransition is an append-only record of a ledger status change
+type PaymentTransition struct {
+	ID         int64                 `db:"id"`
+	PaymentID  int64                 `db:"payment_id"`
+	FromStatus *PaymentAttemptStatus `db:"from_status"`
+	ToStatus   PaymentAttemptStatus  `db:"to_status"`
+	EventType  string                `db:"event_type"`
+	RawPayload []byte                `db:"raw_payload"`
+	CreatedAt  time.Time             `db:"created_at"`
+}
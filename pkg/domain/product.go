@@ -6,6 +6,20 @@ type Product struct {
 	ID          int64     `db:"id"`
 	Name        string    `db:"name"`
 	Description string    `db:"description"`
+	TaxClass    string    `db:"tax_class"`
 	CreatedAt   time.Time `db:"created_at"`
 	UpdatedAt   time.Time `db:"updated_at"`
+
+	// ArchivedAt is set once an admin deletes the product. It is no longer
+	// listed or sold, but orders keep referring to it.
+	ArchivedAt *time.Time `db:"archived_at"`
+}
+
+// ProductSearchResult is a product matching a search, with its rank and the
+// name and description as escaped HTML with the matched words wrapped in <mark> tags
+type ProductSearchResult struct {
+	Product
+	Rank          float64 `db:"rank"`
+	NameHighlight string  `db:"name_highlight"`
+	Snippet       string  `db:"snippet"`
 }
@@ -0,0 +1,83 @@
+package domain
+
+import "errors"
+
+// ProductSort is the order of a product listing
+type ProductSort string
+
+const (
+	ProductSortNewest      ProductSort = "newest"
+	ProductSortPriceAsc    ProductSort = "price_asc"
+	ProductSortPriceDesc   ProductSort = "price_desc"
+	ProductSortBestSelling ProductSort = "best_selling"
+)
+
+var ErrInvalidProductFilter = errors.New("invalid product filter")
+
+// ProductFilter narrows a product listing. Prices are compared in Currency,
+// against the best available offer of each product. CategorySlug takes in
+// the products of the category's descendants too.
+type ProductFilter struct {
+	MinPrice     *Money
+	MaxPrice     *Money
+	Currency     string
+	SellerID     *int64
+	InStock      bool
+	CategorySlug string
+	Sort         ProductSort
+}
+
+// Validate fills in the defaults and checks the filter makes sense
+func (f *ProductFilter) Validate() error {
+	if f.Currency == "" {
+		f.Currency = DefaultCurrency
+	}
+	if !IsSupportedCurrency(f.Currency) {
+		return ErrUnsupportedCurrency
+	}
+	if f.Sort == "" {
+		f.Sort = ProductSortNewest
+	}
+	switch f.Sort {
+	case ProductSortNewest, ProductSortPriceAsc, ProductSortPriceDesc, ProductSortBestSelling:
+	default:
+		return ErrInvalidProductFilter
+	}
+	for _, p := range []*Money{f.MinPrice, f.MaxPrice} {
+		if p == nil {
+			continue
+		}
+		if p.IsNegative() || p.Currency != f.Currency {
+			return ErrInvalidProductFilter
+		}
+	}
+	if f.MinPrice != nil && f.MaxPrice != nil && f.MinPrice.Amount > f.MaxPrice.Amount {
+		return ErrInvalidProductFilter
+	}
+	return nil
+}
+
+// ProductListing is a listed product with its best offer price in the
+// filter's currency. BestPrice is nil when no available offer has a price
+// that can be converted.
+type ProductListing struct {
+	Product
+	BestPrice *Money `db:"best_price"`
+	InStock   bool   `db:"in_stock"`
+	SoldCount int64  `db:"sold_count"`
+}
+
+// ProductFacets counts what each filter would leave, with the other filters
+// applied, so a sidebar can show them next to its options
+type ProductFacets struct {
+	MinPrice     *Money
+	MaxPrice     *Money
+	InStockCount int64
+	Sellers      []SellerFacet
+}
+
+type SellerFacet struct {
+	SellerID int64  `db:"seller_id"`
+	Username string `db:"username"`
+	Count    int64  `db:"count"`
+}
@@ -9,6 +9,7 @@ const (
 	RefundApproved  RefundStatus = "approved"
 	RefundRejected  RefundStatus = "rejected"
 	RefundCompleted RefundStatus = "completed"
+	RefundFailed    RefundStatus = "failed"
 )
 
 type Refund struct {
@@ -16,9 +17,14 @@ type Refund struct {
 	OrderItemID int64        `db:"order_item_id"`
 	RequesterID int64        `db:"requester_id"`
 	SellerID    int64        `db:"seller_id"`
-	Amount      float64      `db:"amount"`
+	Amount      Money        `db:"amount"`
+	Currency    string       `db:"currency"`
+	TaxAmount   Money        `db:"tax_amount"`
 	Reason      string       `db:"reason"`
 	Status      RefundStatus `db:"status"`
 	CreatedAt   time.Time    `db:"created_at"`
 	UpdatedAt   time.Time    `db:"updated_at"`
+
+	ProviderRefundID *string `db:"provider_refund_id"`
+	FailureReason    *string `db:"failure_reason"`
 }
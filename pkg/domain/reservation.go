@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+type ReservationStatus string
+
+const (
+	ReservationReserved  ReservationStatus = "reserved"
+	ReservationCommitted ReservationStatus = "committed"
+	ReservationReleased  ReservationStatus = "released"
+)
+
+// StockReservation holds offer stock for an order item between checkout
+// and payment confirmation.
+type StockReservation struct {
+	ID          int64             `db:"id"`
+	OrderID     int64             `db:"order_id"`
+	OrderItemID int64             `db:"order_item_id"`
+	OfferID     int64             `db:"offer_id"`
+	Quantity    int               `db:"quantity"`
+	Status      ReservationStatus `db:"status"`
+	ExpiresAt   time.Time         `db:"expires_at"`
+	CreatedAt   time.Time         `db:"created_at"`
+	UpdatedAt   time.Time         `db:"updated_at"`
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+type ShipmentStatus string
+
+const (
+	ShipmentStatusPending   ShipmentStatus = "pending"
+	ShipmentStatusPacked    ShipmentStatus = "packed"
+	ShipmentStatusShipped   ShipmentStatus = "shipped"
+	ShipmentStatusDelivered ShipmentStatus = "delivered"
+	// ShipmentStatusCancelled is set once every item of the shipment is cancelled
+	ShipmentStatusCancelled ShipmentStatus = "cancelled"
+)
+
+var shipmentTransitions = map[ShipmentStatus][]ShipmentStatus{
+	ShipmentStatusPending: {ShipmentStatusPacked, ShipmentStatusCancelled},
+	ShipmentStatusPacked:  {ShipmentStatusShipped, ShipmentStatusCancelled},
+	ShipmentStatusShipped: {ShipmentStatusDelivered},
+}
+
+func (s ShipmentStatus) CanTransitionTo(next ShipmentStatus) bool {
+	for _, allowed := range shipmentTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ItemStatus is the status of the live items of a shipment in this status
+func (s ShipmentStatus) ItemStatus() OrderItemStatus {
+	switch s {
+	case ShipmentStatusPacked, ShipmentStatusShipped:
+		return OrderItemStatusProcessing
+	case ShipmentStatusDelivered:
+		return OrderItemStatusDelivered
+	case ShipmentStatusCancelled:
+		return OrderItemStatusCancelled
+	default:
+		return OrderItemStatusPending
+	}
+}
+
+// Shipment is the part of an order one seller sends: all of the seller's items
+// in it travel together under one carrier and tracking number.
+type Shipment struct {
+	ID             int64          `db:"id"`
+	OrderID        int64          `db:"order_id"`
+	SellerID       int64          `db:"seller_id"`
+	Status         ShipmentStatus `db:"status"`
+	Carrier        string         `db:"carrier"`
+	TrackingNumber string         `db:"tracking_number"`
+	ShippedAt      *time.Time     `db:"shipped_at"`
+	DeliveredAt    *time.Time     `db:"delivered_at"`
+	CreatedAt      time.Time      `db:"created_at"`
+	UpdatedAt      time.Time      `db:"updated_at"`
+}
+
+// Transition moves the shipment to next. Shipping it needs a carrier and a
+// tracking number, which may come with the same update.
+func (s *Shipment) Transition(next ShipmentStatus, carrier, trackingNumber string) error {
+	if !s.Status.CanTransitionTo(next) {
+		return fmt.Errorf("%w: shipment %s -> %s", ErrInvalidTransition, s.Status, next)
+	}
+	if carrier != "" {
+		s.Carrier = carrier
+	}
+	if trackingNumber != "" {
+		s.TrackingNumber = trackingNumber
+	}
+	if next == ShipmentStatusShipped && (s.Carrier == "" || s.TrackingNumber == "") {
+		return fmt.Errorf("%w: carrier and tracking number are required to ship", ErrInvalidTransition)
+	}
+
+	now := time.Now()
+	switch next {
+	case ShipmentStatusShipped:
+		s.ShippedAt = &now
+	case ShipmentStatusDelivered:
+		s.DeliveredAt = &now
+	}
+	s.Status = next
+	return nil
+}
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type ShippingMethod string
+
+const (
+	// ShippingFlat charges the rate once per seller group
+	ShippingFlat ShippingMethod = "flat"
+	// ShippingPerItem charges the rate for every unit in the seller group
+	ShippingPerItem ShippingMethod = "per_item"
+	// ShippingFree is recorded for sellers without a shipping profile
+	ShippingFree ShippingMethod = "free"
+)
+
+var ErrInvalidShippingProfile = errors.New("invalid shipping profile")
+
+func IsValidShippingMethod(m ShippingMethod) bool {
+	return m == ShippingFlat || m == ShippingPerItem
+}
+
+// ShippingProfile is how a seller charges for delivery. Prices are in Currency.
+type ShippingProfile struct {
+	ID        int64          `db:"id"`
+	SellerID  int64          `db:"seller_id"`
+	Name      string         `db:"name"`
+	Method    ShippingMethod `db:"method"`
+	Rate      Money          `db:"rate"`
+	FreeAbove *Money         `db:"free_above"`
+	Currency  string         `db:"currency"`
+	IsDefault bool           `db:"is_default"`
+	CreatedAt time.Time      `db:"created_at"`
+	UpdatedAt time.Time      `db:"updated_at"`
+
+	Zones []ShippingZoneRate `db:"-"`
+}
+
+// ShippingZoneRate replaces the profile rate for a country, or a region of it
+type ShippingZoneRate struct {
+	ID        int64  `db:"id"`
+	ProfileID int64  `db:"profile_id"`
+	Country   string `db:"country"`
+	Region    string `db:"region"`
+	Rate      Money  `db:"rate"`
+}
+
+// RateFor picks the most specific rate for the destination: region, then country, then the profile rate
+func (p *ShippingProfile) RateFor(country, region string) Money {
+	rate := p.Rate
+	for _, z := range p.Zones {
+		if !strings.EqualFold(z.Country, country) {
+			continue
+		}
+		if z.Region == "" {
+			rate = z.Rate
+			continue
+		}
+		if strings.EqualFold(z.Region, region) {
+			return z.Rate
+		}
+	}
+	return rate
+}
+
+// Quote prices delivery of quantity units worth subtotal to the destination.
+// subtotal must be in the profile currency.
+func (p *ShippingProfile) Quote(quantity int, subtotal Money, country, region string) (Money, error) {
+	if p.FreeAbove != nil {
+		cmp, err := subtotal.Cmp(*p.FreeAbove)
+		if err != nil {
+			return Money{}, err
+		}
+		if cmp >= 0 {
+			return NewMoney(0, p.Currency), nil
+		}
+	}
+
+	rate := p.RateFor(country, region)
+	if p.Method == ShippingPerItem {
+		return rate.Mul(int64(quantity)), nil
+	}
+	return rate, nil
+}
+
+// Validate checks the method, currency and that no price is negative
+func (p *ShippingProfile) Validate() error {
+	switch {
+	case strings.TrimSpace(p.Name) == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidShippingProfile)
+	case !IsValidShippingMethod(p.Method):
+		return fmt.Errorf("%w: method must be flat or per_item", ErrInvalidShippingProfile)
+	case !IsSupportedCurrency(p.Currency):
+		return fmt.Errorf("%w: %w %q", ErrInvalidShippingProfile, ErrUnsupportedCurrency, p.Currency)
+	case p.Rate.IsNegative() || (p.FreeAbove != nil && p.FreeAbove.IsNegative()):
+		return fmt.Errorf("%w: rates cannot be negative", ErrInvalidShippingProfile)
+	}
+
+	seen := make(map[string]bool)
+	for _, z := range p.Zones {
+		key := strings.ToUpper(z.Country) + "/" + strings.ToUpper(z.Region)
+		switch {
+		case len(z.Country) != 2:
+			return fmt.Errorf("%w: zone country must be a 2-letter code", ErrInvalidShippingProfile)
+		case z.Rate.IsNegative():
+			return fmt.Errorf("%w: rates cannot be negative", ErrInvalidShippingProfile)
+		case seen[key]:
+			return fmt.Errorf("%w: duplicate zone %s", ErrInvalidShippingProfile, key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// ShippingLine is what one seller group of an order pays for delivery
+type ShippingLine struct {
+	ID        int64          `db:"id"`
+	OrderID   int64          `db:"order_id"`
+	SellerID  int64          `db:"seller_id"`
+	ProfileID *int64         `db:"profile_id"`
+	Name      string         `db:"name"`
+	Method    ShippingMethod `db:"method"`
+	Quantity  int            `db:"quantity"`
+	Amount    Money          `db:"amount"`
+	Currency  string         `db:"currency"`
+	CreatedAt time.Time      `db:"created_at"`
+}
@@ -0,0 +1,84 @@
+package domain
+
+import "testing"
+
+func TestShippingProfileRateFor(t *testing.T) {
+	profile := ShippingProfile{
+		Rate:     NewMoney(1000, CurrencyEUR),
+		Currency: CurrencyEUR,
+		Zones: []ShippingZoneRate{
+			{Country: "DE", Region: "BY", Rate: NewMoney(300, CurrencyEUR)},
+			{Country: "DE", Rate: NewMoney(500, CurrencyEUR)},
+			{Country: "FR", Region: "IDF", Rate: NewMoney(700, CurrencyEUR)},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		country string
+		region  string
+		want    int64
+	}{
+		{name: "region zone", country: "DE", region: "BY", want: 300},
+		{name: "region zone ignores case", country: "de", region: "by", want: 300},
+		{name: "other region falls back to the country zone", country: "DE", region: "BE", want: 500},
+		{name: "no region falls back to the country zone", country: "DE", want: 500},
+		{name: "country with only region zones falls back to the profile", country: "FR", region: "NOR", want: 1000},
+		{name: "region zone without a country zone", country: "FR", region: "IDF", want: 700},
+		{name: "country without zones falls back to the profile", country: "US", region: "CA", want: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := profile.RateFor(tt.country, tt.region); got.Amount != tt.want {
+				t.Fatalf("RateFor(%q, %q) = %d, want %d", tt.country, tt.region, got.Amount, tt.want)
+			}
+		})
+	}
+}
+
+func TestShippingProfileQuote(t *testing.T) {
+	freeAbove := NewMoney(5000, CurrencyEUR)
+
+	tests := []struct {
+		name      string
+		method    ShippingMethod
+		freeAbove *Money
+		quantity  int
+		subtotal  Money
+		want      int64
+		wantErr   bool
+	}{
+		{name: "flat below the threshold", method: ShippingFlat, freeAbove: &freeAbove, quantity: 3, subtotal: NewMoney(4999, CurrencyEUR), want: 500},
+		{name: "flat at the threshold is free", method: ShippingFlat, freeAbove: &freeAbove, quantity: 3, subtotal: NewMoney(5000, CurrencyEUR), want: 0},
+		{name: "flat above the threshold is free", method: ShippingFlat, freeAbove: &freeAbove, quantity: 3, subtotal: NewMoney(5001, CurrencyEUR), want: 0},
+		{name: "flat without a threshold", method: ShippingFlat, quantity: 3, subtotal: NewMoney(100000, CurrencyEUR), want: 500},
+		{name: "per item below the threshold", method: ShippingPerItem, freeAbove: &freeAbove, quantity: 3, subtotal: NewMoney(4999, CurrencyEUR), want: 1500},
+		{name: "per item at the threshold is free", method: ShippingPerItem, freeAbove: &freeAbove, quantity: 3, subtotal: NewMoney(5000, CurrencyEUR), want: 0},
+		{name: "threshold in another currency", method: ShippingFlat, freeAbove: &freeAbove, quantity: 1, subtotal: NewMoney(5000, CurrencyUSD), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := ShippingProfile{
+				Method:    tt.method,
+				Rate:      NewMoney(500, CurrencyEUR),
+				FreeAbove: tt.freeAbove,
+				Currency:  CurrencyEUR,
+			}
+			got, err := profile.Quote(tt.quantity, tt.subtotal, "DE", "")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Quote() = %d, want an error", got.Amount)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Quote() error = %v, want nil", err)
+			}
+			if got.Amount != tt.want || got.Currency != CurrencyEUR {
+				t.Fatalf("Quote() = %d %s, want %d %s", got.Amount, got.Currency, tt.want, CurrencyEUR)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TaxClassStandard is the class of products that don't name one
+const TaxClassStandard = "standard"
+
+var (
+	ErrInvalidTaxRate = errors.New("invalid tax rate")
+	taxClassPattern   = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+	maxTaxRatePercent = big.NewRat(100, 1)
+)
+
+func IsValidTaxClass(class string) bool {
+	return taxClassPattern.MatchString(class)
+}
+
+// TaxRate is the tax charged on a product tax class in a country, or in one
+// region of it. Rate is a percentage kept as decimal text, e.g. "20" or "7.25".
+// Inclusive rates are already part of the listed prices; exclusive ones are
+// added on top at checkout.
+type TaxRate struct {
+	ID        int64     `db:"id" json:"id"`
+	Country   string    `db:"country" json:"country"`
+	Region    string    `db:"region" json:"region"`
+	TaxClass  string    `db:"tax_class" json:"tax_class"`
+	Name      string    `db:"name" json:"name"`
+	Rate      string    `db:"rate" json:"rate"`
+	Inclusive bool      `db:"inclusive" json:"inclusive"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (t *TaxRate) percent() (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(t.Rate)
+	if !ok || rate.Sign() < 0 || rate.Cmp(maxTaxRatePercent) > 0 {
+		return nil, fmt.Errorf("%w: rate must be a percentage between 0 and 100, got %q", ErrInvalidTaxRate, t.Rate)
+	}
+	return rate, nil
+}
+
+func (t *TaxRate) Validate() error {
+	switch {
+	case len(t.Country) != 2:
+		return fmt.Errorf("%w: country must be a 2-letter code", ErrInvalidTaxRate)
+	case !IsValidTaxClass(t.TaxClass):
+		return fmt.Errorf("%w: tax class must be lowercase letters, digits or underscores", ErrInvalidTaxRate)
+	case strings.TrimSpace(t.Name) == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidTaxRate)
+	}
+	_, err := t.percent()
+	return err
+}
+
+// Compute returns the tax in a line worth amount, rounded half away from zero.
+// For an inclusive rate amount already contains the tax: tax = amount * r / (100 + r).
+// For an exclusive rate the tax comes on top: tax = amount * r / 100.
+func (t *TaxRate) Compute(amount Money) (Money, error) {
+	rate, err := t.percent()
+	if err != nil {
+		return Money{}, err
+	}
+
+	divisor := new(big.Rat).Set(maxTaxRatePercent)
+	if t.Inclusive {
+		divisor.Add(divisor, rate)
+	}
+	share := new(big.Rat).Quo(rate, divisor)
+
+	minor, err := roundMinor(new(big.Rat).Mul(new(big.Rat).SetInt64(amount.Amount), share))
+	if err != nil {
+		return Money{}, err
+	}
+	return NewMoney(minor, amount.Currency), nil
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTaxRateCompute(t *testing.T) {
+	tests := []struct {
+		name      string
+		rate      string
+		inclusive bool
+		amount    int64
+		want      int64
+		wantErr   error
+	}{
+		{name: "exclusive whole", rate: "20", amount: 1000, want: 200},
+		{name: "exclusive rounds down", rate: "7.25", amount: 999, want: 72},
+		{name: "exclusive half rounds up", rate: "10", amount: 15, want: 2},
+		{name: "exclusive half of a cent", rate: "10", amount: 5, want: 1},
+		{name: "exclusive negative half rounds away from zero", rate: "10", amount: -5, want: -1},
+		{name: "exclusive zero rate", rate: "0", amount: 1234, want: 0},
+		{name: "inclusive whole", rate: "20", inclusive: true, amount: 1200, want: 200},
+		{name: "inclusive rounds down", rate: "20", inclusive: true, amount: 1000, want: 167},
+		{name: "inclusive too small to tax", rate: "20", inclusive: true, amount: 2, want: 0},
+		{name: "inclusive half rounds up", rate: "100", inclusive: true, amount: 3, want: 2},
+		{name: "inclusive fractional rate", rate: "7.25", inclusive: true, amount: 10725, want: 725},
+		{name: "rate is not a number", rate: "abc", amount: 1000, wantErr: ErrInvalidTaxRate},
+		{name: "rate above 100", rate: "100.01", amount: 1000, wantErr: ErrInvalidTaxRate},
+		{name: "negative rate", rate: "-1", amount: 1000, wantErr: ErrInvalidTaxRate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate := TaxRate{Rate: tt.rate, Inclusive: tt.inclusive}
+			got, err := rate.Compute(NewMoney(tt.amount, CurrencyEUR))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Compute() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Compute() error = %v, want nil", err)
+			}
+			if got.Amount != tt.want || got.Currency != CurrencyEUR {
+				t.Fatalf("Compute() = %d %s, want %d %s", got.Amount, got.Currency, tt.want, CurrencyEUR)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type AttributeType string
+
+const (
+	AttributeText    AttributeType = "text"
+	AttributeNumber  AttributeType = "number"
+	AttributeBoolean AttributeType = "boolean"
+	AttributeEnum    AttributeType = "enum"
+)
+
+var (
+	ErrInvalidAttribute  = errors.New("invalid attribute")
+	ErrInvalidVariant    = errors.New("invalid variant")
+	attributeCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+	skuPattern           = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+	// plainSKUPattern matches the SKU a product's plain variant is created
+	// with, product-<product id>
+	plainSKUPattern = regexp.MustCompile(`^product-([0-9]+)$`)
+)
+
+// PlainVariantSKU is the SKU of the plain variant a product is created with.
+// Other products cannot take it, so creating the product never clashes.
+func PlainVariantSKU(productID int64) string {
+	return "product-" + strconv.FormatInt(productID, 10)
+}
+
+// CategoryAttribute is a typed attribute the variants of a category's
+// products are described by, e.g. a "size" enum of S, M and L. Subcategories
+// inherit the attributes of their ancestors.
+type CategoryAttribute struct {
+	ID         int64            `db:"id"`
+	CategoryID int64            `db:"category_id"`
+	Code       string           `db:"code"`
+	Name       string           `db:"name"`
+	Type       AttributeType    `db:"type"`
+	Options    AttributeOptions `db:"options"`
+	Position   int              `db:"position"`
+	CreatedAt  time.Time        `db:"created_at"`
+	UpdatedAt  time.Time        `db:"updated_at"`
+}
+
+func (a *CategoryAttribute) Validate() error {
+	switch {
+	case !attributeCodePattern.MatchString(a.Code):
+		return fmt.Errorf("%w: code must be lowercase letters, digits or underscores", ErrInvalidAttribute)
+	case strings.TrimSpace(a.Name) == "":
+		return fmt.Errorf("%w: name is required", ErrInvalidAttribute)
+	}
+
+	switch a.Type {
+	case AttributeEnum:
+		if len(a.Options) == 0 {
+			return fmt.Errorf("%w: an enum needs options", ErrInvalidAttribute)
+		}
+		seen := make(map[string]bool, len(a.Options))
+		for _, o := range a.Options {
+			if strings.TrimSpace(o) == "" || seen[o] {
+				return fmt.Errorf("%w: options must be distinct and not blank", ErrInvalidAttribute)
+			}
+			seen[o] = true
+		}
+	case AttributeText, AttributeNumber, AttributeBoolean:
+		if len(a.Options) > 0 {
+			return fmt.Errorf("%w: only an enum has options", ErrInvalidAttribute)
+		}
+	default:
+		return fmt.Errorf("%w: type must be text, number, boolean or enum", ErrInvalidAttribute)
+	}
+	return nil
+}
+
+// check tells whether the value fits the attribute's type
+func (a *CategoryAttribute) check(value interface{}) error {
+	ok := false
+	switch v := value.(type) {
+	case string:
+		switch a.Type {
+		case AttributeText:
+			ok = strings.TrimSpace(v) != "" && len(v) <= 100
+		case AttributeEnum:
+			for _, o := range a.Options {
+				ok = ok || o == v
+			}
+		}
+	case float64:
+		ok = a.Type == AttributeNumber
+	case bool:
+		ok = a.Type == AttributeBoolean
+	}
+	if !ok {
+		return fmt.Errorf("%w: %v is not a valid %s for %q", ErrInvalidVariant, value, a.Type, a.Code)
+	}
+	return nil
+}
+
+// AttributeOptions are the allowed values of an enum attribute, stored as JSONB
+type AttributeOptions []string
+
+func (o AttributeOptions) Value() (driver.Value, error) {
+	if o == nil {
+		o = AttributeOptions{}
+	}
+	b, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (o *AttributeOptions) Scan(src interface{}) error {
+	return scanJSON("attribute options", src, o)
+}
+
+// AttributeValues are a variant's attribute values by attribute code, stored
+// as JSONB. Numbers are float64, as JSON decodes them.
+type AttributeValues map[string]interface{}
+
+func (v AttributeValues) Value() (driver.Value, error) {
+	if v == nil {
+		v = AttributeValues{}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (v *AttributeValues) Scan(src interface{}) error {
+	return scanJSON("attribute values", src, v)
+}
+
+func scanJSON(what string, src interface{}, dst interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, dst)
+	case string:
+		return json.Unmarshal([]byte(v), dst)
+	}
+	return fmt.Errorf("%s: cannot scan %T", what, src)
+}
+
+// ProductVariant is a sellable version of a product, such as the medium red
+// shirt. Offers are made for variants. A product without attributes has one
+// plain variant with no attribute values.
+type ProductVariant struct {
+	ID         int64           `db:"id"`
+	ProductID  int64           `db:"product_id"`
+	SKU        string          `db:"sku"`
+	Attributes AttributeValues `db:"attributes"`
+	CreatedAt  time.Time       `db:"created_at"`
+	UpdatedAt  time.Time       `db:"updated_at"`
+}
+
+// Validate checks the SKU, which may not be another product's plain variant
+// SKU, and that every attribute value is defined by the product's attributes
+// and fits its type
+func (v *ProductVariant) Validate(schema []CategoryAttribute) error {
+	if !skuPattern.MatchString(v.SKU) {
+		return fmt.Errorf("%w: sku must be up to 64 letters, digits, dots, dashes or underscores", ErrInvalidVariant)
+	}
+	if plainSKUPattern.MatchString(v.SKU) && v.SKU != PlainVariantSKU(v.ProductID) {
+		return fmt.Errorf("%w: sku %q is reserved for the plain variant of another product", ErrInvalidVariant, v.SKU)
+	}
+
+	byCode := make(map[string]*CategoryAttribute, len(schema))
+	for i := range schema {
+		byCode[schema[i].Code] = &schema[i]
+	}
+	for code, value := range v.Attributes {
+		attr, ok := byCode[code]
+		if !ok {
+			return fmt.Errorf("%w: the product's categories have no attribute %q", ErrInvalidVariant, code)
+		}
+		if err := attr.check(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VariantOffer is the best offer of a variant: the cheapest available one
+// with stock, its price converted into the currency asked for
+type VariantOffer struct {
+	OfferID  int64 `db:"offer_id"`
+	SellerID int64 `db:"seller_id"`
+	Price    Money `db:"price"`
+	Stock    int   `db:"stock"`
+}
+
+// VariantWithOffer is a row of a product's variant matrix
+type VariantWithOffer struct {
+	ProductVariant
+	OfferCount int64         `db:"offer_count"`
+	BestOffer  *VariantOffer `db:"-"`
+}
+
+// VariantAxis is an attribute the product's variants differ by, with the
+// values they use: in option order for an enum, otherwise as first seen
+type VariantAxis struct {
+	CategoryAttribute
+	Values []interface{}
+}
+
+// VariantAxes returns the axes of the variant matrix, one per attribute of
+// the schema that some variant has a value for
+func VariantAxes(schema []CategoryAttribute, variants []ProductVariant) []VariantAxis {
+	axes := []VariantAxis{}
+	for _, attr := range schema {
+		axis := VariantAxis{CategoryAttribute: attr}
+		seen := map[interface{}]bool{}
+		for _, v := range variants {
+			if value, ok := v.Attributes[attr.Code]; ok && !seen[value] {
+				seen[value] = true
+				axis.Values = append(axis.Values, value)
+			}
+		}
+		if len(axis.Values) == 0 {
+			continue
+		}
+		if attr.Type == AttributeEnum {
+			axis.Values = axis.Values[:0]
+			for _, o := range attr.Options {
+				if seen[o] {
+					axis.Values = append(axis.Values, o)
+				}
+			}
+		}
+		axes = append(axes, axis)
+	}
+	return axes
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+type WebhookEventStatus string
+
+const (
+	WebhookEventProcessing WebhookEventStatus = "processing"
+	WebhookEventProcessed  WebhookEventStatus = "processed"
+	WebhookEventFailed     WebhookEventStatus = "failed"
+)
+
+// WebhookEvent is a provider notification as it was received, keyed by the provider event ID
+type WebhookEvent struct {
+	ID          string             `db:"id"`
+	Provider    string             `db:"provider"`
+	EventType   string             `db:"event_type"`
+	Status      WebhookEventStatus `db:"status"`
+	Attempts    int                `db:"attempts"`
+	LastError   *string            `db:"last_error"`
+	Payload     []byte             `db:"payload"`
+	ReceivedAt  time.Time          `db:"received_at"`
+	UpdatedAt   time.Time          `db:"updated_at"`
+	ProcessedAt *time.Time         `db:"processed_at"`
+}
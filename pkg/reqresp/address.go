@@ -0,0 +1,32 @@
+package reqresp
+
+import "time"
+
+// AddressRequest creates or replaces an address book entry
+type AddressRequest struct {
+	FullName   string `json:"full_name" validate:"required,max=255" example:"Aigerim Sadykova"`
+	Phone      string `json:"phone" validate:"required,max=32" example:"+77011234567"`
+	Line1      string `json:"line1" validate:"required,max=255" example:"12 Abay Ave"`
+	Line2      string `json:"line2" validate:"max=255" example:"Apt 4"`
+	City       string `json:"city" validate:"required,max=100" example:"Almaty"`
+	Region     string `json:"region" validate:"max=100" example:"Almaty Region"`
+	PostalCode string `json:"postal_code" validate:"required,max=20" example:"050000"`
+	// ISO 3166-1 alpha-2 country code
+	Country   string `json:"country" validate:"required,len=2" example:"KZ"`
+	IsDefault bool   `json:"is_default" example:"true"`
+}
+
+type AddressResponse struct {
+	ID         int64     `json:"id"`
+	FullName   string    `json:"full_name"`
+	Phone      string    `json:"phone"`
+	Line1      string    `json:"line1"`
+	Line2      string    `json:"line2,omitempty"`
+	City       string    `json:"city"`
+	Region     string    `json:"region,omitempty"`
+	PostalCode string    `json:"postal_code"`
+	Country    string    `json:"country"`
+	IsDefault  bool      `json:"is_default"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
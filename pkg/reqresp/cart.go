@@ -1,15 +1,23 @@
 package reqresp
 
+import "go-app-marketplace/pkg/domain"
+
 type AddItemToCartRequest struct {
 	OfferID  int64 `json:"offer_id" validate:"required"`
 	Quantity int   `json:"quantity" validate:"required,min=1"`
 }
 
 type CartItemResponse struct {
-	OfferID     int64   `json:"offer_id"`
-	ProductID   int64   `json:"product_id"`
-	SellerID    int64   `json:"seller_id"`
-	Price       float64 `json:"price"`
-	Quantity    int     `json:"quantity"`
-	IsAvailable bool    `json:"is_available"`
+	OfferID   int64        `json:"offer_id"`
+	ProductID int64        `json:"product_id"`
+	SellerID  int64        `json:"seller_id"`
+	Price     domain.Money `json:"price"`
+	// Price in the requested currency, missing when there is no exchange rate for it
+	DisplayPrice *domain.Money `json:"display_price,omitempty"`
+	Quantity     int           `json:"quantity"`
+	IsAvailable  bool          `json:"is_available"`
+
+	VariantID  *int64                 `json:"variant_id,omitempty"`
+	SKU        string                 `json:"sku,omitempty" example:"TSHIRT-RED-M"`
+	Attributes domain.AttributeValues `json:"attributes,omitempty"`
 }
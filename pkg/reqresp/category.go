@@ -0,0 +1,62 @@
+package reqresp
+
+// CategoryRequest creates or replaces a category. Without a slug one is made
+// from the name; without a parent the category is a root.
+type CategoryRequest struct {
+	Name     string `json:"name" validate:"required,max=100" example:"T-Shirts"`
+	Slug     string `json:"slug,omitempty" validate:"omitempty,max=100" example:"t-shirts"`
+	ParentID *int64 `json:"parent_id,omitempty" validate:"omitempty,min=1" example:"1"`
+	Position int    `json:"position" example:"0"`
+}
+
+type CategoryResponse struct {
+	ID       int64  `json:"id" example:"2"`
+	ParentID *int64 `json:"parent_id,omitempty" example:"1"`
+	Name     string `json:"name" example:"T-Shirts"`
+	Slug     string `json:"slug" example:"t-shirts"`
+	Position int    `json:"position" example:"0"`
+}
+
+// CategoryTreeResponse is a category with its subcategories. ProductCount
+// includes the products of the subcategories, each counted once.
+type CategoryTreeResponse struct {
+	ID           int64                  `json:"id" example:"1"`
+	Name         string                 `json:"name" example:"Clothing"`
+	Slug         string                 `json:"slug" example:"clothing"`
+	Position     int                    `json:"position" example:"0"`
+	ProductCount int64                  `json:"product_count" example:"42"`
+	Children     []CategoryTreeResponse `json:"children"`
+}
+
+// CategoryRef names a category in a product's categories or breadcrumb
+type CategoryRef struct {
+	ID   int64  `json:"id" example:"2"`
+	Name string `json:"name" example:"T-Shirts"`
+	Slug string `json:"slug" example:"t-shirts"`
+}
+
+// ProductCategoriesRequest replaces the categories of a product; an empty
+// list takes it out of all of them
+type ProductCategoriesRequest struct {
+	CategoryIDs []int64 `json:"category_ids" validate:"required,dive,min=1" example:"1,2"`
+}
+
+// AttributeRequest defines a typed attribute for the variants of a category's
+// products. Options list the values of an enum and are left out otherwise.
+type AttributeRequest struct {
+	Code     string   `json:"code" validate:"required,max=32" example:"size"`
+	Name     string   `json:"name" validate:"required,max=100" example:"Size"`
+	Type     string   `json:"type" validate:"required,oneof=text number boolean enum" example:"enum"`
+	Options  []string `json:"options,omitempty" example:"S,M,L"`
+	Position int      `json:"position" example:"0"`
+}
+
+type AttributeResponse struct {
+	ID         int64    `json:"id" example:"1"`
+	CategoryID int64    `json:"category_id" example:"2"`
+	Code       string   `json:"code" example:"size"`
+	Name       string   `json:"name" example:"Size"`
+	Type       string   `json:"type" example:"enum"`
+	Options    []string `json:"options" example:"S,M,L"`
+	Position   int      `json:"position" example:"0"`
+}
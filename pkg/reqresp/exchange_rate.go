@@ -0,0 +1,8 @@
+package reqresp
+
+// ExchangeRateRequest sets how many units of quote one unit of base buys
+type ExchangeRateRequest struct {
+	Base  string `json:"base" validate:"required,len=3" example:"EUR"`
+	Quote string `json:"quote" validate:"required,len=3" example:"USD"`
+	Rate  string `json:"rate" validate:"required" example:"1.0850"`
+}
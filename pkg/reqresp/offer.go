@@ -1,11 +1,15 @@
 package reqresp
 
+import "go-app-marketplace/pkg/domain"
+
 // OfferCreateRequest represents the payload to create a new offer
 type OfferCreateRequest struct {
-	// The ID of the product for which the offer is being created
-	ProductID int64 `json:"product_id" validate:"required" example:"1" extensions:"x-order=1"`
-	// The price of the offer
-	Price float64 `json:"price" validate:"required" example:"29.99" extensions:"x-order=2"`
+	// The ID of the product for which the offer is being created; enough on its own when the product has a single variant
+	ProductID int64 `json:"product_id,omitempty" example:"1" extensions:"x-order=1"`
+	// The ID of the product variant being offered
+	VariantID int64 `json:"variant_id,omitempty" example:"3" extensions:"x-order=1"`
+	// The price of the offer: "29.99" in USD, or {"amount":"29.99","currency":"EUR"}
+	Price domain.Money `json:"price" swaggertype:"string" example:"29.99" extensions:"x-order=2"`
 	// The available stock quantity
 	Stock int `json:"stock" validate:"required" example:"100" extensions:"x-order=3"`
 	// Whether the offer is currently available for purchase
@@ -20,8 +24,8 @@ type OfferCreateResponse struct {
 
 // OfferUpdateRequest represents the payload to update an existing offer
 type OfferUpdateRequest struct {
-	// The updated price of the offer
-	Price float64 `json:"price" validate:"required" example:"39.99" extensions:"x-order=1"`
+	// The updated price of the offer, in USD unless given as {"amount","currency"}
+	Price domain.Money `json:"price" swaggertype:"string" example:"39.99" extensions:"x-order=1"`
 	// The updated stock quantity
 	Stock int `json:"stock" validate:"required" example:"50" extensions:"x-order=2"`
 	// Whether the offer should be available for purchase
@@ -34,10 +38,12 @@ type OfferResponse struct {
 	ID int64 `json:"id" example:"1" extensions:"x-order=1"`
 	// The product ID this offer is for
 	ProductID int64 `json:"product_id" example:"42" extensions:"x-order=2"`
+	// The product variant this offer is for
+	VariantID int64 `json:"variant_id" example:"3" extensions:"x-order=2"`
 	// The ID of the seller who created the offer
 	SellerID int64 `json:"seller_id" example:"5" extensions:"x-order=3"`
 	// The price of the product in this offer
-	Price float64 `json:"price" example:"29.99" extensions:"x-order=4"`
+	Price domain.Money `json:"price" swaggertype:"object" extensions:"x-order=4"`
 	// The available stock quantity
 	Stock int `json:"stock" example:"100" extensions:"x-order=5"`
 	// Whether the offer is currently available for purchase
@@ -52,30 +58,20 @@ type OfferResponse struct {
 type OfferShortResponse struct {
 	// The unique identifier of the offer
 	ID int64 `json:"id" example:"1" extensions:"x-order=1"`
+	// The product variant this offer is for
+	VariantID int64 `json:"variant_id" example:"3" extensions:"x-order=1"`
 	// The ID of the seller who created the offer
 	SellerID int64 `json:"seller_id" example:"5" extensions:"x-order=2"`
 	// The price of the product in this offer
-	Price float64 `json:"price" example:"29.99" extensions:"x-order=3"`
+	Price domain.Money `json:"price" swaggertype:"object" extensions:"x-order=3"`
+	// The price converted into the requested currency, missing when there is no exchange rate for it
+	DisplayPrice *domain.Money `json:"display_price,omitempty" swaggertype:"object" extensions:"x-order=3"`
 	// The available stock quantity
 	Stock int `json:"stock" example:"100" extensions:"x-order=4"`
 	// Whether the offer is currently available for purchase
 	IsAvailable bool `json:"is_available" example:"true" extensions:"x-order=5"`
 }
 
-// OfferFilterRequest represents filter parameters for listing offers
-type OfferFilterRequest struct {
-	// Minimum price to filter by
-	MinPrice *float64 `json:"min_price,omitempty" query:"min_price" example:"10.00"`
-	// Maximum price to filter by
-	MaxPrice *float64 `json:"max_price,omitempty" query:"max_price" example:"100.00"`
-	// Only show available offers
-	OnlyAvailable *bool `json:"only_available,omitempty" query:"only_available" example:"true"`
-	// Page number for pagination
-	Page int `json:"page,omitempty" query:"page" validate:"min=1" example:"1" default:"1"`
-	// Number of items per page
-	PerPage int `json:"per_page,omitempty" query:"per_page" validate:"min=1,max=100" example:"20" default:"20"`
-}
-
 // OfferListResponse represents a paginated list of offers
 type OfferListResponse struct {
 	// List of offers
@@ -1,5 +1,10 @@
 package reqresp
 
+import (
+	"go-app-marketplace/pkg/domain"
+	"time"
+)
+
 const (
 	OrderStatusPending   = "pending"
 	OrderStatusPaid      = "paid"
@@ -10,38 +15,192 @@ const (
 	PaymentStatusFailed     = "failed"
 )
 
+// CheckoutRequest picks the shipping address from the address book and the
+// currency the buyer pays in; an empty currency keeps the cart's currency.
+type CheckoutRequest struct {
+	AddressID int64  `json:"address_id" validate:"required" example:"1"`
+	Currency  string `json:"currency,omitempty" example:"EUR"`
+
+	// Token from POST /api/orders/quote; checkout then charges the quoted prices
+	QuoteToken string `json:"quote_token,omitempty"`
+
+	// Shipping profile per seller, from the seller's shipping_options in the
+	// preview or quote; sellers left out ship by their default profile
+	Shipping []ShippingChoiceRequest `json:"shipping,omitempty"`
+}
+
+// ShippingChoiceRequest picks one of the seller's shipping profiles
+type ShippingChoiceRequest struct {
+	SellerID  int64 `json:"seller_id" example:"7"`
+	ProfileID int64 `json:"profile_id" example:"3"`
+}
+
 type CheckoutResponse struct {
-	OrderID     int64   `json:"order_id"`
-	TotalAmount float64 `json:"total_amount"`
-	PaymentURL  string  `json:"payment_url"`
+	OrderID     int64        `json:"order_id"`
+	TotalAmount domain.Money `json:"total_amount"`
+	PaymentURL  string       `json:"payment_url"`
 }
 
 type OrderItemCreateInput struct {
-	OfferID   int64   `json:"offer_id" validate:"required"`
-	Quantity  int     `json:"quantity" validate:"required,min=1"`
-	UnitPrice float64 `json:"unit_price" validate:"required,min=0"`
+	OfferID   int64        `json:"offer_id" validate:"required"`
+	Quantity  int          `json:"quantity" validate:"required,min=1"`
+	UnitPrice domain.Money `json:"unit_price"`
 }
 
 type OrderResponse struct {
 	ID            int64               `json:"id"`
 	UserID        int64               `json:"user_id"`
-	TotalAmount   float64             `json:"total_amount"`
+	TotalAmount   domain.Money        `json:"total_amount"`
 	Status        string              `json:"status"`
 	PaymentStatus string              `json:"payment_status"`
 	Items         []OrderItemResponse `json:"items"`
 	CreatedAt     string              `json:"created_at"`
+
+	ExchangeRates   []domain.ExchangeRate   `json:"exchange_rates,omitempty"`
+	ShippingAddress *domain.AddressSnapshot `json:"shipping_address,omitempty"`
+	ShippingAmount  domain.Money            `json:"shipping_amount"`
+	Shipping        []ShippingLineResponse  `json:"shipping,omitempty"`
+	TaxAmount       domain.Money            `json:"tax_amount"`
+	Shipments       []ShipmentResponse      `json:"shipments"`
+}
+
+// ShippingLineResponse is the delivery charge of one seller's items
+type ShippingLineResponse struct {
+	SellerID int64 `json:"seller_id"`
+	// ProfileID is missing for free shipping of a seller without profiles
+	ProfileID *int64       `json:"profile_id,omitempty"`
+	Name      string       `json:"name"`
+	Method    string       `json:"method"`
+	Quantity  int          `json:"quantity"`
+	Amount    domain.Money `json:"amount"`
+}
+
+// CheckoutPreviewResponse is what Checkout would charge right now
+type CheckoutPreviewResponse struct {
+	Currency        string                  `json:"currency"`
+	Subtotal        domain.Money            `json:"subtotal"`
+	ShippingAmount  domain.Money            `json:"shipping_amount"`
+	TaxAmount       domain.Money            `json:"tax_amount"`
+	TotalAmount     domain.Money            `json:"total_amount"`
+	Sellers         []CheckoutPreviewSeller `json:"sellers"`
+	ExchangeRates   []domain.ExchangeRate   `json:"exchange_rates,omitempty"`
+	ShippingAddress *domain.AddressSnapshot `json:"shipping_address"`
+}
+
+// CheckoutPreviewSeller groups the items shipped by one seller
+type CheckoutPreviewSeller struct {
+	SellerID  int64                 `json:"seller_id"`
+	Items     []CheckoutPreviewItem `json:"items"`
+	Subtotal  domain.Money          `json:"subtotal"`
+	TaxAmount domain.Money          `json:"tax_amount"`
+	Shipping  ShippingLineResponse  `json:"shipping"`
+
+	// ShippingOptions is what each of the seller's profiles would charge, the default first
+	ShippingOptions []ShippingLineResponse `json:"shipping_options"`
+}
+
+// CheckoutQuoteResponse is the preview of what can be bought right now. The
+// quote token is left out while an issue keeps the cart from checking out.
+// There are no promotions yet, so the discount is always zero.
+type CheckoutQuoteResponse struct {
+	CheckoutPreviewResponse
+	DiscountAmount domain.Money        `json:"discount_amount"`
+	Issues         []CartIssueResponse `json:"issues"`
+	QuoteToken     string              `json:"quote_token,omitempty"`
+	ExpiresAt      *time.Time          `json:"expires_at,omitempty"`
+}
+
+// CartIssueResponse reports a cart item that changed since it was added:
+// unavailable, insufficient_stock (with the units left) or price_changed
+type CartIssueResponse struct {
+	OfferID       int64         `json:"offer_id"`
+	Reason        string        `json:"reason" example:"price_changed"`
+	Available     *int          `json:"available,omitempty"`
+	PreviousPrice *domain.Money `json:"previous_price,omitempty"`
+	CurrentPrice  *domain.Money `json:"current_price,omitempty"`
+}
+
+type CheckoutPreviewItem struct {
+	OfferID   int64        `json:"offer_id"`
+	ProductID int64        `json:"product_id"`
+	Quantity  int          `json:"quantity"`
+	UnitPrice domain.Money `json:"unit_price"`
+
+	Tax *ItemTaxResponse `json:"tax,omitempty"`
 }
 
 type OrderItemResponse struct {
-	ID        int64   `json:"id"`
-	OfferID   int64   `json:"offer_id"`
-	ProductID int64   `json:"product_id"`
-	SellerID  int64   `json:"seller_id"`
-	Quantity  int     `json:"quantity"`
-	UnitPrice float64 `json:"unit_price"`
-	Status    string  `json:"status"`
+	ID        int64        `json:"id"`
+	OfferID   int64        `json:"offer_id"`
+	ProductID int64        `json:"product_id"`
+	SellerID  int64        `json:"seller_id"`
+	Quantity  int          `json:"quantity"`
+	UnitPrice domain.Money `json:"unit_price"`
+	Status    string       `json:"status"`
+
+	// The variant as it was ordered, even if it changed or was removed since
+	VariantID  *int64                 `json:"variant_id,omitempty"`
+	SKU        string                 `json:"sku,omitempty" example:"TSHIRT-RED-M"`
+	Attributes domain.AttributeValues `json:"attributes,omitempty"`
+
+	Tax                *ItemTaxResponse `json:"tax,omitempty"`
+	ShipmentID         int64            `json:"shipment_id"`
+	CancellationReason string           `json:"cancellation_reason,omitempty"`
+}
+
+// ItemTaxResponse is the tax on a whole item line; inclusive tax is part of
+// its price, exclusive tax was charged on top
+type ItemTaxResponse struct {
+	Name      string       `json:"name"`
+	Rate      string       `json:"rate" example:"20"`
+	Inclusive bool         `json:"inclusive"`
+	Amount    domain.Money `json:"amount"`
+}
+
+// SellerCancelItemRequest explains to the buyer why the seller cancelled the item
+type SellerCancelItemRequest struct {
+	Reason string `json:"reason" validate:"required,max=500" example:"Damaged in the warehouse"`
+}
+
+// CancelOrderItemResponse is the order total after the cancellation and, on a
+// paid order, the refund sent to the payment provider
+type CancelOrderItemResponse struct {
+	ItemID      int64        `json:"item_id"`
+	OrderID     int64        `json:"order_id"`
+	TotalAmount domain.Money `json:"total_amount"`
+
+	RefundID     *int64        `json:"refund_id,omitempty"`
+	RefundAmount *domain.Money `json:"refund_amount,omitempty"`
+	RefundFailed bool          `json:"refund_failed,omitempty"`
+}
+
+// CancelOrderResponse reports a whole-order cancellation item by item. The
+// order is only cancelled when no item was skipped.
+type CancelOrderResponse struct {
+	OrderID       int64                     `json:"order_id"`
+	Status        string                    `json:"status"`
+	PaymentStatus string                    `json:"payment_status"`
+	TotalAmount   domain.Money              `json:"total_amount"`
+	Cancelled     []CancelOrderItemResponse `json:"cancelled"`
+	Skipped       []SkippedItemResponse     `json:"skipped"`
+}
+
+// SkippedItemResponse is an item that can no longer be cancelled
+type SkippedItemResponse struct {
+	ItemID int64  `json:"item_id"`
+	Status string `json:"status" example:"processing"`
+	Reason string `json:"reason" example:"item is already processing"`
 }
 
-type UpdateOrderItemStatusRequest struct {
-	Status string `json:"status" validate:"required,oneof=processing delivered"`
+// OrderEventResponse is one status change in an order's timeline. Entity is
+// order, payment, item, shipment or refund; from_status is null on creation.
+type OrderEventResponse struct {
+	Entity     string    `json:"entity" example:"shipment"`
+	EntityID   int64     `json:"entity_id"`
+	SellerID   *int64    `json:"seller_id,omitempty"`
+	FromStatus *string   `json:"from_status" example:"packed"`
+	ToStatus   string    `json:"to_status" example:"shipped"`
+	ActorRole  string    `json:"actor_role" example:"seller"`
+	ActorID    *int64    `json:"actor_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
 }
@@ -0,0 +1,46 @@
+package reqresp
+
+import (
+	"encoding/json"
+	"go-app-marketplace/pkg/domain"
+	"time"
+)
+
+type PaymentResponse struct {
+	ID                int64        `json:"id"`
+	OrderID           int64        `json:"order_id"`
+	Provider          string       `json:"provider"`
+	CheckoutSessionID string       `json:"checkout_session_id"`
+	PaymentIntentID   *string      `json:"payment_intent_id,omitempty"`
+	Amount            domain.Money `json:"amount"`
+	Status            string       `json:"status"`
+	CreatedAt         time.Time    `json:"created_at"`
+	UpdatedAt         time.Time    `json:"updated_at"`
+}
+
+type PaymentTransitionResponse struct {
+	FromStatus *string         `json:"from_status,omitempty"`
+	ToStatus   string          `json:"to_status"`
+	EventType  string          `json:"event_type"`
+	RawPayload json.RawMessage `json:"raw_payload,omitempty" swaggertype:"object"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// PaymentDetailResponse is the admin view of a ledger entry
+type PaymentDetailResponse struct {
+	PaymentResponse
+	RawPayload  json.RawMessage             `json:"raw_payload,omitempty" swaggertype:"object"`
+	Transitions []PaymentTransitionResponse `json:"transitions"`
+}
+
+type WebhookEventResponse struct {
+	ID          string          `json:"id"`
+	Provider    string          `json:"provider"`
+	EventType   string          `json:"event_type"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	LastError   *string         `json:"last_error,omitempty"`
+	Payload     json.RawMessage `json:"payload" swaggertype:"object"`
+	ReceivedAt  time.Time       `json:"received_at"`
+	ProcessedAt *time.Time      `json:"processed_at,omitempty"`
+}
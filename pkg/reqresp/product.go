@@ -1,8 +1,29 @@
 package reqresp
 
+import (
+	"go-app-marketplace/pkg/domain"
+	"time"
+)
+
 type ProductCreateRequest struct {
 	Name        string `json:"name" validate:"required"`
 	Description string `json:"description"`
+	// Selects the tax rates that apply; defaults to "standard"
+	TaxClass string `json:"tax_class,omitempty" example:"standard"`
+}
+
+// ProductUpdateRequest replaces all editable fields of a product
+type ProductUpdateRequest struct {
+	Name        string `json:"name" validate:"required,max=255"`
+	Description string `json:"description"`
+	TaxClass    string `json:"tax_class" validate:"required" example:"standard"`
+}
+
+// ProductPatchRequest changes only the fields it carries
+type ProductPatchRequest struct {
+	Name        *string `json:"name,omitempty" validate:"omitempty,min=1,max=255"`
+	Description *string `json:"description,omitempty"`
+	TaxClass    *string `json:"tax_class,omitempty" example:"reduced"`
 }
 
 type ProductCreateResponse struct {
@@ -13,13 +34,94 @@ type ProductResponse struct {
 	ID          int64  `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	TaxClass    string `json:"tax_class"`
+
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+}
+
+// ProductSearchResponse is a search hit. NameHighlight and Snippet are HTML:
+// the product text escaped, with the matched words wrapped in <mark> tags; the
+// snippet is cut from the description.
+type ProductSearchResponse struct {
+	ProductResponse
+	Rank          float64 `json:"rank"`
+	NameHighlight string  `json:"name_highlight" example:"<mark>Coffee</mark> grinder"`
+	Snippet       string  `json:"snippet" example:"Conical burr <mark>coffee</mark> grinder with 40 settings"`
+}
+
+// ProductFilterRequest holds the query parameters of the product listing.
+// Prices are decimal strings in Currency and are compared against each
+// product's best available offer.
+type ProductFilterRequest struct {
+	// Minimum best offer price
+	MinPrice string `query:"min_price" validate:"omitempty,numeric" example:"10.00"`
+	// Maximum best offer price
+	MaxPrice string `query:"max_price" validate:"omitempty,numeric" example:"100.00"`
+	// Currency the prices are given and returned in, USD by default
+	Currency string `query:"currency" validate:"omitempty,len=3" example:"USD"`
+	// Only products with an available offer from this seller
+	SellerID int64 `query:"seller_id" validate:"omitempty,min=1" example:"5"`
+	// Only products that have an available offer with stock left
+	InStock bool `query:"in_stock" example:"true"`
+	// Only products in the category with this slug or any of its subcategories
+	Category string `query:"category" example:"t-shirts"`
+	// One of newest, price_asc, price_desc, best_selling
+	Sort string `query:"sort" validate:"omitempty,oneof=newest price_asc price_desc best_selling" example:"price_asc"`
+}
+
+// ProductListingResponse is a listed product with the price of its best
+// offer in the requested currency
+type ProductListingResponse struct {
+	ProductResponse
+	BestPrice *domain.Money `json:"best_price,omitempty" swaggertype:"object"`
+	InStock   bool          `json:"in_stock"`
+	SoldCount int64         `json:"sold_count"`
+}
+
+// ProductListResponse is a page of products with the facets of the whole listing
+type ProductListResponse struct {
+	PaginatedResponse[ProductListingResponse]
+	Facets ProductFacetsResponse `json:"facets"`
+}
+
+// ProductFacetsResponse tells how many products each filter option would
+// leave, given the other filters in the request
+type ProductFacetsResponse struct {
+	Price   PriceRangeFacet `json:"price"`
+	InStock int64           `json:"in_stock" example:"12"`
+	Sellers []SellerFacet   `json:"sellers"`
+}
+
+// PriceRangeFacet is the cheapest and dearest best price without the price filter
+type PriceRangeFacet struct {
+	Min *domain.Money `json:"min,omitempty" swaggertype:"object"`
+	Max *domain.Money `json:"max,omitempty" swaggertype:"object"`
+}
+
+type SellerFacet struct {
+	SellerID int64  `json:"seller_id" example:"5"`
+	Username string `json:"username" example:"acme"`
+	Count    int64  `json:"count" example:"7"`
 }
 
 type ProductWithOffersResponse struct {
 	ID          int64                `json:"id"`
 	Name        string               `json:"name"`
 	Description string               `json:"description"`
+	TaxClass    string               `json:"tax_class"`
 	Offers      []OfferShortResponse `json:"offers"`
+
+	// The categories the product is in
+	Categories []CategoryRef `json:"categories"`
+	// The path from the root to the product's deepest category
+	Breadcrumb []CategoryRef `json:"breadcrumb"`
+	// The attributes the variants differ by
+	Axes []VariantAxisResponse `json:"axes"`
+	// The variants with their best offers
+	Variants []VariantMatrixEntry `json:"variants"`
+
+	// Set when the product was deleted; it is kept for the order history
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
 }
 
 type PaginationRequest struct {
@@ -1,20 +1,35 @@
 package reqresp
 
-import "time"
+import (
+	"go-app-marketplace/pkg/domain"
+	"time"
+)
 
 type SellerOrderItem struct {
-	ItemID       int64     `db:"item_id"       json:"item_id"`
-	OrderID      int64     `db:"order_id"      json:"order_id"`
-	ProductID    int64     `db:"product_id"    json:"product_id"`
-	ProductName  string    `db:"product_name"  json:"product_name"`
-	Quantity     int       `db:"quantity"      json:"quantity"`
-	UnitPrice    float64   `db:"unit_price"    json:"unit_price"`
-	Status       string    `db:"status"        json:"status"`
-	Paid         bool      `db:"paid"          json:"paid"`
-	PlacedAt     time.Time `db:"placed_at"     json:"placed_at"`
-	CustomerID   int64     `db:"customer_id"   json:"customer_id"`
-	CustomerName string    `db:"customer_name" json:"customer_name"`
-	RefundID     *int64    `db:"refund_id"     json:"refund_id,omitempty"`
-	RefundStatus *string   `db:"refund_status" json:"refund_status,omitempty"`
-	RefundReason *string   `db:"refund_reason" json:"refund_reason,omitempty"`
+	ItemID       int64        `db:"item_id"       json:"item_id"`
+	OrderID      int64        `db:"order_id"      json:"order_id"`
+	ProductID    int64        `db:"product_id"    json:"product_id"`
+	ProductName  string       `db:"product_name"  json:"product_name"`
+	SKU          string       `db:"sku"           json:"sku"`
+	Quantity     int          `db:"quantity"      json:"quantity"`
+	UnitPrice    domain.Money `db:"unit_price"    json:"unit_price"`
+	Currency     string       `db:"currency"      json:"-"`
+	Status       string       `db:"status"        json:"status"`
+	Paid         bool         `db:"paid"          json:"paid"`
+	PlacedAt     time.Time    `db:"placed_at"     json:"placed_at"`
+	CustomerID   int64        `db:"customer_id"   json:"customer_id"`
+	CustomerName string       `db:"customer_name" json:"customer_name"`
+	RefundID     *int64       `db:"refund_id"     json:"refund_id,omitempty"`
+	RefundStatus *string      `db:"refund_status" json:"refund_status,omitempty"`
+	RefundReason *string      `db:"refund_reason" json:"refund_reason,omitempty"`
+
+	// The variant's attribute values when the item was ordered
+	Attributes domain.AttributeValues `db:"attributes" json:"attributes"`
+
+	// Where to ship, as the buyer entered it at checkout
+	ShippingAddress *domain.AddressSnapshot `db:"shipping_address" json:"shipping_address,omitempty"`
+
+	// The seller's shipment of the order that carries the item
+	ShipmentID     int64  `db:"shipment_id"     json:"shipment_id"`
+	ShipmentStatus string `db:"shipment_status" json:"shipment_status"`
 }
@@ -0,0 +1,25 @@
+package reqresp
+
+import "time"
+
+// ShipmentUpdateRequest moves a shipment along pending → packed → shipped →
+// delivered. Without a status only the carrier and tracking number change.
+type ShipmentUpdateRequest struct {
+	Status         string `json:"status,omitempty" validate:"omitempty,oneof=packed shipped delivered" example:"shipped"`
+	Carrier        string `json:"carrier,omitempty" validate:"max=100" example:"DHL"`
+	TrackingNumber string `json:"tracking_number,omitempty" validate:"max=100" example:"JD014600006281230704"`
+}
+
+type ShipmentResponse struct {
+	ID             int64      `json:"id"`
+	OrderID        int64      `json:"order_id"`
+	SellerID       int64      `json:"seller_id"`
+	Status         string     `json:"status"`
+	Carrier        string     `json:"carrier,omitempty"`
+	TrackingNumber string     `json:"tracking_number,omitempty"`
+	ItemIDs        []int64    `json:"item_ids"`
+	ShippedAt      *time.Time `json:"shipped_at,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,46 @@
+package reqresp
+
+import (
+	"go-app-marketplace/pkg/domain"
+	"time"
+)
+
+// ShippingProfileRequest creates or replaces a seller's shipping profile.
+// Amounts are plain decimals in the profile currency.
+type ShippingProfileRequest struct {
+	Name string `json:"name" validate:"required,max=100" example:"Standard"`
+	// flat charges rate once per order, per_item charges it for every unit
+	Method string `json:"method" validate:"required,oneof=flat per_item" example:"flat"`
+	Rate   string `json:"rate" validate:"required" example:"5.00"`
+	// Orders of at least this much from the seller ship free
+	FreeAbove *string `json:"free_above,omitempty" example:"100.00"`
+	Currency  string  `json:"currency" validate:"required,len=3" example:"USD"`
+	IsDefault bool    `json:"is_default" example:"true"`
+	// Rates replacing the profile rate for a country, or a region of it
+	Zones []ShippingZoneRateRequest `json:"zones" validate:"dive"`
+}
+
+type ShippingZoneRateRequest struct {
+	Country string `json:"country" validate:"required,len=2" example:"KZ"`
+	Region  string `json:"region,omitempty" example:"Almaty"`
+	Rate    string `json:"rate" validate:"required" example:"3.00"`
+}
+
+type ShippingProfileResponse struct {
+	ID        int64                      `json:"id"`
+	Name      string                     `json:"name"`
+	Method    string                     `json:"method"`
+	Rate      domain.Money               `json:"rate"`
+	FreeAbove *domain.Money              `json:"free_above,omitempty"`
+	Currency  string                     `json:"currency"`
+	IsDefault bool                       `json:"is_default"`
+	Zones     []ShippingZoneRateResponse `json:"zones"`
+	CreatedAt time.Time                  `json:"created_at"`
+	UpdatedAt time.Time                  `json:"updated_at"`
+}
+
+type ShippingZoneRateResponse struct {
+	Country string       `json:"country"`
+	Region  string       `json:"region,omitempty"`
+	Rate    domain.Money `json:"rate"`
+}
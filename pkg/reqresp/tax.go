@@ -0,0 +1,12 @@
+package reqresp
+
+// TaxRateRequest sets the tax on a product tax class in a country, or in one
+// region of it when region is given
+type TaxRateRequest struct {
+	Country   string `json:"country" validate:"required,len=2" example:"DE"`
+	Region    string `json:"region" example:""`
+	TaxClass  string `json:"tax_class" example:"standard"`
+	Name      string `json:"name" validate:"required" example:"VAT"`
+	Rate      string `json:"rate" validate:"required" example:"19"`
+	Inclusive bool   `json:"inclusive"`
+}
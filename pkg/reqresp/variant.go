@@ -0,0 +1,43 @@
+package reqresp
+
+import "go-app-marketplace/pkg/domain"
+
+// VariantRequest creates or replaces a product variant. Attributes are keyed
+// by the codes of the attributes of the product's categories.
+type VariantRequest struct {
+	SKU        string                 `json:"sku" validate:"required,max=64" example:"TSHIRT-RED-M"`
+	Attributes map[string]interface{} `json:"attributes" swaggertype:"object"`
+}
+
+type VariantResponse struct {
+	ID         int64                  `json:"id" example:"3"`
+	ProductID  int64                  `json:"product_id" example:"42"`
+	SKU        string                 `json:"sku" example:"TSHIRT-RED-M"`
+	Attributes map[string]interface{} `json:"attributes" swaggertype:"object"`
+}
+
+// VariantMatrixEntry is a variant on the product page with its best offer:
+// the cheapest available one with stock, priced in the requested currency
+type VariantMatrixEntry struct {
+	ID         int64                  `json:"id" example:"3"`
+	SKU        string                 `json:"sku" example:"TSHIRT-RED-M"`
+	Attributes map[string]interface{} `json:"attributes" swaggertype:"object"`
+	OfferCount int64                  `json:"offer_count" example:"2"`
+	BestOffer  *VariantOfferResponse  `json:"best_offer,omitempty"`
+}
+
+type VariantOfferResponse struct {
+	OfferID  int64        `json:"offer_id" example:"7"`
+	SellerID int64        `json:"seller_id" example:"5"`
+	Price    domain.Money `json:"price" swaggertype:"object"`
+	Stock    int          `json:"stock" example:"10"`
+}
+
+// VariantAxisResponse is an attribute the variants differ by, with the values
+// they use, so the product page can offer a selector per attribute
+type VariantAxisResponse struct {
+	Code   string        `json:"code" example:"size"`
+	Name   string        `json:"name" example:"Size"`
+	Type   string        `json:"type" example:"enum"`
+	Values []interface{} `json:"values" swaggertype:"array,string" example:"S,M"`
+}